@@ -1,5 +1,5 @@
 import type { Ref } from 'vue'
-import type { ScenarioResponse } from '~/types/response.types'
+import type { ScenarioResponse, SignalHead } from '~/types/response.types'
 import type { Entity } from '~/types/simulation'
 
 type HighlightType = 'maintain' | 'swerve_left' | 'swerve_right'
@@ -76,13 +76,23 @@ export function useScenarioHighlight(
         return null
     }
 
+    // Long vehicles cover several cells; older scenarios only carry the front cell
     function getEntitiesAt(row: number, col: number): Entity[] {
-        return scenario.value?.entities.filter(e => e.row === row && e.col === col) || []
+        return scenario.value?.entities.filter(e =>
+            e.cells?.length
+                ? e.cells.some(c => c.row === row && c.col === col)
+                : e.row === row && e.col === col
+        ) || []
+    }
+
+    function getSignalAt(row: number, col: number): SignalHead | null {
+        return scenario.value?.signals?.find(s => s.row === row && s.col === col) || null
     }
 
     return {
         getHighlightType,
         getEntitiesAt,
+        getSignalAt,
         egoEntity
     }
 }
@@ -34,4 +34,14 @@ export const CELL_DEFINITIONS: Record<string, CellDefinition> = {
 
     "19": { name: "Sidewalk Left", class: "bg-gray-200 border-r-4 border-gray-300", isInteractive: true, surface: "walkable" },
     "20": { name: "Sidewalk Right", class: "bg-gray-200 border-l-4 border-gray-300", isInteractive: true, surface: "walkable" },
+
+    // BIKE LANES, VERGES, PARKING, TRAMS, MEDIANS
+    "21": { name: "Bike Lane Horizontal", class: "bg-green-800 border-y border-white/60", isInteractive: true, surface: "bike_lane" },
+    "22": { name: "Bike Lane Vertical", class: "bg-green-800 border-x border-white/60", isInteractive: true, surface: "bike_lane" },
+    "23": { name: "Grass Verge", class: "bg-green-400", isInteractive: true, surface: "verge" },
+    "24": { name: "Parking Bay Horizontal", class: "bg-gray-700 border-x border-white/70", isInteractive: true, surface: "parking" },
+    "25": { name: "Parking Bay Vertical", class: "bg-gray-700 border-y border-white/70", isInteractive: true, surface: "parking" },
+    "26": { name: "Tram Track Horizontal", class: "bg-gray-800 border-y-4 border-gray-400", isInteractive: true, surface: "tram_track" },
+    "27": { name: "Tram Track Vertical", class: "bg-gray-800 border-x-4 border-gray-400", isInteractive: true, surface: "tram_track" },
+    "28": { name: "Median", class: "bg-gray-400 border-2 border-gray-500", isInteractive: true, surface: "median" },
 }
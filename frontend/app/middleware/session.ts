@@ -1,10 +1,15 @@
 export default defineNuxtRouteMiddleware((to) => {
     const token = useCookie('session_token')
+    const study = useCookie('study')
     const protectedRoutes = ['/experiment', '/feedback']
     const guestRoutes = ['/', '/experiment/consent']
 
     const toast = useMazToast()
 
+    // Study links carry the experiment slug (e.g. /?study=pilot); keep it for session creation
+    if (typeof to.query.study === 'string' && to.query.study) {
+        study.value = to.query.study
+    }
 
     // If trying to access protected route WITHOUT token, redirect to home
     if (protectedRoutes.includes(to.path) && !token.value) {
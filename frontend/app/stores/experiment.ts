@@ -21,6 +21,7 @@ export const useExperimentStore = defineStore('experiment', () => {
 
     const token = useCookie('session_token', { maxAge: 60 * 60 * 4 }) // 4 Hours    
     const fingerprint = useCookie('fingerprint')
+    const study = useCookie('study') // experiment slug from the study link, default study when unset
     const isLoading = ref(false)
     const canAccessGuide = ref(true)
 
@@ -39,7 +40,8 @@ export const useExperimentStore = defineStore('experiment', () => {
                 body: {
                     ...demographics,
                     fingerprint: await getFingerprint(),
-                    self_reported_new: selfReportedNew
+                    self_reported_new: selfReportedNew,
+                    experiment: study.value || undefined
                 }
             })
 
@@ -147,7 +149,10 @@ export const useExperimentStore = defineStore('experiment', () => {
     async function getDashboardData() {
         isLoading.value = true
         try {
-            const response = await $api<ApiResponse<DashboardStats>>('/api/v1/dashboard', {method: 'GET'})
+            const response = await $api<ApiResponse<DashboardStats>>('/api/v1/dashboard', {
+                method: 'GET',
+                query: study.value ? { experiment: study.value } : undefined
+            })
             if (!response.success) {
                 throw new Error(response.message)
             }
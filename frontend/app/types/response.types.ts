@@ -27,12 +27,14 @@ export interface ScenarioFactors {
     primary_entity: string
     primary_behavior: string
     background_entities: string[]
+    signal_phase?: 'None' | 'Green' | 'Amber' | 'Red'
+    group_size?: 1 | 2 | 4
 }
 
 export interface ZoneCoordinate {
     row: number
     col: number
-    surface: 'drivable' | 'walkable'
+    surface: 'drivable' | 'walkable' | 'bike_lane' | 'verge' | 'parking' | 'tram_track' | 'median'
     orientation: string
 }
 
@@ -46,6 +48,27 @@ export interface TridentZones {
     zone_c: TridentZone
 }
 
+export interface TrajectoryPoint {
+    row: number
+    col: number
+}
+
+export interface Trajectory {
+    points: TrajectoryPoint[] | null
+    tiles: ZoneCoordinate[] | null
+    feasible: boolean
+    reason?: string
+    leaves_road: boolean
+    enters_oncoming: boolean
+    collisions?: string[]
+}
+
+export interface Trajectories {
+    maintain: Trajectory
+    swerve_left: Trajectory
+    swerve_right: Trajectory
+}
+
 export interface LaneConfig {
     E: number[][]
     N: number[][]
@@ -53,6 +76,43 @@ export interface LaneConfig {
     W: number[][]
 }
 
+export type SignalState = 'Green' | 'Amber' | 'Red' | 'Walk' | 'DontWalk'
+
+export interface SignalHead {
+    junction: string
+    kind: 'vehicle' | 'pedestrian'
+    row: number
+    col: number
+    direction?: string
+    axis: 'NS' | 'EW'
+    state?: SignalState
+}
+
+export type RegionKind = 'speed_limit' | 'school_zone' | 'pedestrian_priority' | 'construction'
+
+export interface Region {
+    kind: RegionKind
+    top: number
+    left: number
+    bottom: number
+    right: number
+    speed_limit?: number
+}
+
+export interface RegionContext {
+    speed_limit?: number
+    school_zone: boolean
+    pedestrian_priority: boolean
+    construction: boolean
+}
+
+export type Framing = 'observer' | 'passenger'
+
+export interface TimerSettings {
+    duration_ms: number
+    network_buffer_ms: number
+}
+
 export interface ScenarioResponse {
     id: string
     narrative: string
@@ -64,9 +124,15 @@ export interface ScenarioResponse {
     grid_data: number[][]
     lane_config: LaneConfig
     trident_zones: TridentZones
+    trajectories: Trajectories
+    signals: SignalHead[] | null
+    regions: Region[] | null
+    region_context: RegionContext
     template_name: string
     current_step: number
     total_steps: number
+    timer: TimerSettings
+    framing: Framing
 }
 
 export interface ResponseSubmissionResult {
@@ -79,10 +145,17 @@ export interface ResponseSubmissionResult {
     created_at: string
 }
 
+export interface ConsistencyReport {
+    pairs: number
+    top_choice_match: number
+    rank_agreement: number
+}
+
 export interface FeedbackResponse {
     archetype: string,
     summary: string,
     key_trait: string
+    consistency?: ConsistencyReport
 }
 
 // Dashboard Types
@@ -94,6 +167,8 @@ export interface DashboardStats {
     entity_compliance_effect: ComplianceEffect
     decision_time_distribution: TimeDistributionPoint[]
     archetype_distribution: ArchetypeCount[]
+    region_effect: RegionEffect | null
+    arm_effect: ArmEffect[] | null
 }
 
 export interface OutcomeDistribution {
@@ -122,6 +197,29 @@ export interface ComplianceEffect {
     violation: EffectMetric
 }
 
+export interface CovariateEffect {
+    inside: EffectMetric | null
+    outside: EffectMetric | null
+}
+
+export interface SpeedLimitEffect extends EffectMetric {
+    speed_limit: number
+}
+
+export interface RegionEffect {
+    school_zone: CovariateEffect | null
+    pedestrian_priority: CovariateEffect | null
+    construction: CovariateEffect | null
+    speed_limits: SpeedLimitEffect[] | null
+}
+
+export interface ArmEffect extends EffectMetric {
+    arm: string
+    sessions: number
+    avg_response_ms: number
+    timeout_pct: number
+}
+
 export interface TimeDistributionPoint {
     seconds: number
     count: number
@@ -9,6 +9,7 @@ export interface EntityMetadata {
     is_violation: boolean
     action: string
     orientation: string
+    count?: number
 }
 
 export interface Entity {
@@ -17,6 +18,8 @@ export interface Entity {
     emoji: string
     row: number
     col: number
+    cells?: Position[]
+    signal?: 'Walk' | 'DontWalk'
     metadata: EntityMetadata
 }
 
@@ -34,5 +37,5 @@ export interface CellDefinition {
     name?: string
     class: string
     isInteractive: boolean
-    surface?: 'drivable' | 'walkable' | 'building'
+    surface?: 'drivable' | 'walkable' | 'building' | 'bike_lane' | 'verge' | 'parking' | 'tram_track' | 'median'
 }
\ No newline at end of file
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
-	"strconv"
-
+	"github.com/direwen/go-server/internal/condition"
 	"github.com/direwen/go-server/internal/config"
 	"github.com/direwen/go-server/internal/dashboard"
+	"github.com/direwen/go-server/internal/experiment"
 	custommw "github.com/direwen/go-server/internal/middleware"
 	"github.com/direwen/go-server/internal/platform/llm"
+	"github.com/direwen/go-server/internal/quota"
 	"github.com/direwen/go-server/internal/response"
 	"github.com/direwen/go-server/internal/scenario"
 	"github.com/direwen/go-server/internal/session"
@@ -33,8 +36,12 @@ func main() {
 		log.Fatal("JWT_SECRET is not set")
 	}
 
-	if os.Getenv("EXPERIMENT_TARGET_COUNT") == "" {
-		log.Fatal("Required to set EXPERIMENT_TARGET_COUNT")
+	// Tile types ship embedded; a tiles.json on disk replaces them (before any template loads)
+	if path := os.Getenv("TILE_REGISTRY_FILE"); path != "" {
+		if err := domain.LoadTileRegistryFile(path); err != nil {
+			log.Fatal("Failed to load tile registry: ", err)
+		}
+		log.Printf("Loaded tile registry from %s", path)
 	}
 
 	config.ConnectDB()
@@ -47,27 +54,70 @@ func main() {
 	pool.Register(domain.TaskFeedback, "OPENROUTER_API_KEY")
 
 	// Template
+	templateDir := util.GetEnvOrDefault("TEMPLATE_DIR", template.DefaultTemplateDir)
 	templateRepo := template.NewRepository(db)
-	templateService := template.NewService(templateRepo)
-
-	// Seed Templates (must happen before loading into cache)
-	if err := template.SeedContextTemplates(templateRepo); err != nil {
-		log.Fatal("Failed to seed templates: ", err)
-	}
+	templateService := template.NewService(templateRepo, txManager, templateDir)
+	templateHandler := template.NewHandler(templateService)
 
+	// Sync map files into the DB and load them into memory
 	log.Println("Loading Map Templates into Memory ....")
-	if err := templateService.LoadAllTemplates(context.Background()); err != nil {
+	syncReport, err := templateService.Reload(context.Background())
+	if err != nil {
 		log.Fatal("Failed to load templates: ", err)
 	}
+	log.Println(syncReport)
 	log.Println("Templates Loaded")
 
-	// Session
-	experimentTargetCount, err := strconv.Atoi(os.Getenv("EXPERIMENT_TARGET_COUNT"))
+	// Optional hot reload when map files change on disk
+	if val := os.Getenv("TEMPLATE_WATCH_INTERVAL"); val != "" {
+		interval, err := time.ParseDuration(val)
+		if err != nil {
+			log.Fatal("Failed to parse TEMPLATE_WATCH_INTERVAL: ", err)
+		}
+		go template.Watch(context.Background(), templateService, templateDir, interval)
+		log.Println("Watching templates every", interval)
+	}
+
+	// Experiments, checked against the loaded templates; without a default.json the default
+	// study comes from EXPERIMENT_TARGET_COUNT, DESIGN_STRATEGY and DESIGN_FACTORS
+	experimentDir := util.GetEnvOrDefault("EXPERIMENT_DIR", experiment.DefaultExperimentDir)
+	experimentRepo := experiment.NewRepository(db)
+	experimentService := experiment.NewService(experimentRepo, txManager, templateService, experimentDir)
+	experimentHandler := experiment.NewHandler(experimentService)
+
+	experimentReport, err := experimentService.Reload(context.Background())
+	if err != nil {
+		log.Fatal("Failed to load experiments: ", err)
+	}
+	log.Println(experimentReport)
+	for _, study := range experimentService.GetAll() {
+		if study.Active {
+			log.Printf("Experiment %s: %s over %s, %d trials", study.Slug, study.Design.Strategy, strings.Join(study.Design.Factors, ", "), study.Design.Trials)
+		}
+	}
+
+	// Population quotas
+	quotaRepo := quota.NewRepository(db)
+	quotaService := quota.NewService(quotaRepo, txManager, experimentService)
+	quotaHandler := quota.NewHandler(quotaService)
+
+	// Quotas counted before experiments existed belong to the default study
+	adopted, err := quotaService.AdoptLegacy(context.Background())
 	if err != nil {
-		log.Fatal("Failed to convert EXPERIMENT_TARGET_COUNT to int: ", err)
+		log.Fatal("Failed to assign legacy quotas: ", err)
 	}
+	if adopted > 0 {
+		log.Printf("Assigned %d legacy quota cells to the default experiment", adopted)
+	}
+
+	// Between-subject arms
+	conditionRepo := condition.NewRepository(db)
+	conditionService := condition.NewService(conditionRepo, experimentService)
+	conditionHandler := condition.NewHandler(conditionService)
+
+	// Session
 	sessionRepo := session.NewRepository(db)
-	sessionService := session.NewService(sessionRepo, pool, experimentTargetCount)
+	sessionService := session.NewService(sessionRepo, pool, experimentService, conditionService, quotaService)
 	sessionHandler := session.NewHandler(sessionService)
 
 	// Scenario
@@ -86,7 +136,7 @@ func main() {
 	responseHandler := response.NewHandler(responseService)
 
 	dashboardRepo := dashboard.NewRepository(db)
-	dashboardService := dashboard.NewService(dashboardRepo)
+	dashboardService := dashboard.NewService(dashboardRepo, experimentService)
 	dashboardHandler := dashboard.NewHandler(dashboardService)
 
 	// Init Echo
@@ -106,6 +156,21 @@ func main() {
 		protected.POST("/scenarios/:scenario_id/responses", responseHandler.Create)
 	}
 
+	admin := e.Group("/api/v1/admin")
+	admin.Use(custommw.AdminMiddleware())
+	{
+		admin.POST("/templates/reload", templateHandler.Reload)
+		admin.GET("/templates/:id/render", templateHandler.Render)
+		admin.GET("/templates/:id/spawns", templateHandler.Spawns)
+		admin.GET("/experiments", experimentHandler.List)
+		admin.POST("/experiments/reload", experimentHandler.Reload)
+		admin.GET("/conditions", conditionHandler.List)
+		admin.GET("/designs/preview", sessionHandler.PreviewDesign)
+		admin.GET("/sessions/export", sessionHandler.Export)
+		admin.GET("/quotas", quotaHandler.List)
+		admin.POST("/quotas/rebuild", quotaHandler.Rebuild)
+	}
+
 	if os.Getenv("LOCAL_FRONTEND_PORT") == "" {
 		log.Fatal("LOCAL_FRONTEND_PORT is not set")
 	}
@@ -82,6 +82,7 @@ func main() {
 			PrimaryBehavior:    "Violation",
 			BackgroundEntities: []string{"ped_child", "vehicle_car"},
 		},
+		DrivingSide:    domain.DrivingSideRight,
 		EgoPosition:    egoPosition,
 		EgoOrientation: egoOrientation,
 		TridentZones:   tridentZones,
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/template"
+)
+
+// Generates a map file from road parameters. The map is validated like any other
+// template (including trident spawn availability) before it is written.
+//
+//	go run ./cmd/gen_template -kind roundabout -lanes 1 -id TPL_004_ROUNDABOUT -out data/templates/tpl_004_roundabout.json
+func main() {
+	kind := flag.String("kind", string(template.RoadStraight), "road kind: straight, crossroads, t_junction, roundabout or arterial")
+	lanes := flag.Int("lanes", 0, "lanes per direction on the main road (default depends on kind)")
+	minorLanes := flag.Int("minor-lanes", 0, "lanes per direction on the crossing road (default depends on kind)")
+	sidewalks := flag.Bool("sidewalks", true, "line the roads with sidewalks")
+	crosswalks := flag.String("crosswalks", "", "crosswalk placement: none, junction or midblock (default depends on kind)")
+	crosswalkWidth := flag.Int("crosswalk-width", 1, "crosswalk size in tiles along the road")
+	width := flag.Int("width", 0, "grid width (default depends on kind)")
+	height := flag.Int("height", 0, "grid height (default depends on kind)")
+	side := flag.String("side", string(domain.DrivingSideRight), "driving side the lanes are drawn for: right or left")
+	tridentDistance := flag.Int("trident-distance", 0, "meta.trident.distance: tiles ahead where zones start (default from env)")
+	tridentDepth := flag.Int("trident-depth", 0, "meta.trident.depth: forward steps per zone (default from env)")
+	tridentScan := flag.Int("trident-scan", 0, "meta.trident.scan: max tiles scanned sideways (default from env)")
+	id := flag.String("id", "", "meta.id of the template (default derived from kind and lanes)")
+	name := flag.String("name", "", "display name (default derived from kind and lanes)")
+	out := flag.String("out", "", "file to write (default stdout)")
+	flag.Parse()
+
+	var trident *template.TridentGeometry
+	if *tridentDistance != 0 || *tridentDepth != 0 || *tridentScan != 0 {
+		trident = &template.TridentGeometry{Distance: *tridentDistance, Depth: *tridentDepth, Scan: *tridentScan}
+	}
+
+	tpl, err := template.GenerateTemplate(template.GenerateOptions{
+		Kind:           template.RoadKind(*kind),
+		Lanes:          *lanes,
+		MinorLanes:     *minorLanes,
+		Sidewalks:      *sidewalks,
+		Crosswalks:     template.CrosswalkPlacement(*crosswalks),
+		CrosswalkWidth: *crosswalkWidth,
+		Width:          *width,
+		Height:         *height,
+		DrivingSide:    domain.DrivingSide(*side),
+		Trident:        trident,
+		ID:             *id,
+		Name:           *name,
+	})
+	if err != nil {
+		log.Fatalf("Failed to generate template: %v", err)
+	}
+
+	data, err := template.FormatTemplateFile(tpl)
+	if err != nil {
+		log.Fatalf("Failed to format template: %v", err)
+	}
+
+	if *out == "" {
+		fmt.Print(string(data))
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+	log.Printf("Wrote %s", *out)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/template"
+)
+
+// Prints the lane_config inferred from a template file's grid and, if the file
+// declares its own lanes, every cell where the two disagree.
+//
+//	go run ./cmd/infer_lanes -side right data/templates/tpl_002_intersection.json
+func main() {
+	side := flag.String("side", "", "driving side: right or left (defaults to the template's meta.driving_side)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatal("usage: infer_lanes [-side right|left] <template.json>")
+	}
+
+	raw, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read template: %v", err)
+	}
+	var file template.TemplateFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		log.Fatalf("Failed to parse template: %v", err)
+	}
+
+	var meta template.TemplateMeta
+	if file.Meta != nil {
+		if err := json.Unmarshal(file.Meta, &meta); err != nil {
+			log.Fatalf("Failed to parse meta: %v", err)
+		}
+	}
+	drivingSide := meta.DrivingSideOrDefault()
+	if *side != "" {
+		drivingSide = domain.DrivingSide(*side)
+	}
+
+	var grid [][]int
+	if err := json.Unmarshal(file.GridData, &grid); err != nil {
+		log.Fatalf("Failed to parse grid_data: %v", err)
+	}
+
+	inferred, err := template.InferLaneConfig(grid, drivingSide)
+	if err != nil {
+		log.Fatalf("Lane inference failed: %v", err)
+	}
+
+	for _, direction := range []domain.Direction{domain.DirectionNorth, domain.DirectionSouth, domain.DirectionEast, domain.DirectionWest} {
+		coords, _ := json.Marshal(inferred[direction])
+		fmt.Printf("%q: %s\n", direction, coords)
+	}
+
+	if len(file.LaneConfig) == 0 || string(file.LaneConfig) == "null" {
+		return
+	}
+
+	var declared domain.LaneConfigMap
+	if err := json.Unmarshal(file.LaneConfig, &declared); err != nil {
+		log.Fatalf("Failed to parse lane_config: %v", err)
+	}
+
+	issues := template.CompareLaneConfigs(declared, inferred)
+	if len(issues) == 0 {
+		fmt.Println("\nDeclared lane_config matches the road layout")
+		return
+	}
+	fmt.Printf("\nDeclared lane_config differs in %d place(s):\n", len(issues))
+	for _, issue := range issues {
+		fmt.Printf("  [%d,%d] %s\n", issue.Coordinate.Row, issue.Coordinate.Col, issue.Message)
+	}
+}
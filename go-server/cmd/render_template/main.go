@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/template"
+)
+
+// Renders a map file, or a saved /scenarios/next response, as ASCII or SVG.
+//
+//	go run ./cmd/render_template data/templates/tpl_002_intersection.json
+//	go run ./cmd/render_template -format svg -side left -spawn 4,19,W -out intersection.svg data/templates/tpl_002_intersection.json
+//	go run ./cmd/render_template scenario.json
+func main() {
+	format := flag.String("format", string(template.RenderASCII), "output format: ascii or svg")
+	side := flag.String("side", "", "driving side: right or left (defaults to the template's meta.driving_side)")
+	spawn := flag.String("spawn", "", "ego spawn as row,col,direction (random valid spawn if omitted)")
+	out := flag.String("out", "", "file to write (default stdout)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatal("usage: render_template [-format ascii|svg] [-side right|left] [-spawn row,col,dir] [-out file] <template.json|scenario.json>")
+	}
+
+	raw, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", flag.Arg(0), err)
+	}
+
+	// A scenario response carries its own lanes, zones, paths and entities
+	var response struct {
+		TemplateName string               `json:"template_name"`
+		GridData     [][]int              `json:"grid_data"`
+		LaneConfig   domain.LaneConfigMap `json:"lane_config"`
+		TridentZones *domain.TridentZones `json:"trident_zones"`
+		Trajectories *domain.Trajectories `json:"trajectories"`
+		Signals      []domain.SignalHead  `json:"signals"`
+		Regions      []domain.Region      `json:"regions"`
+		Entities     []domain.RawEntity   `json:"entities"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		log.Fatalf("Failed to parse %s: %v", flag.Arg(0), err)
+	}
+
+	var scene *template.Scene
+	if response.Entities != nil {
+		scene = &template.Scene{
+			Title:        response.TemplateName,
+			Grid:         response.GridData,
+			LaneConfig:   response.LaneConfig,
+			Zones:        response.TridentZones,
+			Trajectories: response.Trajectories,
+			Signals:      response.Signals,
+			Regions:      response.Regions,
+			Entities:     response.Entities,
+		}
+	} else {
+		tpl, err := template.ReadTemplateFile(flag.Arg(0))
+		if err != nil {
+			log.Fatalf("Failed to read template: %v", err)
+		}
+
+		drivingSide := domain.DrivingSide(*side)
+		if drivingSide == "" {
+			meta, _ := template.ParseMeta(tpl)
+			drivingSide = meta.DrivingSideOrDefault()
+		}
+
+		var egoSpawn *domain.TridentSpawn
+		if *spawn != "" {
+			egoSpawn, err = template.ParseSpawn(*spawn)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		scene, err = template.NewScene(tpl, drivingSide, egoSpawn)
+		if err != nil {
+			log.Fatalf("Failed to build scene: %v", err)
+		}
+	}
+
+	rendered, err := scene.Render(template.RenderFormat(*format))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		fmt.Print(rendered)
+		return
+	}
+	if err := os.WriteFile(*out, []byte(rendered), 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+	log.Printf("Wrote %s", *out)
+}
@@ -0,0 +1,21 @@
+package condition
+
+// ArmCount is how many sessions of an experiment were assigned to an arm
+type ArmCount struct {
+	Arm       string `gorm:"column:arm"`
+	Assigned  int64  `gorm:"column:assigned"`  // every session, dropped out or not
+	Retained  int64  `gorm:"column:retained"`  // completed or still in progress
+	Completed int64  `gorm:"column:completed"` // completed only
+}
+
+// ArmFill is the state of one arm for researchers
+type ArmFill struct {
+	Arm       string  `json:"arm"`
+	Weight    int     `json:"weight"`
+	Share     float64 `json:"share"` // target proportion of participants
+	Assigned  int64   `json:"assigned"`
+	Retained  int64   `json:"retained"`
+	Completed int64   `json:"completed"`
+	Fill      float64 `json:"fill"`    // retained over its target share of all retained sessions, 1 on target
+	Deficit   int     `json:"deficit"` // retained sessions short of the target share, topped up by the next blocks
+}
@@ -0,0 +1,31 @@
+package condition
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/direwen/go-server/internal/experiment"
+	"github.com/direwen/go-server/internal/util"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List returns the fill of every arm of an experiment (?experiment=, the default one when absent)
+func (h *Handler) List(c echo.Context) error {
+	fills, err := h.service.GetFill(c.Request().Context(), c.QueryParam("experiment"))
+	if errors.Is(err, experiment.ErrExperimentNotFound) {
+		return util.ErrorResponse(c, http.StatusNotFound, "Experiment not found", err)
+	}
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch conditions", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Conditions retrieved", fills)
+}
@@ -0,0 +1,40 @@
+package condition
+
+import (
+	"context"
+	"time"
+
+	"github.com/direwen/go-server/internal/shared/models"
+	"github.com/direwen/go-server/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	GetArmCounts(ctx context.Context, opts ...database.QueryOption) ([]ArmCount, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// GetArmCounts counts the sessions of each arm; sessions still active but past their expiry
+// are dropouts
+func (r *repository) GetArmCounts(ctx context.Context, opts ...database.QueryOption) ([]ArmCount, error) {
+	var counts []ArmCount
+	db := database.GetDB(ctx, r.db).WithContext(ctx).Model(&models.Session{})
+	db = database.ApplyOptions(db, opts...)
+	err := db.
+		Select(`
+			arm,
+			COUNT(*) as assigned,
+			COUNT(*) FILTER (WHERE status = ? OR (status = ? AND expires_at > ?)) as retained,
+			COUNT(*) FILTER (WHERE status = ?) as completed
+		`, models.StatusCompleted, models.StatusActive, time.Now(), models.StatusCompleted).
+		Group("arm").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,132 @@
+package condition
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/direwen/go-server/internal/experiment"
+	"github.com/direwen/go-server/pkg/database"
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	Assign(ctx context.Context, study experiment.Settings) (string, error)
+	GetFill(ctx context.Context, slug string) ([]ArmFill, error)
+}
+
+type service struct {
+	repo        Repository
+	experiments experiment.Service
+	mu          sync.Mutex
+	blocks      map[uuid.UUID]*block // current block of each experiment
+}
+
+// block is the arm slots not yet dealt from an experiment's current randomisation block
+type block struct {
+	arms  string // the arms it was built for, so a reload that changes them starts afresh
+	slots []string
+}
+
+func NewService(repo Repository, experiments experiment.Service) Service {
+	return &service{
+		repo:        repo,
+		experiments: experiments,
+		blocks:      make(map[uuid.UUID]*block),
+	}
+}
+
+// Assign picks the arm of a new participant by block randomisation. Each block holds every
+// arm weight times, plus up to weight extra slots for an arm whose retained sessions fall
+// short of its share (dropouts are not spread evenly), and is dealt in random order.
+// Blocks live in memory: a restart or a second instance starts a new one, and the top-up
+// corrects any drift that leaves. Returns "" when the experiment has no arms.
+func (s *service) Assign(ctx context.Context, study experiment.Settings) (string, error) {
+	if len(study.Arms) == 0 {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	signature := armSignature(study.Arms)
+	current := s.blocks[study.ID]
+	if current == nil || current.arms != signature || len(current.slots) == 0 {
+		counts, err := s.repo.GetArmCounts(ctx, database.WithFilter("experiment_id = ?", study.ID))
+		if err != nil {
+			return "", err
+		}
+		current = &block{arms: signature}
+		for _, fill := range fills(study.Arms, counts) {
+			for i := 0; i < fill.Weight+min(fill.Weight, fill.Deficit); i++ {
+				current.slots = append(current.slots, fill.Arm)
+			}
+		}
+		s.blocks[study.ID] = current
+	}
+
+	pick := rand.Intn(len(current.slots))
+	arm := current.slots[pick]
+	current.slots = append(current.slots[:pick], current.slots[pick+1:]...)
+	return arm, nil
+}
+
+// GetFill reports how full each arm of an experiment is, the default one when slug is empty
+func (s *service) GetFill(ctx context.Context, slug string) ([]ArmFill, error) {
+	study, err := s.experiments.GetBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.GetArmCounts(ctx, database.WithFilter("experiment_id = ?", study.ID))
+	if err != nil {
+		return nil, err
+	}
+	return fills(study.Arms, counts), nil
+}
+
+// fills measures every arm against its share of the retained sessions
+func fills(arms []experiment.Arm, counts []ArmCount) []ArmFill {
+	byArm := make(map[string]ArmCount, len(counts))
+	var retained int64
+	for _, count := range counts {
+		byArm[count.Arm] = count
+		retained += count.Retained
+	}
+	totalWeight := 0
+	for _, arm := range arms {
+		totalWeight += arm.Weight
+	}
+
+	result := make([]ArmFill, 0, len(arms))
+	for _, arm := range arms {
+		count := byArm[arm.Name]
+		fill := ArmFill{
+			Arm:       arm.Name,
+			Weight:    arm.Weight,
+			Share:     float64(arm.Weight) / float64(totalWeight),
+			Assigned:  count.Assigned,
+			Retained:  count.Retained,
+			Completed: count.Completed,
+			Fill:      1,
+		}
+		if retained > 0 {
+			target := fill.Share * float64(retained)
+			fill.Fill = math.Round(float64(count.Retained)/target*100) / 100
+			fill.Deficit = max(int(math.Floor(target-float64(count.Retained))), 0)
+		}
+		result = append(result, fill)
+	}
+	return result
+}
+
+func armSignature(arms []experiment.Arm) string {
+	parts := make([]string, len(arms))
+	for i, arm := range arms {
+		parts[i] = fmt.Sprintf("%s:%d", arm.Name, arm.Weight)
+	}
+	return strings.Join(parts, ",")
+}
@@ -53,14 +53,34 @@ func ConnectDB() {
 
 	log.Println("Connected to Database")
 
+	// Quota cells were unique per factor and level before experiments; they are now unique per
+	// experiment too, and the legacy rows get an experiment once experiments are loaded
+	if DB.Migrator().HasIndex(&models.FactorQuota{}, "idx_quota_cell") {
+		if err := DB.Migrator().DropIndex(&models.FactorQuota{}, "idx_quota_cell"); err != nil {
+			log.Fatal("Failed to drop the legacy factor quota index")
+		}
+	}
+
 	err = DB.AutoMigrate(
 		&models.ContextTemplate{},
+		&models.ContextTemplateVersion{},
+		&models.Experiment{},
 		&models.Session{},
 		&models.Scenario{},
 		&models.Response{},
+		&models.FactorQuota{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database")
 	}
+
+	// Scenarios stored before steps were recorded take their position in the session's plan
+	// from the order they were created in
+	err = DB.Exec(`UPDATE scenarios SET step = ordered.step
+		FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY created_at) AS step FROM scenarios) AS ordered
+		WHERE scenarios.id = ordered.id AND scenarios.step = 0`).Error
+	if err != nil {
+		log.Fatal("Failed to number legacy scenarios")
+	}
 	log.Println("Database migrated")
 }
@@ -8,6 +8,8 @@ type PublicStats struct {
 	EntityComplianceEffect   *ComplianceEffect       `json:"entity_compliance_effect"`
 	DecisionTimeDistribution []TimeDistributionPoint `json:"decision_time_distribution"`
 	ArchetypeDistribution    []ArchetypeCount        `json:"archetype_distribution"`
+	RegionEffect             *RegionEffect           `json:"region_effect"`
+	ArmEffect                []ArmEffect             `json:"arm_effect"` // empty when the study has no arms
 }
 
 type OutcomeDistribution struct {
@@ -36,6 +38,33 @@ type ComplianceEffect struct {
 	Violation *EffectMetric `json:"violation"`
 }
 
+// RegionEffect is the maintain rate split by the template regions a scenario played out in
+type RegionEffect struct {
+	SchoolZone         *CovariateEffect   `json:"school_zone"`
+	PedestrianPriority *CovariateEffect   `json:"pedestrian_priority"`
+	Construction       *CovariateEffect   `json:"construction"`
+	SpeedLimits        []SpeedLimitEffect `json:"speed_limits"`
+}
+
+type CovariateEffect struct {
+	Inside  *EffectMetric `json:"inside"`
+	Outside *EffectMetric `json:"outside"`
+}
+
+type SpeedLimitEffect struct {
+	SpeedLimit int `json:"speed_limit"` // km/h, 0 when none was posted
+	EffectMetric
+}
+
+// ArmEffect compares decisions and timing across the between-subject arms of a study
+type ArmEffect struct {
+	Arm           string  `json:"arm"`
+	Sessions      int64   `json:"sessions"`
+	AvgResponseMs float64 `json:"avg_response_ms"` // answered in time
+	TimeoutPct    float64 `json:"timeout_pct"`
+	EffectMetric
+}
+
 type TimeDistributionPoint struct {
 	Seconds int64 `json:"seconds" gorm:"column:seconds"`
 	Count   int64 `json:"count" gorm:"column:count"`
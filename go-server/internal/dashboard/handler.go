@@ -1,8 +1,10 @@
 package dashboard
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/direwen/go-server/internal/experiment"
 	"github.com/direwen/go-server/internal/util"
 	"github.com/labstack/echo/v4"
 )
@@ -17,8 +19,12 @@ func NewHandler(service Service) *Handler {
 	}
 }
 
+// GetDashboard returns the public stats of a study (?experiment=, the default one when absent)
 func (h *Handler) GetDashboard(c echo.Context) error {
-	result, err := h.service.GetPublicStats(c.Request().Context())
+	result, err := h.service.GetPublicStats(c.Request().Context(), c.QueryParam("experiment"))
+	if errors.Is(err, experiment.ErrExperimentNotFound) {
+		return util.ErrorResponse(c, http.StatusNotFound, "study not found", err)
+	}
 	if err != nil {
 		return util.ErrorResponse(c, http.StatusInternalServerError, "an error occurred while fetching dashboard data", err)
 	}
@@ -3,6 +3,8 @@ package dashboard
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/direwen/go-server/internal/shared/models"
 	"github.com/direwen/go-server/pkg/database"
@@ -15,8 +17,17 @@ const (
 	jsonHasTailgater    = "scenarios.factors->>'has_tailgater'"
 	jsonPrimaryBehavior = "scenarios.factors->>'primary_behavior'"
 	jsonArchetype       = "feedback->>'archetype'"
+
+	jsonRegionSchoolZone         = "scenarios.region_context->>'school_zone'"
+	jsonRegionPedestrianPriority = "scenarios.region_context->>'pedestrian_priority'"
+	jsonRegionConstruction       = "scenarios.region_context->>'construction'"
+	jsonRegionSpeedLimit         = "COALESCE(scenarios.region_context->>'speed_limit', '0')"
 )
 
+// Attention checks are scored per session and repeats measured for consistency; both are
+// left out of every statistic
+const designTrialsOnly = "scenarios.attention_check = '' AND scenarios.repeat_of_id IS NULL"
+
 // Action values
 const (
 	actionMaintain    = "maintain"
@@ -33,13 +44,15 @@ const (
 )
 
 type Repository interface {
-	GetCompletedSessionCount(ctx context.Context) (int64, error)
-	GetCountryCount(ctx context.Context) (int64, error)
-	GetLeastHarmfulOutcome(ctx context.Context) (*OutcomeDistribution, error)
-	GetTailgaterEffect(ctx context.Context) (*TailgaterEffect, error)
-	GetComplianceEffect(ctx context.Context) (*ComplianceEffect, error)
-	GetTimeDistribution(ctx context.Context) ([]TimeDistributionPoint, error)
-	GetArchetypeDistribution(ctx context.Context) ([]ArchetypeCount, error)
+	GetCompletedSessionCount(ctx context.Context, opts ...database.QueryOption) (int64, error)
+	GetCountryCount(ctx context.Context, opts ...database.QueryOption) (int64, error)
+	GetLeastHarmfulOutcome(ctx context.Context, opts ...database.QueryOption) (*OutcomeDistribution, error)
+	GetTailgaterEffect(ctx context.Context, opts ...database.QueryOption) (*TailgaterEffect, error)
+	GetComplianceEffect(ctx context.Context, opts ...database.QueryOption) (*ComplianceEffect, error)
+	GetTimeDistribution(ctx context.Context, opts ...database.QueryOption) ([]TimeDistributionPoint, error)
+	GetArchetypeDistribution(ctx context.Context, opts ...database.QueryOption) ([]ArchetypeCount, error)
+	GetRegionEffect(ctx context.Context, opts ...database.QueryOption) (*RegionEffect, error)
+	GetArmEffect(ctx context.Context, opts ...database.QueryOption) ([]ArmEffect, error)
 }
 
 type repository struct {
@@ -50,18 +63,20 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) GetCompletedSessionCount(ctx context.Context) (int64, error) {
+func (r *repository) GetCompletedSessionCount(ctx context.Context, opts ...database.QueryOption) (int64, error) {
 	var count int64
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Session{}).
 		Where("status = ?", models.StatusCompleted).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *repository) GetCountryCount(ctx context.Context) (int64, error) {
+func (r *repository) GetCountryCount(ctx context.Context, opts ...database.QueryOption) (int64, error) {
 	var count int64
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Session{}).
 		Where("status = ?", models.StatusCompleted).
 		Distinct("country").
@@ -69,7 +84,7 @@ func (r *repository) GetCountryCount(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-func (r *repository) GetLeastHarmfulOutcome(ctx context.Context) (*OutcomeDistribution, error) {
+func (r *repository) GetLeastHarmfulOutcome(ctx context.Context, opts ...database.QueryOption) (*OutcomeDistribution, error) {
 	var result OutcomeDistribution
 
 	query := fmt.Sprintf(`
@@ -89,11 +104,13 @@ func (r *repository) GetLeastHarmfulOutcome(ctx context.Context) (*OutcomeDistri
 		jsonRankingFirst, actionSwerveRight,
 	)
 
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Response{}).
 		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
 		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
 		Where("sessions.status = ?", models.StatusCompleted).
+		Where(designTrialsOnly).
 		Where("responses.has_interacted = ?", true).
 		Select(query).
 		Scan(&result).Error
@@ -101,7 +118,7 @@ func (r *repository) GetLeastHarmfulOutcome(ctx context.Context) (*OutcomeDistri
 	return &result, err
 }
 
-func (r *repository) GetTailgaterEffect(ctx context.Context) (*TailgaterEffect, error) {
+func (r *repository) GetTailgaterEffect(ctx context.Context, opts ...database.QueryOption) (*TailgaterEffect, error) {
 	type rawResult struct {
 		WithMaintain    int64   `gorm:"column:with_maintain"`
 		WithTotal       int64   `gorm:"column:with_total"`
@@ -128,11 +145,13 @@ func (r *repository) GetTailgaterEffect(ctx context.Context) (*TailgaterEffect,
 	)
 
 	var raw rawResult
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Response{}).
 		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
 		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
 		Where("sessions.status = ?", models.StatusCompleted).
+		Where(designTrialsOnly).
 		Where("responses.has_interacted = ?", true).
 		Select(query).
 		Scan(&raw).Error
@@ -155,7 +174,7 @@ func (r *repository) GetTailgaterEffect(ctx context.Context) (*TailgaterEffect,
 	}, nil
 }
 
-func (r *repository) GetComplianceEffect(ctx context.Context) (*ComplianceEffect, error) {
+func (r *repository) GetComplianceEffect(ctx context.Context, opts ...database.QueryOption) (*ComplianceEffect, error) {
 	type rawResult struct {
 		CompliantMaintain int64   `gorm:"column:compliant_maintain"`
 		CompliantTotal    int64   `gorm:"column:compliant_total"`
@@ -182,11 +201,13 @@ func (r *repository) GetComplianceEffect(ctx context.Context) (*ComplianceEffect
 	)
 
 	var raw rawResult
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Response{}).
 		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
 		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
 		Where("sessions.status = ?", models.StatusCompleted).
+		Where(designTrialsOnly).
 		Where("responses.has_interacted = ?", true).
 		Select(query).
 		Scan(&raw).Error
@@ -209,14 +230,16 @@ func (r *repository) GetComplianceEffect(ctx context.Context) (*ComplianceEffect
 	}, nil
 }
 
-func (r *repository) GetTimeDistribution(ctx context.Context) ([]TimeDistributionPoint, error) {
+func (r *repository) GetTimeDistribution(ctx context.Context, opts ...database.QueryOption) ([]TimeDistributionPoint, error) {
 	var result []TimeDistributionPoint
 
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Response{}).
 		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
 		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
 		Where("sessions.status = ?", models.StatusCompleted).
+		Where(designTrialsOnly).
 		Where("responses.has_interacted = ?", true).
 		Where("responses.is_timeout = ?", false).
 		Select(`
@@ -229,10 +252,11 @@ func (r *repository) GetTimeDistribution(ctx context.Context) ([]TimeDistributio
 	return result, err
 }
 
-func (r *repository) GetArchetypeDistribution(ctx context.Context) ([]ArchetypeCount, error) {
+func (r *repository) GetArchetypeDistribution(ctx context.Context, opts ...database.QueryOption) ([]ArchetypeCount, error) {
 	var results []ArchetypeCount
 
-	err := database.GetDB(ctx, r.db).WithContext(ctx).
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
 		Model(&models.Session{}).
 		Where("status = ?", models.StatusCompleted).
 		Where(jsonArchetype + " IS NOT NULL").
@@ -243,3 +267,143 @@ func (r *repository) GetArchetypeDistribution(ctx context.Context) ([]ArchetypeC
 
 	return results, err
 }
+
+// covariateRow is the maintain count for one value of a scenario covariate
+type covariateRow struct {
+	Value         string `gorm:"column:value"`
+	MaintainCount int64  `gorm:"column:maintain_count"`
+	TotalCount    int64  `gorm:"column:total_count"`
+}
+
+// getMaintainByCovariate groups interacted responses by a scenario JSON value. Scenarios
+// stored before the covariate existed are left out.
+func (r *repository) getMaintainByCovariate(ctx context.Context, selector string, opts ...database.QueryOption) ([]covariateRow, error) {
+	var rows []covariateRow
+
+	query := fmt.Sprintf(`
+		%s as value,
+		COUNT(*) FILTER (WHERE %s = '%s') as maintain_count,
+		COUNT(*) as total_count
+	`, selector, jsonRankingFirst, actionMaintain)
+
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
+		Model(&models.Response{}).
+		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
+		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
+		Where("sessions.status = ?", models.StatusCompleted).
+		Where(designTrialsOnly).
+		Where("responses.has_interacted = ?", true).
+		Where("scenarios.region_context IS NOT NULL").
+		Select(query).
+		Group("value").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+func (r *repository) GetRegionEffect(ctx context.Context, opts ...database.QueryOption) (*RegionEffect, error) {
+	result := &RegionEffect{}
+
+	for _, covariate := range []struct {
+		selector string
+		effect   **CovariateEffect
+	}{
+		{jsonRegionSchoolZone, &result.SchoolZone},
+		{jsonRegionPedestrianPriority, &result.PedestrianPriority},
+		{jsonRegionConstruction, &result.Construction},
+	} {
+		rows, err := r.getMaintainByCovariate(ctx, covariate.selector, opts...)
+		if err != nil {
+			return nil, err
+		}
+		effect := &CovariateEffect{Inside: &EffectMetric{}, Outside: &EffectMetric{}}
+		for _, row := range rows {
+			metric := newEffectMetric(row.MaintainCount, row.TotalCount)
+			if row.Value == factorTrue {
+				effect.Inside = metric
+			} else {
+				effect.Outside = metric
+			}
+		}
+		*covariate.effect = effect
+	}
+
+	rows, err := r.getMaintainByCovariate(ctx, jsonRegionSpeedLimit, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result.SpeedLimits = make([]SpeedLimitEffect, 0, len(rows))
+	for _, row := range rows {
+		speedLimit, err := strconv.Atoi(row.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid speed limit %q: %w", row.Value, err)
+		}
+		result.SpeedLimits = append(result.SpeedLimits, SpeedLimitEffect{
+			SpeedLimit:   speedLimit,
+			EffectMetric: *newEffectMetric(row.MaintainCount, row.TotalCount),
+		})
+	}
+	sort.Slice(result.SpeedLimits, func(i, j int) bool {
+		return result.SpeedLimits[i].SpeedLimit < result.SpeedLimits[j].SpeedLimit
+	})
+
+	return result, nil
+}
+
+func (r *repository) GetArmEffect(ctx context.Context, opts ...database.QueryOption) ([]ArmEffect, error) {
+	type rawResult struct {
+		Arm           string  `gorm:"column:arm"`
+		Sessions      int64   `gorm:"column:sessions"`
+		MaintainCount int64   `gorm:"column:maintain_count"`
+		TotalCount    int64   `gorm:"column:total_count"`
+		AvgResponseMs float64 `gorm:"column:avg_response_ms"`
+		TimeoutPct    float64 `gorm:"column:timeout_pct"`
+	}
+
+	query := fmt.Sprintf(`
+		sessions.arm as arm,
+		COUNT(DISTINCT sessions.id) as sessions,
+		COUNT(*) FILTER (WHERE responses.has_interacted AND %s = '%s') as maintain_count,
+		COUNT(*) FILTER (WHERE responses.has_interacted) as total_count,
+		COALESCE(AVG(responses.response_time_ms) FILTER (WHERE responses.has_interacted AND NOT responses.is_timeout), 0) as avg_response_ms,
+		COALESCE(100.0 * COUNT(*) FILTER (WHERE responses.is_timeout) / NULLIF(COUNT(*), 0), 0) as timeout_pct
+	`, jsonRankingFirst, actionMaintain)
+
+	var rows []rawResult
+	db := database.ApplyOptions(database.GetDB(ctx, r.db).WithContext(ctx), opts...)
+	err := db.
+		Model(&models.Response{}).
+		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
+		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
+		Where("sessions.status = ?", models.StatusCompleted).
+		Where(designTrialsOnly).
+		Where("sessions.arm <> ''").
+		Select(query).
+		Group("sessions.arm").
+		Order("sessions.arm").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ArmEffect, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, ArmEffect{
+			Arm:           row.Arm,
+			Sessions:      row.Sessions,
+			AvgResponseMs: row.AvgResponseMs,
+			TimeoutPct:    row.TimeoutPct,
+			EffectMetric:  *newEffectMetric(row.MaintainCount, row.TotalCount),
+		})
+	}
+	return result, nil
+}
+
+func newEffectMetric(maintain, total int64) *EffectMetric {
+	metric := &EffectMetric{MaintainCount: maintain, TotalCount: total}
+	if total > 0 {
+		metric.Percentage = 100.0 * float64(maintain) / float64(total)
+	}
+	return metric
+}
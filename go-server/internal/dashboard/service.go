@@ -2,52 +2,84 @@ package dashboard
 
 import (
 	"context"
+
+	"github.com/direwen/go-server/internal/experiment"
+	"github.com/direwen/go-server/pkg/database"
 )
 
 type Service interface {
-	GetPublicStats(ctx context.Context) (*PublicStats, error)
+	GetPublicStats(ctx context.Context, slug string) (*PublicStats, error)
 }
 
 type service struct {
-	repo Repository
+	repo        Repository
+	experiments experiment.Service
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, experiments experiment.Service) Service {
+	return &service{
+		repo:        repo,
+		experiments: experiments,
+	}
 }
 
-func (s *service) GetPublicStats(ctx context.Context) (*PublicStats, error) {
-	sessionCount, err := s.repo.GetCompletedSessionCount(ctx)
+// GetPublicStats aggregates the completed sessions of one experiment, the default one when
+// slug is empty
+func (s *service) GetPublicStats(ctx context.Context, slug string) (*PublicStats, error) {
+	study, err := s.experiments.GetBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := s.experiments.GetBySlug(experiment.DefaultSlug)
+	if err != nil {
+		return nil, err
+	}
+	// Sessions from before experiments belong to the default one
+	opts := []database.QueryOption{
+		database.WithFilter("COALESCE(sessions.experiment_id, ?) = ?", fallback.ID, study.ID),
+	}
+
+	sessionCount, err := s.repo.GetCompletedSessionCount(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	countriesCount, err := s.repo.GetCountryCount(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	leastHarmfulOutcome, err := s.repo.GetLeastHarmfulOutcome(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	countriesCount, err := s.repo.GetCountryCount(ctx)
+	selfPreservationEffect, err := s.repo.GetTailgaterEffect(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	leastHarmfulOutcome, err := s.repo.GetLeastHarmfulOutcome(ctx)
+	entityComplianceEffect, err := s.repo.GetComplianceEffect(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	selfPreservationEffect, err := s.repo.GetTailgaterEffect(ctx)
+	decisionTimeDistribution, err := s.repo.GetTimeDistribution(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	entityComplianceEffect, err := s.repo.GetComplianceEffect(ctx)
+	archetypeDistribution, err := s.repo.GetArchetypeDistribution(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	decisionTimeDistribution, err := s.repo.GetTimeDistribution(ctx)
+	regionEffect, err := s.repo.GetRegionEffect(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	archetypeDistribution, err := s.repo.GetArchetypeDistribution(ctx)
+	armEffect, err := s.repo.GetArmEffect(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,5 +92,7 @@ func (s *service) GetPublicStats(ctx context.Context) (*PublicStats, error) {
 		EntityComplianceEffect:   entityComplianceEffect,
 		DecisionTimeDistribution: decisionTimeDistribution,
 		ArchetypeDistribution:    archetypeDistribution,
+		RegionEffect:             regionEffect,
+		ArmEffect:                armEffect,
 	}, nil
 }
@@ -0,0 +1,82 @@
+package experiment
+
+import (
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/google/uuid"
+)
+
+// ExperimentFile is the on-disk definition of an experiment in data/experiments
+type ExperimentFile struct {
+	Slug         string              `json:"slug"`
+	Name         string              `json:"name"`
+	Active       *bool               `json:"active,omitempty"` // defaults to true
+	Design       domain.DesignConfig `json:"design"`
+	TemplatePool []string            `json:"template_pool,omitempty"` // template meta IDs, every template when empty
+	Timer        TimerSettings       `json:"timer"`
+	Arms         []Arm               `json:"arms,omitempty"` // between-subject conditions, none when every participant is treated alike
+}
+
+// TimerSettings is how long a participant has to answer a trial
+type TimerSettings struct {
+	DurationMs      int64 `json:"duration_ms"`
+	NetworkBufferMs int64 `json:"network_buffer_ms"` // grace on top of the duration before a response counts as late
+}
+
+// Arm is a between-subject condition. Unset fields keep the experiment's settings.
+type Arm struct {
+	Name      string         `json:"name"`
+	Weight    int            `json:"weight,omitempty"`    // relative share of participants, 1 when unset
+	Timer     *TimerSettings `json:"timer,omitempty"`     // replaces the experiment timer
+	Narrative *bool          `json:"narrative,omitempty"` // show the scenario narrative, true when unset
+	Framing   domain.Framing `json:"framing,omitempty"`   // point of view, observer when unset
+}
+
+// Condition is what a participant in an arm is shown, with the arm's overrides applied
+type Condition struct {
+	Arm       string         `json:"arm"` // empty when the experiment has no arms
+	Timer     TimerSettings  `json:"timer"`
+	Narrative bool           `json:"narrative"`
+	Framing   domain.Framing `json:"framing"`
+}
+
+// Settings are an experiment's parameters, parsed for the services that run its sessions
+type Settings struct {
+	ID           uuid.UUID           `json:"id"`
+	Slug         string              `json:"slug"`
+	Name         string              `json:"name"`
+	Active       bool                `json:"active"`
+	Design       domain.DesignConfig `json:"design"`
+	TemplatePool []string            `json:"template_pool"`
+	Timer        TimerSettings       `json:"timer"`
+	Arms         []Arm               `json:"arms"`
+}
+
+// Condition applies an arm's overrides to the experiment settings; an unknown or empty arm
+// gets the settings as they are
+func (s Settings) Condition(arm string) Condition {
+	condition := Condition{Timer: s.Timer, Narrative: true, Framing: domain.FramingObserver}
+	for _, candidate := range s.Arms {
+		if candidate.Name != arm {
+			continue
+		}
+		condition.Arm = arm
+		if candidate.Timer != nil {
+			condition.Timer = *candidate.Timer
+		}
+		if candidate.Narrative != nil {
+			condition.Narrative = *candidate.Narrative
+		}
+		if candidate.Framing != "" {
+			condition.Framing = candidate.Framing
+		}
+	}
+	return condition
+}
+
+// SyncReport summarises what an experiment sync did to the database
+type SyncReport struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	Closed    []string `json:"closed"` // no longer defined on disk, kept inactive for their sessions
+}
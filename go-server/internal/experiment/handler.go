@@ -0,0 +1,31 @@
+package experiment
+
+import (
+	"net/http"
+
+	"github.com/direwen/go-server/internal/util"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List returns every experiment with its parsed settings, closed ones included
+func (h *Handler) List(c echo.Context) error {
+	return util.SuccessResponse(c, http.StatusOK, "Experiments retrieved", h.service.GetAll())
+}
+
+// Reload syncs the experiment files into the DB
+func (h *Handler) Reload(c echo.Context) error {
+	report, err := h.service.Reload(c.Request().Context())
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusUnprocessableEntity, "Failed to reload experiments", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Experiments reloaded", report)
+}
@@ -0,0 +1,168 @@
+package experiment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// DefaultExperimentDir is where experiment files live relative to the server working directory
+const DefaultExperimentDir = "data/experiments"
+
+// DefaultSlug is the experiment sessions join when they name none
+const DefaultSlug = "default"
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ReadExperimentDir parses every *.json file in dir; a missing dir holds no experiments
+func ReadExperimentDir(dir string) ([]ExperimentFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	files := make([]ExperimentFile, 0, len(paths))
+	seen := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var file ExperimentFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		file = file.withDefaults()
+		if err := file.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if other, dup := seen[file.Slug]; dup {
+			return nil, fmt.Errorf("%s: slug %q already used by %s", path, file.Slug, other)
+		}
+		seen[file.Slug] = path
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// DefaultExperimentFromEnv builds the default experiment from the environment, for
+// deployments that do not define it in a file
+func DefaultExperimentFromEnv() (ExperimentFile, error) {
+	trials, err := strconv.Atoi(os.Getenv("EXPERIMENT_TARGET_COUNT"))
+	if err != nil {
+		return ExperimentFile{}, errors.New("EXPERIMENT_TARGET_COUNT must be set when no default experiment file exists")
+	}
+
+	var factors []string
+	if val := os.Getenv("DESIGN_FACTORS"); val != "" {
+		factors = strings.Split(val, ",")
+	}
+
+	file := ExperimentFile{
+		Slug:   DefaultSlug,
+		Name:   "Default",
+		Design: domain.DesignConfig{Strategy: domain.DesignStrategyName(os.Getenv("DESIGN_STRATEGY")), Trials: trials, Factors: factors},
+	}
+	file = file.withDefaults()
+	return file, file.Validate()
+}
+
+// DefaultTimer is the timer for experiments that leave it out (TIMER_DURATION_MS and
+// NETWORK_BUFFER_MS, 12 and 10 seconds when unset)
+func DefaultTimer() TimerSettings {
+	timer := TimerSettings{DurationMs: 12000, NetworkBufferMs: 10000}
+
+	if val := os.Getenv("TIMER_DURATION_MS"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			timer.DurationMs = parsed
+		}
+	}
+
+	if val := os.Getenv("NETWORK_BUFFER_MS"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			timer.NetworkBufferMs = parsed
+		}
+	}
+
+	return timer
+}
+
+func (f ExperimentFile) withDefaults() ExperimentFile {
+	if f.Name == "" {
+		f.Name = f.Slug
+	}
+	if f.Active == nil {
+		active := true
+		f.Active = &active
+	}
+	f.Design = f.Design.WithDefaults()
+	defaults := DefaultTimer()
+	if f.Timer.DurationMs == 0 {
+		f.Timer.DurationMs = defaults.DurationMs
+	}
+	if f.Timer.NetworkBufferMs == 0 {
+		f.Timer.NetworkBufferMs = defaults.NetworkBufferMs
+	}
+	arms := make([]Arm, len(f.Arms))
+	for i, arm := range f.Arms {
+		if arm.Weight == 0 {
+			arm.Weight = 1
+		}
+		if arm.Timer != nil {
+			timer := *arm.Timer
+			if timer.NetworkBufferMs == 0 {
+				timer.NetworkBufferMs = f.Timer.NetworkBufferMs
+			}
+			arm.Timer = &timer
+		}
+		arms[i] = arm
+	}
+	f.Arms = arms
+	return f
+}
+
+func (f ExperimentFile) Validate() error {
+	if !slugPattern.MatchString(f.Slug) {
+		return fmt.Errorf("slug %q must be lowercase letters, digits, - or _", f.Slug)
+	}
+	if err := f.Design.Validate(); err != nil {
+		return fmt.Errorf("design: %w", err)
+	}
+	if f.Timer.DurationMs < 0 || f.Timer.NetworkBufferMs < 0 {
+		return errors.New("timer settings must not be negative")
+	}
+	seen := make(map[string]bool, len(f.Arms))
+	for _, arm := range f.Arms {
+		if !slugPattern.MatchString(arm.Name) {
+			return fmt.Errorf("arm name %q must be lowercase letters, digits, - or _", arm.Name)
+		}
+		if seen[arm.Name] {
+			return fmt.Errorf("arm %q listed twice", arm.Name)
+		}
+		seen[arm.Name] = true
+		if arm.Weight < 1 {
+			return fmt.Errorf("arm %s: weight must be positive", arm.Name)
+		}
+		if arm.Timer != nil && (arm.Timer.DurationMs <= 0 || arm.Timer.NetworkBufferMs < 0) {
+			return fmt.Errorf("arm %s: timer needs a positive duration", arm.Name)
+		}
+		if arm.Framing != "" && !slices.Contains(domain.Framings, arm.Framing) {
+			return fmt.Errorf("arm %s: unknown framing %q", arm.Name, arm.Framing)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package experiment
+
+import "github.com/direwen/go-server/internal/shared/models"
+
+type Experiment = models.Experiment
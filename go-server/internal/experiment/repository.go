@@ -0,0 +1,38 @@
+package experiment
+
+import (
+	"context"
+
+	"github.com/direwen/go-server/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, experiment *Experiment) error
+	Update(ctx context.Context, experiment *Experiment) error
+	GetAll(ctx context.Context, opts ...database.QueryOption) ([]Experiment, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, experiment *Experiment) error {
+	return database.GetDB(ctx, r.db).WithContext(ctx).Create(experiment).Error
+}
+
+func (r *repository) Update(ctx context.Context, experiment *Experiment) error {
+	return database.GetDB(ctx, r.db).WithContext(ctx).Save(experiment).Error
+}
+
+func (r *repository) GetAll(ctx context.Context, opts ...database.QueryOption) ([]Experiment, error) {
+	var experiments []Experiment
+	db := database.GetDB(ctx, r.db).WithContext(ctx).Model(&Experiment{})
+	db = database.ApplyOptions(db, opts...)
+	err := db.Order("slug").Find(&experiments).Error
+	return experiments, err
+}
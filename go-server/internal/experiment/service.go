@@ -0,0 +1,362 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/template"
+	"github.com/direwen/go-server/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrExperimentNotFound = errors.New("experiment not found")
+	ErrExperimentClosed   = errors.New("experiment is not accepting new sessions")
+)
+
+type Service interface {
+	Reload(ctx context.Context) (*SyncReport, error)
+	GetAll() []Settings
+	GetBySlug(slug string) (*Settings, error)
+	GetByID(id *uuid.UUID) (*Settings, error)
+}
+
+type service struct {
+	repo            Repository
+	txManager       database.TransactionManager
+	templateService template.Service
+	experimentDir   string
+	mu              sync.RWMutex
+	bySlug          map[string]Settings
+	byID            map[uuid.UUID]Settings
+	reloadMu        sync.Mutex
+}
+
+func NewService(repo Repository, txManager database.TransactionManager, templateService template.Service, experimentDir string) Service {
+	return &service{
+		repo:            repo,
+		txManager:       txManager,
+		templateService: templateService,
+		experimentDir:   experimentDir,
+		bySlug:          make(map[string]Settings),
+		byID:            make(map[uuid.UUID]Settings),
+	}
+}
+
+// Reload syncs the experiment files into the DB and rebuilds the cache. Experiments whose
+// file was removed are closed rather than deleted, since their sessions still point at them.
+// Call it after the templates are loaded, which the template pools are checked against.
+func (s *service) Reload(ctx context.Context) (*SyncReport, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	files, err := s.readFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var report *SyncReport
+	var experiments []Experiment
+	err = s.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		report, err = s.sync(txCtx, files)
+		if err != nil {
+			return err
+		}
+		experiments, err = s.repo.GetAll(txCtx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bySlug := make(map[string]Settings, len(experiments))
+	byID := make(map[uuid.UUID]Settings, len(experiments))
+	for _, experiment := range experiments {
+		settings, err := toSettings(experiment)
+		if err != nil {
+			return nil, fmt.Errorf("experiment %s: %w", experiment.Slug, err)
+		}
+		bySlug[settings.Slug] = settings
+		byID[settings.ID] = settings
+	}
+
+	s.mu.Lock()
+	s.bySlug, s.byID = bySlug, byID
+	s.mu.Unlock()
+
+	return report, nil
+}
+
+// readFiles reads and checks every experiment file, adding the default experiment from the
+// environment when no file defines it
+func (s *service) readFiles(ctx context.Context) ([]ExperimentFile, error) {
+	files, err := ReadExperimentDir(s.experimentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(files, func(f ExperimentFile) bool { return f.Slug == DefaultSlug }) {
+		file, err := DefaultExperimentFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	templates, err := s.templateService.GetAllTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]uuid.UUID, len(templates))
+	for _, tpl := range templates {
+		if meta, err := template.ParseMeta(tpl); err == nil {
+			known[meta.ID] = tpl.Id
+		}
+	}
+	for _, file := range files {
+		for _, id := range file.TemplatePool {
+			if _, ok := known[id]; !ok {
+				return nil, fmt.Errorf("experiment %s: unknown template %q in pool", file.Slug, id)
+			}
+		}
+		if unplaceable := file.Design.UnplaceableLevels(s.poolAreas(file.TemplatePool, known)); len(unplaceable) > 0 {
+			return nil, fmt.Errorf("experiment %s: no template in the pool has an area where %s can be placed under the constraints", file.Slug, strings.Join(unplaceable, ", "))
+		}
+	}
+
+	return files, nil
+}
+
+// poolAreas collects the distinct regions at the spawns of the pool's templates, every
+// template when the pool is empty, for each driving side
+func (s *service) poolAreas(pool []string, known map[string]uuid.UUID) map[domain.DrivingSide][]domain.RegionContext {
+	ids := make([]uuid.UUID, 0, len(known))
+	for metaID, id := range known {
+		if len(pool) == 0 || slices.Contains(pool, metaID) {
+			ids = append(ids, id)
+		}
+	}
+	areas := make(map[domain.DrivingSide][]domain.RegionContext)
+	for _, side := range []domain.DrivingSide{domain.DrivingSideRight, domain.DrivingSideLeft} {
+		for _, id := range ids {
+			spawns, err := s.templateService.GetSpawns(id, side, template.SpawnCriteria{})
+			if err != nil {
+				continue
+			}
+			for _, spawn := range spawns {
+				if !slices.Contains(areas[side], spawn.Regions) {
+					areas[side] = append(areas[side], spawn.Regions)
+				}
+			}
+		}
+	}
+	return areas
+}
+
+func (s *service) sync(ctx context.Context, files []ExperimentFile) (*SyncReport, error) {
+	existing, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string]Experiment, len(existing))
+	for _, experiment := range existing {
+		bySlug[experiment.Slug] = experiment
+	}
+
+	report := &SyncReport{}
+	onDisk := make(map[string]bool, len(files))
+
+	for _, file := range files {
+		onDisk[file.Slug] = true
+
+		next, err := toModel(file)
+		if err != nil {
+			return nil, fmt.Errorf("experiment %s: %w", file.Slug, err)
+		}
+
+		current, found := bySlug[file.Slug]
+		if !found {
+			if err := s.repo.Create(ctx, &next); err != nil {
+				return nil, fmt.Errorf("create %s: %w", file.Slug, err)
+			}
+			report.Created = append(report.Created, file.Slug)
+			continue
+		}
+
+		if sameExperiment(current, next) {
+			report.Unchanged = append(report.Unchanged, file.Slug)
+			continue
+		}
+
+		next.BaseModel = current.BaseModel
+		if err := s.repo.Update(ctx, &next); err != nil {
+			return nil, fmt.Errorf("update %s: %w", file.Slug, err)
+		}
+		report.Updated = append(report.Updated, file.Slug)
+	}
+
+	for _, experiment := range existing {
+		if onDisk[experiment.Slug] || !experiment.Active {
+			continue
+		}
+		experiment.Active = false
+		if err := s.repo.Update(ctx, &experiment); err != nil {
+			return nil, fmt.Errorf("close %s: %w", experiment.Slug, err)
+		}
+		report.Closed = append(report.Closed, experiment.Slug)
+	}
+
+	return report, nil
+}
+
+func (s *service) GetAll() []Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make([]Settings, 0, len(s.bySlug))
+	for _, settings := range s.bySlug {
+		all = append(all, settings)
+	}
+	slices.SortFunc(all, func(a, b Settings) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+	return all
+}
+
+// GetBySlug returns an experiment, the default one when slug is empty
+func (s *service) GetBySlug(slug string) (*Settings, error) {
+	if slug == "" {
+		slug = DefaultSlug
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, ok := s.bySlug[slug]
+	if !ok {
+		return nil, ErrExperimentNotFound
+	}
+	return &settings, nil
+}
+
+// GetByID returns the experiment a session belongs to; sessions without one belong to the
+// default experiment
+func (s *service) GetByID(id *uuid.UUID) (*Settings, error) {
+	if id == nil {
+		return s.GetBySlug(DefaultSlug)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, ok := s.byID[*id]
+	if !ok {
+		return nil, ErrExperimentNotFound
+	}
+	return &settings, nil
+}
+
+func toModel(file ExperimentFile) (Experiment, error) {
+	design, err := json.Marshal(file.Design)
+	if err != nil {
+		return Experiment{}, err
+	}
+	pool, err := json.Marshal(file.TemplatePool)
+	if err != nil {
+		return Experiment{}, err
+	}
+	arms, err := json.Marshal(file.Arms)
+	if err != nil {
+		return Experiment{}, err
+	}
+	return Experiment{
+		Slug:            file.Slug,
+		Name:            file.Name,
+		Active:          *file.Active,
+		Design:          datatypes.JSON(design),
+		TemplatePool:    datatypes.JSON(pool),
+		Arms:            datatypes.JSON(arms),
+		TimerDurationMs: file.Timer.DurationMs,
+		NetworkBufferMs: file.Timer.NetworkBufferMs,
+	}, nil
+}
+
+func toSettings(experiment Experiment) (Settings, error) {
+	settings := Settings{
+		ID:     experiment.Id,
+		Slug:   experiment.Slug,
+		Name:   experiment.Name,
+		Active: experiment.Active,
+		Timer: TimerSettings{
+			DurationMs:      experiment.TimerDurationMs,
+			NetworkBufferMs: experiment.NetworkBufferMs,
+		},
+	}
+	if err := json.Unmarshal(experiment.Design, &settings.Design); err != nil {
+		return Settings{}, errors.New("failed to parse design")
+	}
+	settings.Design = settings.Design.WithDefaults()
+	if err := settings.Design.Validate(); err != nil {
+		// A stored design the code no longer accepts keeps its sessions readable but takes no new ones
+		log.Printf("Experiment %s closed: %v", experiment.Slug, err)
+		settings.Active = false
+	}
+	if experiment.TemplatePool != nil {
+		if err := json.Unmarshal(experiment.TemplatePool, &settings.TemplatePool); err != nil {
+			return Settings{}, errors.New("failed to parse template pool")
+		}
+	}
+	if experiment.Arms != nil {
+		if err := json.Unmarshal(experiment.Arms, &settings.Arms); err != nil {
+			return Settings{}, errors.New("failed to parse arms")
+		}
+	}
+	return settings, nil
+}
+
+func sameExperiment(a, b Experiment) bool {
+	return a.Name == b.Name &&
+		a.Active == b.Active &&
+		jsonEqual(a.Design, b.Design) &&
+		jsonEqual(a.TemplatePool, b.TemplatePool) &&
+		jsonEqual(a.Arms, b.Arms) &&
+		a.TimerDurationMs == b.TimerDurationMs &&
+		a.NetworkBufferMs == b.NetworkBufferMs
+}
+
+// jsonEqual compares JSON by value, since jsonb does not keep the key order or spacing
+func jsonEqual(a, b datatypes.JSON) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// String renders the report for startup logs
+func (r *SyncReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "experiments: %d created, %d updated, %d unchanged, %d closed",
+		len(r.Created), len(r.Updated), len(r.Unchanged), len(r.Closed))
+
+	for _, slug := range r.Created {
+		fmt.Fprintf(&b, "\n  + %s", slug)
+	}
+	for _, slug := range r.Updated {
+		fmt.Fprintf(&b, "\n  ~ %s", slug)
+	}
+	for _, slug := range r.Closed {
+		fmt.Fprintf(&b, "\n  - %s (no file on disk, closed to new sessions)", slug)
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/direwen/go-server/internal/util"
+	"github.com/labstack/echo/v4"
+)
+
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminMiddleware guards researcher-only endpoints with the shared ADMIN_TOKEN secret.
+// If ADMIN_TOKEN is not set every admin request is rejected.
+func AdminMiddleware() echo.MiddlewareFunc {
+	secret := os.Getenv("ADMIN_TOKEN")
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if secret == "" {
+				return util.ErrorResponse(c, http.StatusForbidden, "Admin access disabled", errors.New("ADMIN_TOKEN is not set"))
+			}
+
+			token := c.Request().Header.Get(AdminTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+				return util.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", errors.New("invalid admin token"))
+			}
+
+			return next(c)
+		}
+	}
+}
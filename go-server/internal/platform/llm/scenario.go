@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/direwen/go-server/internal/shared/domain"
 	"github.com/tmc/langchaingo/llms"
@@ -37,7 +38,7 @@ func (c *scenarioClient) GenerateScenario(ctx context.Context, req domain.Scenar
 	// Prepare template
 	template := prompts.PromptTemplate{
 		Template:       scenarioPromptTemplate,
-		InputVariables: []string{"TemplateName", "Dimensions", "Factors", "EgoPosition", "EgoOrientation", "ZoneA", "ZoneB", "ZoneC"},
+		InputVariables: []string{"TemplateName", "Dimensions", "Factors", "DrivingSide", "EgoPosition", "EgoOrientation", "ZoneA", "ZoneB", "ZoneC", "Surfaces", "Signals", "Regions", "Framing"},
 		TemplateFormat: prompts.TemplateFormatGoTemplate,
 	}
 
@@ -46,11 +47,16 @@ func (c *scenarioClient) GenerateScenario(ctx context.Context, req domain.Scenar
 		"TemplateName":   req.TemplateName,
 		"Dimensions":     req.GridDimensions,
 		"Factors":        req.Factors,
+		"DrivingSide":    formatDrivingSideForLLM(req.DrivingSide),
 		"EgoPosition":    formatCoordForLLM(req.EgoPosition),
 		"EgoOrientation": req.EgoOrientation,
 		"ZoneA":          formatZoneForLLM(req.TridentZones.ZoneA),
 		"ZoneB":          formatZoneForLLM(req.TridentZones.ZoneB),
 		"ZoneC":          formatZoneForLLM(req.TridentZones.ZoneC),
+		"Surfaces":       formatSurfacesForLLM(req.TridentZones),
+		"Signals":        formatSignalsForLLM(req.Signals, req.Factors.SignalPhase),
+		"Regions":        formatRegionsForLLM(req.Regions),
+		"Framing":        formatFramingForLLM(req.Framing),
 	})
 	if err != nil {
 		return nil, err
@@ -97,3 +103,84 @@ func formatZoneForLLM(zone domain.TridentZone) string {
 	result, _ := json.Marshal(zone.Coordinates)
 	return string(result)
 }
+
+func formatDrivingSideForLLM(side domain.DrivingSide) string {
+	if side == domain.DrivingSideLeft {
+		return "Keep left (vehicles drive on the left side of the road)"
+	}
+	return "Keep right (vehicles drive on the right side of the road)"
+}
+
+// formatSurfacesForLLM explains every surface that appears in the zones, from the tile registry
+func formatSurfacesForLLM(zones domain.TridentZones) string {
+	present := make(map[domain.SurfaceType]bool)
+	for _, zone := range []domain.TridentZone{zones.ZoneA, zones.ZoneB, zones.ZoneC} {
+		for _, coord := range zone.Coordinates {
+			present[coord.Surface] = true
+		}
+	}
+
+	var lines []string
+	for _, surface := range domain.Surfaces() {
+		if !present[surface] {
+			continue
+		}
+		rules := domain.RulesFor(surface)
+		usage := "nothing"
+		if len(rules.Usage) > 0 {
+			usage = strings.Join(rules.Usage, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("- `%s`: %s Belongs here: %s.", surface, rules.Description, usage))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSignalsForLLM states the AV's signal and which crosswalk tiles are Walk or DontWalk
+func formatSignalsForLLM(signals []domain.SignalHead, phase string) string {
+	if len(signals) == 0 {
+		return "No traffic signals. Crossing legality follows the surfaces only."
+	}
+
+	lines := []string{fmt.Sprintf("- AV signal: **%s**", phase)}
+	crossings := make(map[domain.SignalPhase][]string)
+	for _, head := range signals {
+		if head.Kind == domain.SignalKindPedestrian {
+			crossings[head.State] = append(crossings[head.State], formatCoordForLLM(head.Coordinate))
+		}
+	}
+	for _, state := range []domain.SignalPhase{domain.SignalPhaseWalk, domain.SignalPhaseDontWalk} {
+		if len(crossings[state]) > 0 {
+			lines = append(lines, fmt.Sprintf("- Crosswalk tiles showing %s: %s", state, strings.Join(crossings[state], ", ")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatRegionsForLLM lists the area rules that apply where the scenario plays out
+func formatRegionsForLLM(regions domain.RegionContext) string {
+	var lines []string
+	if regions.SpeedLimit > 0 {
+		lines = append(lines, fmt.Sprintf("- Speed limit: %d km/h", regions.SpeedLimit))
+	}
+	if regions.SchoolZone {
+		lines = append(lines, "- School zone: children are expected near the road")
+	}
+	if regions.PedestrianPriority {
+		lines = append(lines, "- Pedestrian priority area: pedestrians may use the carriageway and vehicles must yield")
+	}
+	if regions.Construction {
+		lines = append(lines, "- Construction area: barriers, cones and workers belong here")
+	}
+	if len(lines) == 0 {
+		return "No special area rules."
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatFramingForLLM says whose point of view the narrative takes
+func formatFramingForLLM(framing domain.Framing) string {
+	if framing == domain.FramingPassenger {
+		return "The participant is a passenger riding in the AV. Write the narrative in the second person from inside the AV (e.g., \"You are riding in...\")."
+	}
+	return "The participant is an observer deciding for the AV. Write the narrative in the third person about the AV."
+}
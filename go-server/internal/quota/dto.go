@@ -0,0 +1,12 @@
+package quota
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// AnsweredTrial is the factors of an answered scenario and the experiment of its session
+type AnsweredTrial struct {
+	ExperimentID *uuid.UUID     `gorm:"column:experiment_id"`
+	Factors      datatypes.JSON `gorm:"column:factors"`
+}
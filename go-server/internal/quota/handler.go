@@ -0,0 +1,42 @@
+package quota
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/direwen/go-server/internal/experiment"
+	"github.com/direwen/go-server/internal/util"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List returns the quota cells of an experiment (?experiment=, the default one when absent),
+// optionally for one factor or pair (?factor=has_tailgater%26speed)
+func (h *Handler) List(c echo.Context) error {
+	quotas, err := h.service.GetQuotas(c.Request().Context(), c.QueryParam("experiment"), c.QueryParam("factor"))
+	if errors.Is(err, experiment.ErrExperimentNotFound) {
+		return util.ErrorResponse(c, http.StatusNotFound, "Experiment not found", err)
+	}
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch quotas", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Quotas retrieved", quotas)
+}
+
+// Rebuild recounts the quotas from the completed sessions
+func (h *Handler) Rebuild(c echo.Context) error {
+	trials, err := h.service.Rebuild(c.Request().Context())
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusInternalServerError, "Failed to rebuild quotas", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Quotas rebuilt", map[string]int{"trials": trials})
+}
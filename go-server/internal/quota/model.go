@@ -0,0 +1,5 @@
+package quota
+
+import "github.com/direwen/go-server/internal/shared/models"
+
+type FactorQuota = models.FactorQuota
@@ -0,0 +1,95 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/direwen/go-server/internal/shared/models"
+	"github.com/direwen/go-server/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	GetAll(ctx context.Context, opts ...database.QueryOption) ([]FactorQuota, error)
+	Increment(ctx context.Context, quotas []FactorQuota) error
+	DeleteAll(ctx context.Context) error
+	AdoptLegacy(ctx context.Context, experimentID uuid.UUID) (int64, error)
+	GetAnsweredTrials(ctx context.Context, opts ...database.QueryOption) ([]AnsweredTrial, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll(ctx context.Context, opts ...database.QueryOption) ([]FactorQuota, error) {
+	var quotas []FactorQuota
+	db := database.GetDB(ctx, r.db).WithContext(ctx).Model(&FactorQuota{})
+	db = database.ApplyOptions(db, opts...)
+	err := db.Order("factor, level").Find(&quotas).Error
+	return quotas, err
+}
+
+// Increment adds each quota's count to its cell, creating cells on first use
+func (r *repository) Increment(ctx context.Context, quotas []FactorQuota) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+	return database.GetDB(ctx, r.db).WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "experiment_id"}, {Name: "factor"}, {Name: "level"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"count":      gorm.Expr("factor_quotas.count + excluded.count"),
+				"updated_at": gorm.Expr("excluded.updated_at"),
+			}),
+		}).
+		CreateInBatches(quotas, 500).Error
+}
+
+func (r *repository) DeleteAll(ctx context.Context) error {
+	return database.GetDB(ctx, r.db).WithContext(ctx).Unscoped().
+		Where("1 = 1").
+		Delete(&FactorQuota{}).Error
+}
+
+// AdoptLegacy assigns the cells counted before experiments existed to an experiment,
+// adding them to any cell it already has, and returns how many it assigned
+func (r *repository) AdoptLegacy(ctx context.Context, experimentID uuid.UUID) (int64, error) {
+	db := database.GetDB(ctx, r.db).WithContext(ctx)
+	err := db.Exec(`
+		UPDATE factor_quotas AS cell SET count = cell.count + legacy.count, updated_at = NOW()
+		FROM factor_quotas AS legacy
+		WHERE legacy.experiment_id IS NULL AND cell.experiment_id = ?
+			AND cell.factor = legacy.factor AND cell.level = legacy.level`, experimentID).Error
+	if err != nil {
+		return 0, err
+	}
+	err = db.Exec(`
+		DELETE FROM factor_quotas AS legacy
+		WHERE legacy.experiment_id IS NULL AND EXISTS (
+			SELECT 1 FROM factor_quotas AS cell
+			WHERE cell.experiment_id = ? AND cell.factor = legacy.factor AND cell.level = legacy.level)`, experimentID).Error
+	if err != nil {
+		return 0, err
+	}
+	result := db.Model(&FactorQuota{}).Where("experiment_id IS NULL").Update("experiment_id", experimentID)
+	return result.RowsAffected, result.Error
+}
+
+// GetAnsweredTrials returns the factors of every design trial that has a response, with the
+// experiment of its session
+func (r *repository) GetAnsweredTrials(ctx context.Context, opts ...database.QueryOption) ([]AnsweredTrial, error) {
+	var trials []AnsweredTrial
+	db := database.GetDB(ctx, r.db).WithContext(ctx).Model(&models.Scenario{}).
+		Select("sessions.experiment_id, scenarios.factors").
+		Joins("JOIN responses ON responses.scenario_id = scenarios.id").
+		Joins("JOIN sessions ON sessions.id = scenarios.session_id").
+		Where("scenarios.attention_check = '' AND scenarios.repeat_of_id IS NULL")
+	db = database.ApplyOptions(db, opts...)
+	err := db.Scan(&trials).Error
+	return trials, err
+}
@@ -0,0 +1,166 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/direwen/go-server/internal/experiment"
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/shared/models"
+	"github.com/direwen/go-server/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type Service interface {
+	GetCounts(ctx context.Context, experimentID uuid.UUID) (domain.QuotaCounts, error)
+	GetQuotas(ctx context.Context, slug, factor string) ([]FactorQuota, error)
+	RecordSession(ctx context.Context, experimentID, sessionID uuid.UUID) error
+	Rebuild(ctx context.Context) (int, error)
+	AdoptLegacy(ctx context.Context) (int64, error)
+}
+
+type service struct {
+	repo        Repository
+	txManager   database.TransactionManager
+	experiments experiment.Service
+}
+
+func NewService(repo Repository, txManager database.TransactionManager, experiments experiment.Service) Service {
+	return &service{
+		repo:        repo,
+		txManager:   txManager,
+		experiments: experiments,
+	}
+}
+
+// GetCounts loads every cell of an experiment for biasing its new plans
+func (s *service) GetCounts(ctx context.Context, experimentID uuid.UUID) (domain.QuotaCounts, error) {
+	quotas, err := s.repo.GetAll(ctx, database.WithFilter("experiment_id = ?", experimentID))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(domain.QuotaCounts, len(quotas))
+	for _, quota := range quotas {
+		counts[domain.QuotaCell{Factor: quota.Factor, Level: quota.Level}] = quota.Count
+	}
+	return counts, nil
+}
+
+// GetQuotas lists the cells of an experiment (the default one when slug is empty) for one
+// factor or pair, or every cell when factor is empty
+func (s *service) GetQuotas(ctx context.Context, slug, factor string) ([]FactorQuota, error) {
+	settings, err := s.experiments.GetBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []database.QueryOption{database.WithFilter("experiment_id = ?", settings.ID)}
+	if factor != "" {
+		opts = append(opts, database.WithFilter("factor = ?", factor))
+	}
+	return s.repo.GetAll(ctx, opts...)
+}
+
+// RecordSession adds the answered trials of a completed session to its experiment's quotas
+func (s *service) RecordSession(ctx context.Context, experimentID, sessionID uuid.UUID) error {
+	trials, err := s.repo.GetAnsweredTrials(ctx, database.WithFilter("scenarios.session_id = ?", sessionID))
+	if err != nil {
+		return err
+	}
+
+	factors := make([]datatypes.JSON, len(trials))
+	for i, trial := range trials {
+		factors[i] = trial.Factors
+	}
+
+	quotas, err := tally(experimentID, factors)
+	if err != nil {
+		return err
+	}
+	return s.repo.Increment(ctx, quotas)
+}
+
+// Rebuild recounts the quotas of every experiment from the completed sessions, returning
+// the trials counted. Sessions from before experiments count towards the default one.
+func (s *service) Rebuild(ctx context.Context) (int, error) {
+	fallback, err := s.experiments.GetBySlug(experiment.DefaultSlug)
+	if err != nil {
+		return 0, err
+	}
+
+	var counted int
+	err = s.txManager.Do(ctx, func(txCtx context.Context) error {
+		trials, err := s.repo.GetAnsweredTrials(txCtx, database.WithFilter("sessions.status = ?", models.StatusCompleted))
+		if err != nil {
+			return err
+		}
+
+		byExperiment := make(map[uuid.UUID][]datatypes.JSON)
+		for _, trial := range trials {
+			experimentID := fallback.ID
+			if trial.ExperimentID != nil {
+				experimentID = *trial.ExperimentID
+			}
+			byExperiment[experimentID] = append(byExperiment[experimentID], trial.Factors)
+		}
+
+		if err := s.repo.DeleteAll(txCtx); err != nil {
+			return err
+		}
+		for experimentID, factors := range byExperiment {
+			quotas, err := tally(experimentID, factors)
+			if err != nil {
+				return err
+			}
+			if err := s.repo.Increment(txCtx, quotas); err != nil {
+				return err
+			}
+		}
+		counted = len(trials)
+		return nil
+	})
+	return counted, err
+}
+
+// tally counts the cells of an experiment filled by a set of trials
+func tally(experimentID uuid.UUID, factors []datatypes.JSON) ([]FactorQuota, error) {
+	counts := make(domain.QuotaCounts)
+	var order []domain.QuotaCell
+	for _, raw := range factors {
+		var trial domain.ScenarioFactors
+		if err := json.Unmarshal(raw, &trial); err != nil {
+			return nil, errors.New("failed to parse scenario factors")
+		}
+		for _, cell := range domain.QuotaCellsOf(trial) {
+			if counts[cell] == 0 {
+				order = append(order, cell)
+			}
+			counts[cell]++
+		}
+	}
+
+	quotas := make([]FactorQuota, 0, len(order))
+	for _, cell := range order {
+		quotas = append(quotas, FactorQuota{ExperimentID: experimentID, Factor: cell.Factor, Level: cell.Level, Count: counts[cell]})
+	}
+	return quotas, nil
+}
+
+// AdoptLegacy gives the cells counted before experiments existed to the default experiment,
+// the one their sessions belong to
+func (s *service) AdoptLegacy(ctx context.Context) (int64, error) {
+	settings, err := s.experiments.GetBySlug(experiment.DefaultSlug)
+	if err != nil {
+		return 0, err
+	}
+
+	var adopted int64
+	err = s.txManager.Do(ctx, func(txCtx context.Context) error {
+		adopted, err = s.repo.AdoptLegacy(txCtx, settings.ID)
+		return err
+	})
+	return adopted, err
+}
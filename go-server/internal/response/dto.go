@@ -7,6 +7,12 @@ type SubmitResponseInput struct {
 	HasInteracted  bool     `json:"has_interacted"`
 }
 
+// AttentionResult is how many catch trials a session answered and passed
+type AttentionResult struct {
+	Checks int `json:"checks"`
+	Passed int `json:"passed"`
+}
+
 type SubmitResponseOutput struct {
 	Response   *Response `json:"response"`
 	IsComplete bool      `json:"is_complete"`
@@ -13,6 +13,7 @@ type Repository interface {
 	GetByID(ctx context.Context, id uuid.UUID, opts ...database.QueryOption) (*Response, error)
 	GetByScenarioID(ctx context.Context, scenarioID uuid.UUID, opts ...database.QueryOption) (*Response, error)
 	CountBySessionID(ctx context.Context, sessionID uuid.UUID) (int, error)
+	GetAttentionResult(ctx context.Context, sessionID uuid.UUID) (*AttentionResult, error)
 	GetBySessionID(ctx context.Context, sessionID uuid.UUID, opts ...database.QueryOption) ([]*Response, error)
 }
 
@@ -44,16 +45,32 @@ func (r *repository) GetByScenarioID(ctx context.Context, scenarioID uuid.UUID,
 	return &response, err
 }
 
+// CountBySessionID counts the answered design trials of a session; attention checks are
+// counted by GetAttentionResult
 func (r *repository) CountBySessionID(ctx context.Context, sessionID uuid.UUID) (int, error) {
 	var count int64
 	err := database.GetDB(ctx, r.db).WithContext(ctx).
 		Model(&Response{}).
 		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
 		Where("scenarios.session_id = ?", sessionID).
+		Where("scenarios.attention_check = ''").
 		Count(&count).Error
 	return int(count), err
 }
 
+// GetAttentionResult counts the answered and passed attention checks of a session
+func (r *repository) GetAttentionResult(ctx context.Context, sessionID uuid.UUID) (*AttentionResult, error) {
+	var result AttentionResult
+	err := database.GetDB(ctx, r.db).WithContext(ctx).
+		Model(&Response{}).
+		Joins("JOIN scenarios ON scenarios.id = responses.scenario_id").
+		Where("scenarios.session_id = ?", sessionID).
+		Where("scenarios.attention_check <> ''").
+		Select("COUNT(*) AS checks, COUNT(*) FILTER (WHERE responses.attention_passed) AS passed").
+		Scan(&result).Error
+	return &result, err
+}
+
 func (r *repository) GetBySessionID(ctx context.Context, sessionID uuid.UUID, opts ...database.QueryOption) ([]*Response, error) {
 	var responses []*Response
 	db := database.GetDB(ctx, r.db).WithContext(ctx).
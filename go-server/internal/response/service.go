@@ -6,6 +6,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/direwen/go-server/internal/shared/domain"
 	"github.com/direwen/go-server/internal/shared/services"
 	"github.com/direwen/go-server/pkg/database"
 	"github.com/google/uuid"
@@ -57,13 +58,17 @@ func (s *service) SubmitResponse(ctx context.Context, sessionID, scenarioID uuid
 		return nil, errors.New("scenario does not belong to this session")
 	}
 
-	// Validate response time against scenario start time
+	// Validate response time against scenario start time, with the timer of the session's arm
+	condition, err := s.sessionService.GetCondition(*session)
+	if err != nil {
+		return nil, err
+	}
 	if scenario.StartedAt != nil {
 		actualElapsed := time.Since(*scenario.StartedAt).Milliseconds()
-		maxAllowedTime := TimerDurationMs + NetworkBufferMs
+		maxAllowedTime := condition.Timer.DurationMs + condition.Timer.NetworkBufferMs
 		if actualElapsed > maxAllowedTime {
 			input.IsTimeout = true
-			input.ResponseTimeMs = TimerDurationMs
+			input.ResponseTimeMs = condition.Timer.DurationMs
 		}
 	}
 
@@ -83,12 +88,25 @@ func (s *service) SubmitResponse(ctx context.Context, sessionID, scenarioID uuid
 		RankingOrder:   rankingOrderJSON,
 	}
 
-	// Calculate completion before transaction
-	var experimentPlan []any
+	// Catch trials are scored against the option they were built around
+	if scenario.AttentionCheck != "" {
+		passed := !input.IsTimeout && len(input.RankingOrder) > 0 && input.RankingOrder[0] == scenario.ExpectedChoice
+		response.AttentionPassed = &passed
+	}
+
+	// Calculate completion before transaction; design trials and attention checks are counted apart
+	var experimentPlan []domain.ScenarioFactors
 	if err := json.Unmarshal(session.ExperimentPlan, &experimentPlan); err != nil {
 		return nil, err
 	}
-	totalSteps := len(experimentPlan)
+	totalSteps, totalChecks := 0, 0
+	for _, trial := range experimentPlan {
+		if trial.AttentionCheck != "" {
+			totalChecks++
+		} else {
+			totalSteps++
+		}
+	}
 
 	var isComplete bool
 
@@ -103,8 +121,15 @@ func (s *service) SubmitResponse(ctx context.Context, sessionID, scenarioID uuid
 			return err
 		}
 
-		isComplete = responseCount >= totalSteps
+		attention, err := s.repo.GetAttentionResult(txCtx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		isComplete = responseCount >= totalSteps && attention.Checks >= totalChecks
 		if isComplete {
+			session.AttentionChecks = attention.Checks
+			session.AttentionPassed = attention.Passed
 			if err := s.sessionService.CompleteSession(txCtx, *session); err != nil {
 				return err
 			}
@@ -0,0 +1,107 @@
+package scenario
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// clearPathScene stages a clear-path check without the LLM: the star stands in the AV's
+// lane, a pedestrian on one swerve path and nobody on the other, so swerving that way is
+// plainly harmless. The second return is the passing option; false when the spawn's paths
+// overlap too much to keep one of them clear, or leave too little of zone A for the star's group.
+func clearPathScene(factors domain.ScenarioFactors, spawn domain.TridentSpawn, zones domain.TridentZones, trajectories domain.Trajectories) (*domain.ScenarioLLMResponse, string, bool) {
+	clearKey, clear, blocked := domain.OptionSwerveLeft, trajectories.SwerveLeft, trajectories.SwerveRight
+	if rand.Intn(2) == 0 {
+		clearKey, clear, blocked = domain.OptionSwerveRight, trajectories.SwerveRight, trajectories.SwerveLeft
+	}
+	if !clear.Feasible || !blocked.Feasible {
+		return nil, "", false
+	}
+
+	swept := func(path domain.Trajectory) map[domain.Coordinate]bool {
+		tiles := make(map[domain.Coordinate]bool, len(path.Tiles))
+		for _, tile := range path.Tiles {
+			tiles[tile.Coordinate] = true
+		}
+		return tiles
+	}
+	onMaintain, onClear, onBlocked := swept(trajectories.Maintain), swept(clear), swept(blocked)
+
+	// The star's group takes the first zone A tiles only the maintain path sweeps, the
+	// pedestrian the last tile only the blocked path sweeps
+	var starTiles []domain.Coordinate
+	for _, tile := range trajectories.Maintain.Tiles {
+		if tile.Coordinate != spawn.Coordinate && !onClear[tile.Coordinate] && !onBlocked[tile.Coordinate] && zoneHas(zones.ZoneA, tile.Coordinate) {
+			starTiles = append(starTiles, tile.Coordinate)
+		}
+	}
+	var blockTile *domain.EnrichedCoordinate
+	for i, tile := range blocked.Tiles {
+		if !onMaintain[tile.Coordinate] && !onClear[tile.Coordinate] {
+			blockTile = &blocked.Tiles[i]
+		}
+	}
+	if len(starTiles) == 0 || blockTile == nil {
+		return nil, "", false
+	}
+	star := domain.RawEntity{
+		Type: factors.PrimaryEntity,
+		Metadata: domain.EntityMeta{
+			IsStar:      true,
+			IsViolation: factors.PrimaryBehavior == string(domain.BehaviorViolation),
+			Action:      "Standing in the lane ahead",
+			Orientation: string(spawn.Orientation),
+		},
+	}
+	crowd, ok := spreadCrowd(star, factors.Group(), starTiles)
+	if !ok {
+		return nil, "", false
+	}
+
+	pedestrian := domain.CastRandomPedestrian()
+	blockerViolates := !domain.RulesFor(blockTile.Surface).Allows(domain.UsagePedestrian)
+	blockerAction := "Waiting at the roadside"
+	if blockerViolates {
+		blockerAction = "Standing in the road"
+	}
+
+	narrative := "Someone is standing in your lane and another person is standing to one side. The other side is empty."
+	if factors.Group() > 1 {
+		narrative = fmt.Sprintf("A group of %d is standing in your lane and another person is standing to one side. The other side is empty.", factors.Group())
+	}
+
+	return &domain.ScenarioLLMResponse{
+		Narrative: narrative,
+		DilemmaOptions: domain.DilemmaOptions{
+			Maintain:    "Continue straight ahead",
+			SwerveLeft:  "Swerve left",
+			SwerveRight: "Swerve right",
+		},
+		Entities: append(crowd, domain.RawEntity{
+			Type: pedestrian,
+			Row:  blockTile.Row,
+			Col:  blockTile.Col,
+			Metadata: domain.EntityMeta{
+				IsViolation: blockerViolates,
+				Action:      blockerAction,
+				Orientation: string(spawn.Orientation),
+			},
+		}),
+	}, clearKey, true
+}
+
+// addInstruction names a random option in the narrative as the one to rank first and
+// returns it
+func addInstruction(llmRes *domain.ScenarioLLMResponse) string {
+	labels := map[string]string{
+		domain.OptionMaintain:    llmRes.DilemmaOptions.Maintain,
+		domain.OptionSwerveLeft:  llmRes.DilemmaOptions.SwerveLeft,
+		domain.OptionSwerveRight: llmRes.DilemmaOptions.SwerveRight,
+	}
+	keys := []string{domain.OptionMaintain, domain.OptionSwerveLeft, domain.OptionSwerveRight}
+	expected := keys[rand.Intn(len(keys))]
+	llmRes.Narrative += fmt.Sprintf(" Attention check: whatever the scene, please rank %q first.", labels[expected])
+	return expected
+}
@@ -0,0 +1,156 @@
+package scenario
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// starZone returns the zone the star belongs in: zone A when it violates or a clear-path
+// check stages it in the lane, otherwise whichever of B and C the given tile is in. False
+// when the tile is not in that zone.
+func starZone(factors domain.ScenarioFactors, zones domain.TridentZones, at domain.Coordinate) (domain.TridentZone, bool) {
+	candidates := []domain.TridentZone{zones.ZoneB, zones.ZoneC}
+	if factors.PrimaryBehavior == string(domain.BehaviorViolation) || factors.AttentionCheck == domain.CheckClearPath {
+		candidates = []domain.TridentZone{zones.ZoneA}
+	}
+	for _, zone := range candidates {
+		if zoneHas(zone, at) {
+			return zone, true
+		}
+	}
+	return domain.TridentZone{}, false
+}
+
+func zoneHas(zone domain.TridentZone, at domain.Coordinate) bool {
+	return slices.ContainsFunc(zone.Coordinates, func(coord domain.EnrichedCoordinate) bool {
+		return coord.Coordinate == at
+	})
+}
+
+// gatherCrowd turns the star the LLM placed into the planned group. The first star in its
+// zone anchors the group, any other star is dropped, and the members spread over the nearest
+// free tiles of that zone the star may stand on. Fails when no star is in its zone or the
+// zone has no room for the group, so the pool can ask another client.
+func gatherCrowd(entities []domain.RawEntity, factors domain.ScenarioFactors, zones domain.TridentZones) ([]domain.RawEntity, error) {
+	anchor := -1
+	var zone domain.TridentZone
+	for i, entity := range entities {
+		if !entity.Metadata.IsStar {
+			continue
+		}
+		if found, ok := starZone(factors, zones, domain.Coordinate{Row: entity.Row, Col: entity.Col}); ok {
+			anchor, zone = i, found
+			break
+		}
+	}
+	if anchor < 0 {
+		return nil, fmt.Errorf("no %s star placed in its zone", factors.PrimaryBehavior)
+	}
+	star := entities[anchor]
+	start := domain.Coordinate{Row: star.Row, Col: star.Col}
+
+	taken := make(map[domain.Coordinate]bool)
+	for _, entity := range entities {
+		if entity.Metadata.IsStar {
+			continue
+		}
+		for _, cell := range domain.FootprintCells(entity.Type, entity.Row, entity.Col, domain.Direction(entity.Metadata.Orientation)) {
+			taken[cell] = true
+		}
+	}
+	// A compliant star keeps to the surfaces it belongs on; a violating one stands in the road
+	usage := domain.EntityUsage(star.Type)
+	tiles := []domain.Coordinate{start}
+	for _, coord := range zone.Coordinates {
+		if coord.Coordinate == start || taken[coord.Coordinate] {
+			continue
+		}
+		if factors.PrimaryBehavior == string(domain.BehaviorCompliant) && !domain.RulesFor(coord.Surface).Allows(usage) {
+			continue
+		}
+		tiles = append(tiles, coord.Coordinate)
+	}
+	slices.SortStableFunc(tiles[1:], func(a, b domain.Coordinate) int {
+		return distance(start, a) - distance(start, b)
+	})
+
+	crowd, ok := spreadCrowd(star, factors.Group(), tiles)
+	if !ok {
+		return nil, fmt.Errorf("zone has room for %d of a group of %d", len(tiles)*domain.CrowdPerTile, factors.Group())
+	}
+
+	gathered := make([]domain.RawEntity, 0, len(entities)+len(crowd))
+	for i, entity := range entities {
+		switch {
+		case i == anchor:
+			gathered = append(gathered, crowd...)
+		case !entity.Metadata.IsStar:
+			gathered = append(gathered, entity)
+		}
+	}
+	return gathered, nil
+}
+
+// spreadCrowd puts a group of size on the first tiles, one member per tile while there are
+// tiles, then stacking the rest evenly. False when even stacked the group does not fit.
+func spreadCrowd(star domain.RawEntity, size int, tiles []domain.Coordinate) ([]domain.RawEntity, bool) {
+	used := min(size, len(tiles))
+	if used < domain.CrowdTiles(size) {
+		return nil, false
+	}
+	crowd := make([]domain.RawEntity, used)
+	for i, tile := range tiles[:used] {
+		member := star
+		member.Row, member.Col = tile.Row, tile.Col
+		member.Metadata.Count = 0
+		count := size / used
+		if i < size%used {
+			count++
+		}
+		if count > 1 {
+			member.Metadata.Count = count
+		}
+		crowd[i] = member
+	}
+	return crowd, true
+}
+
+// checkCrowd confirms that the whole planned group ended up in the star's zone once every
+// entity is placed
+func checkCrowd(entities []EnrichedEntity, factors domain.ScenarioFactors, zones domain.TridentZones) error {
+	landed, placed := 0, 0
+	var zone *domain.TridentZone
+	for _, entity := range entities {
+		if !entity.Metadata.IsStar {
+			continue
+		}
+		placed += entity.Metadata.Members()
+		if zone == nil {
+			found, ok := starZone(factors, zones, domain.Coordinate{Row: entity.Row, Col: entity.Col})
+			if !ok {
+				continue
+			}
+			zone = &found
+		}
+		if !slices.ContainsFunc(entity.Cells, func(cell domain.Coordinate) bool { return !zoneHas(*zone, cell) }) {
+			landed += entity.Metadata.Members()
+		}
+	}
+	if landed != factors.Group() || placed != factors.Group() {
+		return fmt.Errorf("a group of %d placed %d, %d of them in its zone", factors.Group(), placed, landed)
+	}
+	return nil
+}
+
+func distance(a, b domain.Coordinate) int {
+	return abs(a.Row-b.Row) + abs(a.Col-b.Col)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,6 +1,7 @@
 package scenario
 
 import (
+	"github.com/direwen/go-server/internal/experiment"
 	"github.com/direwen/go-server/internal/shared/domain"
 	"github.com/google/uuid"
 )
@@ -10,26 +11,35 @@ type GenerateRequest struct {
 }
 
 type GetNextResponse struct {
-	ID             uuid.UUID              `json:"id"`
-	Narrative      string                 `json:"narrative"`
-	DilemmaOptions domain.DilemmaOptions  `json:"dilemma_options"`
-	Entities       []EnrichedEntity       `json:"entities"`
-	Factors        domain.ScenarioFactors `json:"factors"`
-	Width          int                    `json:"width"`
-	Height         int                    `json:"height"`
-	GridData       [][]int                `json:"grid_data"`
-	LaneConfig     domain.LaneConfigMap   `json:"lane_config"`
-	TridentZones   domain.TridentZones    `json:"trident_zones"`
-	TemplateName   string                 `json:"template_name"`
-	CurrentStep    int                    `json:"current_step"`
-	TotalSteps     int                    `json:"total_steps"`
+	ID             uuid.UUID                `json:"id"`
+	Narrative      string                   `json:"narrative"`
+	DilemmaOptions domain.DilemmaOptions    `json:"dilemma_options"`
+	Entities       []EnrichedEntity         `json:"entities"`
+	Factors        domain.ScenarioFactors   `json:"factors"`
+	Width          int                      `json:"width"`
+	Height         int                      `json:"height"`
+	GridData       [][]int                  `json:"grid_data"`
+	LaneConfig     domain.LaneConfigMap     `json:"lane_config"`
+	DrivingSide    domain.DrivingSide       `json:"driving_side"`
+	TridentZones   domain.TridentZones      `json:"trident_zones"`
+	Trajectories   domain.Trajectories      `json:"trajectories"`
+	Signals        []domain.SignalHead      `json:"signals"` // signal heads with their state, empty when unsignalised
+	Regions        []domain.Region          `json:"regions"` // metadata layers of the template
+	RegionContext  domain.RegionContext     `json:"region_context"`
+	TemplateName   string                   `json:"template_name"`
+	CurrentStep    int                      `json:"current_step"`
+	TotalSteps     int                      `json:"total_steps"`
+	Timer          experiment.TimerSettings `json:"timer"`   // answer window of the session's arm
+	Framing        domain.Framing           `json:"framing"` // point of view of the session's arm
 }
 
 type EnrichedEntity struct {
-	ID       string            `json:"id"`
-	Type     string            `json:"type"`
-	Emoji    string            `json:"emoji"`
-	Row      int               `json:"row"`
-	Col      int               `json:"col"`
-	Metadata domain.EntityMeta `json:"metadata"`
+	ID       string              `json:"id"`
+	Type     string              `json:"type"`
+	Emoji    string              `json:"emoji"`
+	Row      int                 `json:"row"`
+	Col      int                 `json:"col"`
+	Cells    []domain.Coordinate `json:"cells"`            // every tile the entity covers, front first
+	Signal   domain.SignalPhase  `json:"signal,omitempty"` // Walk/DontWalk where a pedestrian stands on a signalised crosswalk
+	Metadata domain.EntityMeta   `json:"metadata"`
 }
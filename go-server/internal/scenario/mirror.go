@@ -0,0 +1,176 @@
+package scenario
+
+import (
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// mirror reflects a scene left to right across the AV's heading: the columns for a north or
+// south heading, the rows for an east or west one. Traffic then drives on the other side and
+// the swerve paths, zones B and C and the swerve options trade places.
+type mirror struct {
+	width, height int
+	columns       bool // flip columns, otherwise rows
+}
+
+func newMirror(heading domain.Direction, width, height int) mirror {
+	return mirror{
+		width:   width,
+		height:  height,
+		columns: heading == domain.DirectionNorth || heading == domain.DirectionSouth,
+	}
+}
+
+func (m mirror) coord(c domain.Coordinate) domain.Coordinate {
+	if m.columns {
+		return domain.Coordinate{Row: c.Row, Col: m.width - 1 - c.Col}
+	}
+	return domain.Coordinate{Row: m.height - 1 - c.Row, Col: c.Col}
+}
+
+func (m mirror) enriched(c domain.EnrichedCoordinate) domain.EnrichedCoordinate {
+	c.Coordinate = m.coord(c.Coordinate)
+	c.Orientation = m.direction(c.Orientation)
+	return c
+}
+
+func (m mirror) direction(d domain.Direction) domain.Direction {
+	switch {
+	case m.columns && d == domain.DirectionEast:
+		return domain.DirectionWest
+	case m.columns && d == domain.DirectionWest:
+		return domain.DirectionEast
+	case !m.columns && d == domain.DirectionNorth:
+		return domain.DirectionSouth
+	case !m.columns && d == domain.DirectionSouth:
+		return domain.DirectionNorth
+	}
+	return d
+}
+
+// tiles swaps a tile for its mirror image by name, e.g. Sidewalk Left for Sidewalk Right;
+// tiles without one are symmetric
+func (m mirror) tiles() func(id int) int {
+	from, to := "Top", "Bottom"
+	if m.columns {
+		from, to = "Left", "Right"
+	}
+	swap := strings.NewReplacer(from, to, to, from)
+	byName := make(map[string]int, len(domain.TileRegistry))
+	for id, tile := range domain.TileRegistry {
+		byName[tile.Name] = id
+	}
+	return func(id int) int {
+		tile, ok := domain.TileRegistry[id]
+		if !ok {
+			return id
+		}
+		if mirrored, ok := byName[swap.Replace(tile.Name)]; ok {
+			return mirrored
+		}
+		return id
+	}
+}
+
+func (m mirror) trajectory(path domain.Trajectory) domain.Trajectory {
+	points := make([]domain.TrajectoryPoint, len(path.Points))
+	for i, p := range path.Points {
+		if m.columns {
+			points[i] = domain.TrajectoryPoint{Row: p.Row, Col: float64(m.width-1) - p.Col}
+		} else {
+			points[i] = domain.TrajectoryPoint{Row: float64(m.height-1) - p.Row, Col: p.Col}
+		}
+	}
+	tiles := make([]domain.EnrichedCoordinate, len(path.Tiles))
+	for i, tile := range path.Tiles {
+		tiles[i] = m.enriched(tile)
+	}
+	path.Points, path.Tiles = points, tiles
+	return path
+}
+
+func (m mirror) zone(zone domain.TridentZone) domain.TridentZone {
+	coords := make([]domain.EnrichedCoordinate, len(zone.Coordinates))
+	for i, coord := range zone.Coordinates {
+		coords[i] = m.enriched(coord)
+	}
+	return domain.TridentZone{Coordinates: coords}
+}
+
+// apply mirrors everything a participant is shown. Collisions keep their entity IDs.
+func (m mirror) apply(res *GetNextResponse) {
+	tile := m.tiles()
+	grid := make([][]int, len(res.GridData))
+	for r, row := range res.GridData {
+		grid[r] = make([]int, len(row))
+	}
+	for r, row := range res.GridData {
+		for c, id := range row {
+			if at := m.coord(domain.Coordinate{Row: r, Col: c}); at.Row >= 0 && at.Row < len(grid) && at.Col >= 0 && at.Col < len(grid[at.Row]) {
+				grid[at.Row][at.Col] = tile(id)
+			}
+		}
+	}
+	res.GridData = grid
+
+	lanes := make(domain.LaneConfigMap, len(res.LaneConfig))
+	for direction, coords := range res.LaneConfig {
+		mirrored := make([][2]int, len(coords))
+		for i, coord := range coords {
+			at := m.coord(domain.Coordinate{Row: coord[0], Col: coord[1]})
+			mirrored[i] = [2]int{at.Row, at.Col}
+		}
+		lanes[m.direction(direction)] = mirrored
+	}
+	res.LaneConfig = lanes
+	res.DrivingSide = res.DrivingSide.Opposite()
+
+	res.TridentZones = domain.TridentZones{
+		ZoneA: m.zone(res.TridentZones.ZoneA),
+		ZoneB: m.zone(res.TridentZones.ZoneC),
+		ZoneC: m.zone(res.TridentZones.ZoneB),
+	}
+	res.Trajectories = domain.Trajectories{
+		Maintain:    m.trajectory(res.Trajectories.Maintain),
+		SwerveLeft:  m.trajectory(res.Trajectories.SwerveRight),
+		SwerveRight: m.trajectory(res.Trajectories.SwerveLeft),
+	}
+
+	signals := make([]domain.SignalHead, len(res.Signals))
+	for i, head := range res.Signals {
+		head.Coordinate = m.coord(head.Coordinate)
+		head.Direction = m.direction(head.Direction)
+		signals[i] = head
+	}
+	res.Signals = signals
+
+	regions := make([]domain.Region, len(res.Regions))
+	for i, region := range res.Regions {
+		if m.columns {
+			region.Left, region.Right = m.width-1-region.Right, m.width-1-region.Left
+		} else {
+			region.Top, region.Bottom = m.height-1-region.Bottom, m.height-1-region.Top
+		}
+		regions[i] = region
+	}
+	res.Regions = regions
+
+	entities := make([]EnrichedEntity, len(res.Entities))
+	for i, entity := range res.Entities {
+		at := m.coord(domain.Coordinate{Row: entity.Row, Col: entity.Col})
+		entity.Row, entity.Col = at.Row, at.Col
+		cells := make([]domain.Coordinate, len(entity.Cells))
+		for j, cell := range entity.Cells {
+			cells[j] = m.coord(cell)
+		}
+		entity.Cells = cells
+		entity.Metadata.Orientation = string(m.direction(domain.Direction(entity.Metadata.Orientation)))
+		entities[i] = entity
+	}
+	res.Entities = entities
+
+	// The swerve options follow their paths; free text is shown as written, since rewriting
+	// its directions cannot tell a side of the road from any other use of the words
+	res.DilemmaOptions.SwerveLeft, res.DilemmaOptions.SwerveRight = res.DilemmaOptions.SwerveRight, res.DilemmaOptions.SwerveLeft
+}
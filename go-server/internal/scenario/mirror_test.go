@@ -0,0 +1,153 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// mirrorScene is a small lopsided scene, 4 wide and 3 high, with something on every layer
+// the mirror touches
+func mirrorScene() *GetNextResponse {
+	tile := func(row, col int, orientation domain.Direction) domain.EnrichedCoordinate {
+		return domain.EnrichedCoordinate{Coordinate: domain.Coordinate{Row: row, Col: col}, Surface: domain.SurfaceDrivable, Orientation: orientation}
+	}
+	path := func(cols ...int) domain.Trajectory {
+		trajectory := domain.Trajectory{Feasible: true, Collisions: []string{"e1"}}
+		for _, col := range cols {
+			trajectory.Points = append(trajectory.Points, domain.TrajectoryPoint{Row: 1.5, Col: float64(col)})
+			trajectory.Tiles = append(trajectory.Tiles, tile(1, col, domain.DirectionNorth))
+		}
+		return trajectory
+	}
+	return &GetNextResponse{
+		Narrative: "A cyclist waits on the right.",
+		DilemmaOptions: domain.DilemmaOptions{
+			Maintain:    "Brake in lane",
+			SwerveLeft:  "Swerve into the parked van",
+			SwerveRight: "Swerve onto the sidewalk",
+		},
+		GridData: [][]int{
+			{19, 10, 17, 20},
+			{5, 9, 11, 7},
+			{19, 23, 0, 20},
+		},
+		LaneConfig: domain.LaneConfigMap{
+			domain.DirectionNorth: {{0, 1}, {1, 1}},
+			domain.DirectionEast:  {{1, 0}, {1, 1}, {1, 2}},
+		},
+		DrivingSide: domain.DrivingSideRight,
+		TridentZones: domain.TridentZones{
+			ZoneA: domain.TridentZone{Coordinates: []domain.EnrichedCoordinate{tile(0, 1, domain.DirectionNorth)}},
+			ZoneB: domain.TridentZone{Coordinates: []domain.EnrichedCoordinate{tile(0, 0, "")}},
+			ZoneC: domain.TridentZone{Coordinates: []domain.EnrichedCoordinate{tile(0, 2, domain.DirectionEast), tile(0, 3, "")}},
+		},
+		Trajectories: domain.Trajectories{
+			Maintain:    path(1),
+			SwerveLeft:  path(1, 0),
+			SwerveRight: path(1, 2, 3),
+		},
+		Signals: []domain.SignalHead{
+			{Junction: "J1", Kind: domain.SignalKindVehicle, Coordinate: domain.Coordinate{Row: 2, Col: 3}, Direction: domain.DirectionWest},
+		},
+		Regions: []domain.Region{
+			{Kind: domain.RegionSchoolZone, Top: 0, Left: 2, Bottom: 1, Right: 3},
+		},
+		Entities: []EnrichedEntity{
+			{
+				ID: "e1", Type: "vehicle_van", Row: 1, Col: 2,
+				Cells:    []domain.Coordinate{{Row: 1, Col: 2}, {Row: 1, Col: 3}},
+				Metadata: domain.EntityMeta{Orientation: string(domain.DirectionEast)},
+			},
+		},
+	}
+}
+
+func TestMirrorApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		heading domain.Direction
+		check   func(t *testing.T, original, mirrored *GetNextResponse)
+	}{
+		{
+			name:    "heading north flips columns",
+			heading: domain.DirectionNorth,
+			check: func(t *testing.T, original, mirrored *GetNextResponse) {
+				if got := mirrored.GridData[1]; !reflect.DeepEqual(got, []int{5, 11, 9, 7}) {
+					t.Errorf("row 1 = %v, want corners swapped and the row reversed", got)
+				}
+				if got := mirrored.GridData[0][0]; got != 19 {
+					t.Errorf("tile at 0,0 = %d, want Sidewalk Left (19)", got)
+				}
+				if e := mirrored.Entities[0]; e.Col != 1 || e.Metadata.Orientation != string(domain.DirectionWest) {
+					t.Errorf("entity at col %d facing %s, want col 1 facing west", e.Col, e.Metadata.Orientation)
+				}
+				if r := mirrored.Regions[0]; r.Left != 0 || r.Right != 1 || r.Top != 0 || r.Bottom != 1 {
+					t.Errorf("region = %+v, want columns 0-1", r)
+				}
+				if got := mirrored.LaneConfig[domain.DirectionWest]; len(got) != 3 {
+					t.Errorf("west lanes = %v, want the east lane mirrored", got)
+				}
+			},
+		},
+		{
+			name:    "heading east flips rows",
+			heading: domain.DirectionEast,
+			check: func(t *testing.T, original, mirrored *GetNextResponse) {
+				if got := mirrored.GridData[0]; !reflect.DeepEqual(got, []int{19, 23, 0, 20}) {
+					t.Errorf("row 0 = %v, want the bottom row", got)
+				}
+				if got := mirrored.GridData[1]; !reflect.DeepEqual(got, []int{6, 9, 11, 8}) {
+					t.Errorf("row 1 = %v, want top corners turned to bottom ones", got)
+				}
+				if s := mirrored.Signals[0]; s.Row != 0 || s.Direction != domain.DirectionWest {
+					t.Errorf("signal at row %d facing %s, want row 0 facing west", s.Row, s.Direction)
+				}
+				if r := mirrored.Regions[0]; r.Top != 1 || r.Bottom != 2 {
+					t.Errorf("region = %+v, want rows 1-2", r)
+				}
+				if got := mirrored.LaneConfig[domain.DirectionSouth]; len(got) != 2 {
+					t.Errorf("south lanes = %v, want the north lane mirrored", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := mirrorScene()
+			mirror := newMirror(tt.heading, len(original.GridData[0]), len(original.GridData))
+
+			mirrored := mirrorScene()
+			mirror.apply(mirrored)
+			tt.check(t, original, mirrored)
+
+			// The sides trade places; the texts are kept as written
+			if mirrored.DrivingSide != domain.DrivingSideLeft {
+				t.Errorf("driving side = %s, want left", mirrored.DrivingSide)
+			}
+			if !reflect.DeepEqual(mirrored.TridentZones.ZoneB.Coordinates, mirror.zone(original.TridentZones.ZoneC).Coordinates) {
+				t.Error("zone B is not the mirrored zone C")
+			}
+			if mirrored.Trajectories.SwerveLeft.Points[len(mirrored.Trajectories.SwerveLeft.Points)-1] !=
+				mirror.trajectory(original.Trajectories.SwerveRight).Points[2] {
+				t.Error("the left swerve is not the mirrored right swerve")
+			}
+			if mirrored.DilemmaOptions.SwerveLeft != original.DilemmaOptions.SwerveRight ||
+				mirrored.DilemmaOptions.SwerveRight != original.DilemmaOptions.SwerveLeft ||
+				mirrored.DilemmaOptions.Maintain != original.DilemmaOptions.Maintain {
+				t.Errorf("options = %+v, want the swerves swapped", mirrored.DilemmaOptions)
+			}
+			if mirrored.Narrative != original.Narrative {
+				t.Errorf("narrative = %q, want it unchanged", mirrored.Narrative)
+			}
+
+			// Mirroring is its own inverse
+			mirror.apply(mirrored)
+			if !reflect.DeepEqual(mirrored, original) {
+				t.Errorf("mirrored twice = %+v, want %+v", mirrored, original)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ type Repository interface {
 	GetByID(ctx context.Context, id uuid.UUID, opts ...database.QueryOption) (*Scenario, error)
 	GetUsedTemplateIDs(ctx context.Context, sessionID uuid.UUID) ([]uuid.UUID, error)
 	GetPendingScenario(ctx context.Context, sessionID uuid.UUID, opts ...database.QueryOption) (*Scenario, error)
+	GetByStep(ctx context.Context, sessionID uuid.UUID, step int, opts ...database.QueryOption) (*Scenario, error)
 }
 
 type repository struct {
@@ -75,3 +76,14 @@ func (r *repository) GetPendingScenario(ctx context.Context, sessionID uuid.UUID
 
 	return &s, nil
 }
+
+// GetByStep returns the scenario a session was shown at a 1-based step of its plan
+func (r *repository) GetByStep(ctx context.Context, sessionID uuid.UUID, step int, opts ...database.QueryOption) (*Scenario, error) {
+	var s Scenario
+	db := database.GetDB(ctx, r.db).WithContext(ctx).
+		Model(&Scenario{}).
+		Where("session_id = ? AND step = ?", sessionID, step)
+	db = database.ApplyOptions(db, opts...)
+	err := db.First(&s).Error
+	return &s, err
+}
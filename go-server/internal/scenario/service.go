@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/direwen/go-server/internal/experiment"
 	"github.com/direwen/go-server/internal/session"
 	"github.com/direwen/go-server/internal/shared/domain"
 	"github.com/direwen/go-server/internal/shared/models"
@@ -56,6 +58,14 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 	}
 	totalSteps := len(experimentPlan)
 
+	// The template pool comes from the study the session registered for, the timer,
+	// narrative and framing from its arm
+	study, err := s.sessionService.GetExperiment(*session)
+	if err != nil {
+		return nil, err
+	}
+	condition := study.Condition(session.Arm)
+
 	// Get used scenario context template ids for progress tracking
 	usedContextIDs, err := s.repo.GetUsedTemplateIDs(ctx, sessionID)
 	if err != nil {
@@ -73,51 +83,8 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 			}
 		}
 
-		tmpl, err := s.templateService.GetByID(pendingScenario.ContextTemplateID)
-		if err != nil {
-			return nil, err
-		}
-		var entities []EnrichedEntity
-		if err := json.Unmarshal(pendingScenario.Entities, &entities); err != nil {
-			return nil, err
-		}
-		var factors domain.ScenarioFactors
-		if err := json.Unmarshal(pendingScenario.Factors, &factors); err != nil {
-			return nil, err
-		}
-		var dilemmaOptions domain.DilemmaOptions
-		if err := json.Unmarshal(pendingScenario.DilemmaOptions, &dilemmaOptions); err != nil {
-			return nil, err
-		}
-		var gridData [][]int
-		if err := json.Unmarshal(tmpl.GridData, &gridData); err != nil {
-			return nil, err
-		}
-		var tridentSpawn domain.TridentSpawn
-		if err := json.Unmarshal(pendingScenario.TridentSpawn, &tridentSpawn); err != nil {
-			return nil, err
-		}
-		// Recalculate trident zones from stored spawn
-		tridentZones := s.templateService.CalculateTridentZones(tmpl.Id, tridentSpawn)
-
 		// Current step is the count of used templates + 1 (for the pending one)
-		currentStep := len(usedContextIDs) + 1
-
-		return &GetNextResponse{
-			ID:             pendingScenario.Id,
-			Narrative:      pendingScenario.Narrative,
-			DilemmaOptions: dilemmaOptions,
-			Entities:       entities,
-			Factors:        factors,
-			Width:          tmpl.Width,
-			Height:         tmpl.Height,
-			GridData:       gridData,
-			LaneConfig:     s.templateService.GetLaneConfig(tmpl.Id),
-			TridentZones:   tridentZones,
-			TemplateName:   tmpl.Name,
-			CurrentStep:    currentStep,
-			TotalSteps:     totalSteps,
-		}, nil
+		return s.replay(ctx, pendingScenario, condition, len(usedContextIDs)+1, totalSteps)
 	}
 
 	// Check Progress
@@ -126,43 +93,111 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 	}
 	currentStep := len(usedContextIDs)
 
-	// Pick a context template and factors for the current scenario
-	contextTemplate, err := s.templateService.PickTemplate(usedContextIDs)
+	// Pick factors, then a context template that can physically host them
+	currentFactors := experimentPlan[currentStep]
+	// Repeats copy the scenario of the trial they repeat instead of generating one
+	if currentFactors.Repeat != nil {
+		return s.repeatScenario(ctx, sessionID, currentFactors, condition, currentStep+1, totalSteps)
+	}
+	// Lanes and spawns follow the traffic rule of the scenario location
+	drivingSide := domain.DrivingSideFor(currentFactors.Location)
+	spawnCriteria := template.SpawnCriteriaFor(currentFactors)
+	// Rules on school zones and other areas are settled by where the trial is placed
+	spawnCriteria.Area = func(regions domain.RegionContext) bool {
+		return domain.AllowsArea(study.Design.Constraints, currentFactors, regions)
+	}
+	// A clear-path check needs both swerves open so one can be kept empty
+	check := currentFactors.AttentionCheck
+	if check == domain.CheckClearPath {
+		spawnCriteria.FeasibleSwerves = true
+	}
+
+	contextTemplate, err := s.templateService.PickTemplate(usedContextIDs, study.TemplatePool, drivingSide, spawnCriteria)
+	if err != nil {
+		// The loader checks each level against the pool's areas, but a combination of levels
+		// can still miss them all; placing the trial beats failing the session
+		spawnCriteria.Area = nil
+		contextTemplate, err = s.templateService.PickTemplate(usedContextIDs, study.TemplatePool, drivingSide, spawnCriteria)
+		if err == nil {
+			log.Printf("No area in the pool fits the rules for trial %d of session %s, ignoring area rules", currentStep+1, sessionID)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	currentFactors := experimentPlan[currentStep]
 
-	// Select a Trident Spawn point
-	tridentSpawn, err := s.templateService.GetRandomTridentSpawn(contextTemplate.Id)
+	// Geometry lookups go through the pinned version so a concurrent reload cannot change the map mid-request
+	layoutID := contextTemplate.Id
+	if contextTemplate.CurrentVersionID != nil {
+		layoutID = *contextTemplate.CurrentVersionID
+	}
+
+	// Select a Trident Spawn point where the star and tailgater fit; its zones (with
+	// expandable B/C) and swerve paths were computed when the template was loaded
+	candidate, err := s.templateService.PickSpawn(layoutID, drivingSide, spawnCriteria)
 	if err != nil {
 		return nil, errors.New("failed to get a trident spawn point")
 	}
-	// Calculate Trident Zones (with expandable B/C)
-	tridentZones := s.templateService.CalculateTridentZones(contextTemplate.Id, *tridentSpawn)
+	tridentSpawn := candidate.Spawn
+	tridentZones := candidate.Zones
+	trajectories := candidate.Trajectories
+	signals := s.templateService.GetSignals(layoutID, drivingSide, tridentSpawn, domain.SignalPhase(currentFactors.SignalPhase))
+	regionContext := s.templateService.GetRegionContext(layoutID, drivingSide, tridentSpawn)
 
 	// Build Scenario LLM Request
 	var gridData [][]int
 	if err := json.Unmarshal(contextTemplate.GridData, &gridData); err != nil {
 		return nil, err
 	}
-	laneConfig := s.templateService.GetLaneConfig(contextTemplate.Id)
-
-	result, err := s.llmPool.Execute(domain.TaskScenario, func(client domain.Client) (any, error) {
-		scenarioClient := client.(domain.LLMClient)
-		return scenarioClient.GenerateScenario(ctx, domain.ScenarioLLMRequest{
-			TemplateName:   contextTemplate.Name,
-			GridDimensions: fmt.Sprintf("%d:%d", contextTemplate.Width, contextTemplate.Height),
-			Factors:        currentFactors,
-			EgoPosition:    tridentSpawn.Coordinate,
-			EgoOrientation: tridentSpawn.Orientation,
-			TridentZones:   tridentZones,
+	laneConfig := s.templateService.GetLaneConfig(layoutID, drivingSide)
+
+	// Clear-path checks are staged without the LLM; one that cannot be staged on this spawn
+	// becomes an instruction check
+	var llmRes *domain.ScenarioLLMResponse
+	var expectedChoice string
+	if check == domain.CheckClearPath {
+		var staged bool
+		llmRes, expectedChoice, staged = clearPathScene(currentFactors, tridentSpawn, tridentZones, trajectories)
+		if !staged {
+			check = domain.CheckInstruction
+			currentFactors.AttentionCheck = check
+		}
+	}
+
+	if llmRes == nil {
+		result, err := s.llmPool.Execute(domain.TaskScenario, func(client domain.Client) (any, error) {
+			scenarioClient := client.(domain.LLMClient)
+			res, err := scenarioClient.GenerateScenario(ctx, domain.ScenarioLLMRequest{
+				TemplateName:   contextTemplate.Name,
+				GridDimensions: fmt.Sprintf("%d:%d", contextTemplate.Width, contextTemplate.Height),
+				Factors:        currentFactors,
+				DrivingSide:    drivingSide,
+				EgoPosition:    tridentSpawn.Coordinate,
+				EgoOrientation: tridentSpawn.Orientation,
+				TridentZones:   tridentZones,
+				Signals:        signals,
+				Regions:        regionContext,
+				Framing:        condition.Framing,
+			})
+			if err != nil {
+				return nil, err
+			}
+			// The LLM places the star once; its group is spread here so the count is exact
+			res.Entities, err = gatherCrowd(res.Entities, currentFactors, tridentZones)
+			if err != nil {
+				log.Printf("Rejecting scenario: %v", err)
+				return nil, err
+			}
+			return res, nil
 		})
-	})
-	if err != nil {
-		return nil, errors.New("failed to generate scenario")
+		if err != nil {
+			return nil, errors.New("failed to generate scenario")
+		}
+		llmRes = result.(*domain.ScenarioLLMResponse)
+	}
+	if check == domain.CheckInstruction {
+		expectedChoice = addInstruction(llmRes)
 	}
-	llmRes := result.(*domain.ScenarioLLMResponse)
 
 	// Add Ego AV entity (fixed position, not from LLM)
 	egoEntity := EnrichedEntity{
@@ -171,6 +206,7 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 		Emoji: domain.EntityRegistry["vehicle_av"].Emoji,
 		Row:   tridentSpawn.Row,
 		Col:   tridentSpawn.Col,
+		Cells: []domain.Coordinate{tridentSpawn.Coordinate},
 		Metadata: domain.EntityMeta{
 			IsStar:      false,
 			IsEgo:       true,
@@ -184,34 +220,33 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 	enrichedEntities := make([]EnrichedEntity, 0, len(llmRes.Entities)+1)
 	enrichedEntities = append(enrichedEntities, egoEntity)
 	for i, e := range llmRes.Entities {
-		info := domain.EntityRegistry[e.Type]
-		enrichedEntities = append(enrichedEntities, EnrichedEntity{
-			ID:    fmt.Sprintf("ent_%s_%d", e.Type, i),
-			Type:  e.Type,
-			Emoji: info.Emoji,
-			Row:   e.Row,
-			Col:   e.Col,
+		entity := EnrichedEntity{
+			Type: e.Type,
+			Row:  e.Row,
+			Col:  e.Col,
 			Metadata: domain.EntityMeta{
 				IsStar:      e.Metadata.IsStar,
 				IsEgo:       e.Metadata.IsEgo,
 				IsViolation: e.Metadata.IsViolation,
 				Action:      e.Metadata.Action,
 				Orientation: e.Metadata.Orientation,
+				Count:       e.Metadata.Count,
 			},
-		})
+		}
+		s.placeEntity(layoutID, drivingSide, &entity)
+		entity.ID = fmt.Sprintf("ent_%s_%d", entity.Type, i)
+		entity.Emoji = domain.EntityRegistry[entity.Type].Emoji
+		enrichedEntities = append(enrichedEntities, entity)
 	}
 
 	// Inject Tailgater if True
 	if currentFactors.HasTailgater {
-		rearCoord, err := s.templateService.GetRearCoordinate(contextTemplate.Id, tridentSpawn.Row, tridentSpawn.Col, tridentSpawn.Orientation)
+		rearCoord, err := s.templateService.GetRearCoordinate(layoutID, drivingSide, tridentSpawn.Row, tridentSpawn.Col, tridentSpawn.Orientation)
 		if err == nil {
-			vehType := domain.CastRandomVehicle()
 			tailgaterEntity := EnrichedEntity{
-				ID:    "ent_" + vehType + "_tailgater",
-				Type:  vehType,
-				Emoji: domain.EntityRegistry[vehType].Emoji,
-				Row:   rearCoord.Row,
-				Col:   rearCoord.Col,
+				Type: domain.CastRandomVehicle(),
+				Row:  rearCoord.Row,
+				Col:  rearCoord.Col,
 				Metadata: domain.EntityMeta{
 					IsStar:      false,
 					IsEgo:       false,
@@ -220,10 +255,30 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 					Orientation: string(rearCoord.Orientation),
 				},
 			}
+			s.placeEntity(layoutID, drivingSide, &tailgaterEntity)
+			// A long vehicle that had to slide forward would sit on top of the AV
+			if tailgaterEntity.Row != rearCoord.Row || tailgaterEntity.Col != rearCoord.Col {
+				tailgaterEntity.Type = fallbackVehicle
+				tailgaterEntity.Row, tailgaterEntity.Col = rearCoord.Row, rearCoord.Col
+				tailgaterEntity.Cells = []domain.Coordinate{rearCoord.Coordinate}
+			}
+			tailgaterEntity.ID = "ent_" + tailgaterEntity.Type + "_tailgater"
+			tailgaterEntity.Emoji = domain.EntityRegistry[tailgaterEntity.Type].Emoji
 			enrichedEntities = append(enrichedEntities, tailgaterEntity)
 		}
 	}
 
+	// The whole planned group must stand in the star's zone
+	if err := checkCrowd(enrichedEntities, currentFactors, tridentZones); err != nil {
+		return nil, fmt.Errorf("failed to place the star group: %w", err)
+	}
+
+	// Legality on a crosswalk is what the signal shows, not what the narrative claims
+	markCrossings(enrichedEntities, signals)
+
+	// Paths are shared with the template cache; the copy records what this scenario puts on them
+	trajectories = markCollisions(trajectories, enrichedEntities)
+
 	// Serialize for DB storage
 	entitiesJSON, err := json.Marshal(enrichedEntities)
 	if err != nil {
@@ -241,17 +296,26 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal trident spawn: %w", err)
 	}
+	regionContextJSON, err := json.Marshal(regionContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal region context: %w", err)
+	}
 
 	now := time.Now()
 	newScenario := &Scenario{
-		SessionID:         sessionID,
-		Entities:          entitiesJSON,
-		Factors:           factorsJSON,
-		DilemmaOptions:    dilemmaOptionsJSON,
-		ContextTemplateID: contextTemplate.Id,
-		Narrative:         llmRes.Narrative,
-		TridentSpawn:      tridentSpawnJSON,
-		StartedAt:         &now,
+		SessionID:                sessionID,
+		Step:                     currentStep + 1,
+		Entities:                 entitiesJSON,
+		Factors:                  factorsJSON,
+		DilemmaOptions:           dilemmaOptionsJSON,
+		ContextTemplateID:        contextTemplate.Id,
+		ContextTemplateVersionID: contextTemplate.CurrentVersionID,
+		Narrative:                llmRes.Narrative,
+		TridentSpawn:             tridentSpawnJSON,
+		RegionContext:            regionContextJSON,
+		StartedAt:                &now,
+		AttentionCheck:           string(check),
+		ExpectedChoice:           expectedChoice,
 	}
 	// Save to DB with retry
 	if err := util.Retry(ctx, 3, 50*time.Millisecond, func() error {
@@ -263,21 +327,247 @@ func (s *service) GetNextScenario(ctx context.Context, sessionID uuid.UUID) (*Ge
 		ID:             newScenario.Id,
 		GridData:       gridData,
 		LaneConfig:     laneConfig,
+		DrivingSide:    drivingSide,
 		Entities:       enrichedEntities,
 		Width:          contextTemplate.Width,
 		Height:         contextTemplate.Height,
-		Narrative:      llmRes.Narrative,
+		Narrative:      narrativeFor(condition, check, llmRes.Narrative),
 		DilemmaOptions: llmRes.DilemmaOptions,
-		Factors:        currentFactors,
+		Factors:        participantFactors(currentFactors),
 		TridentZones:   tridentZones,
+		Trajectories:   trajectories,
+		Signals:        signals,
+		Regions:        s.templateService.GetRegions(layoutID),
+		RegionContext:  regionContext,
 		TemplateName:   contextTemplate.Name,
 		CurrentStep:    currentStep + 1,
 		TotalSteps:     totalSteps,
+		Timer:          condition.Timer,
+		Framing:        condition.Framing,
+	}
+
+	return res, nil
+}
+
+// replay rebuilds what the participant is shown from a stored scenario, on the exact map
+// revision it was generated on
+func (s *service) replay(ctx context.Context, stored *Scenario, condition experiment.Condition, currentStep, totalSteps int) (*GetNextResponse, error) {
+	layoutID := stored.ContextTemplateID
+	var templateName string
+	var templateWidth, templateHeight int
+	var templateGrid []byte
+	if stored.ContextTemplateVersionID != nil {
+		version, err := s.templateService.GetVersion(ctx, *stored.ContextTemplateVersionID)
+		if err != nil {
+			return nil, err
+		}
+		layoutID = version.Id
+		templateName, templateWidth, templateHeight, templateGrid = version.Name, version.Width, version.Height, version.GridData
+	} else {
+		tmpl, err := s.templateService.GetByID(stored.ContextTemplateID)
+		if err != nil {
+			return nil, err
+		}
+		templateName, templateWidth, templateHeight, templateGrid = tmpl.Name, tmpl.Width, tmpl.Height, tmpl.GridData
+	}
+	var entities []EnrichedEntity
+	if err := json.Unmarshal(stored.Entities, &entities); err != nil {
+		return nil, err
+	}
+	var factors domain.ScenarioFactors
+	if err := json.Unmarshal(stored.Factors, &factors); err != nil {
+		return nil, err
+	}
+	var dilemmaOptions domain.DilemmaOptions
+	if err := json.Unmarshal(stored.DilemmaOptions, &dilemmaOptions); err != nil {
+		return nil, err
+	}
+	var gridData [][]int
+	if err := json.Unmarshal(templateGrid, &gridData); err != nil {
+		return nil, err
+	}
+	var tridentSpawn domain.TridentSpawn
+	if err := json.Unmarshal(stored.TridentSpawn, &tridentSpawn); err != nil {
+		return nil, err
+	}
+	drivingSide := domain.DrivingSideFor(factors.Location)
+	// Look up trident zones for the stored spawn (cached unless the spawn is no longer valid)
+	tridentZones := s.templateService.CalculateTridentZones(layoutID, drivingSide, tridentSpawn)
+	trajectories := s.templateService.CalculateTrajectories(layoutID, drivingSide, tridentSpawn)
+	// Scenarios stored before footprints existed only have the front tile
+	for i := range entities {
+		if len(entities[i].Cells) == 0 {
+			entities[i].Cells = []domain.Coordinate{{Row: entities[i].Row, Col: entities[i].Col}}
+		}
+	}
+	trajectories = markCollisions(trajectories, entities)
+	signals := s.templateService.GetSignals(layoutID, drivingSide, tridentSpawn, domain.SignalPhase(factors.SignalPhase))
+	// Scenarios stored before regions existed are summarised from the template
+	regionContext := s.templateService.GetRegionContext(layoutID, drivingSide, tridentSpawn)
+	if stored.RegionContext != nil {
+		if err := json.Unmarshal(stored.RegionContext, &regionContext); err != nil {
+			return nil, err
+		}
 	}
 
+	res := &GetNextResponse{
+		ID:             stored.Id,
+		Narrative:      narrativeFor(condition, domain.AttentionCheckKind(stored.AttentionCheck), stored.Narrative),
+		DilemmaOptions: dilemmaOptions,
+		Entities:       entities,
+		Factors:        participantFactors(factors),
+		Width:          templateWidth,
+		Height:         templateHeight,
+		GridData:       gridData,
+		LaneConfig:     s.templateService.GetLaneConfig(layoutID, drivingSide),
+		DrivingSide:    drivingSide,
+		TridentZones:   tridentZones,
+		Trajectories:   trajectories,
+		Signals:        signals,
+		Regions:        s.templateService.GetRegions(layoutID),
+		RegionContext:  regionContext,
+		TemplateName:   templateName,
+		CurrentStep:    currentStep,
+		TotalSteps:     totalSteps,
+		Timer:          condition.Timer,
+		Framing:        condition.Framing,
+	}
+	// Mirrored repeats are stored as the original and flipped for display
+	if stored.Mirrored {
+		newMirror(tridentSpawn.Orientation, templateWidth, templateHeight).apply(res)
+	}
 	return res, nil
 }
 
+// narrativeFor hides the narrative from arms that run without one; it is still stored.
+// Instruction checks always show it, since it carries the instruction.
+func narrativeFor(condition experiment.Condition, check domain.AttentionCheckKind, narrative string) string {
+	if !condition.Narrative && check != domain.CheckInstruction {
+		return ""
+	}
+	return narrative
+}
+
+// participantFactors leaves out what marks a catch trial or a repeat
+func participantFactors(factors domain.ScenarioFactors) domain.ScenarioFactors {
+	factors.AttentionCheck = ""
+	factors.Repeat = nil
+	return factors
+}
+
+// repeatScenario copies the stored scenario of the trial a repeat points back to, without
+// calling the LLM
+func (s *service) repeatScenario(ctx context.Context, sessionID uuid.UUID, factors domain.ScenarioFactors, condition experiment.Condition, currentStep, totalSteps int) (*GetNextResponse, error) {
+	original, err := s.repo.GetByStep(ctx, sessionID, factors.Repeat.Of)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the scenario to repeat: %w", err)
+	}
+	factorsJSON, err := json.Marshal(factors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal factors: %w", err)
+	}
+
+	now := time.Now()
+	clone := &Scenario{
+		SessionID:                sessionID,
+		Step:                     currentStep,
+		ContextTemplateID:        original.ContextTemplateID,
+		ContextTemplateVersionID: original.ContextTemplateVersionID,
+		Entities:                 original.Entities,
+		Factors:                  factorsJSON,
+		DilemmaOptions:           original.DilemmaOptions,
+		Narrative:                original.Narrative,
+		TridentSpawn:             original.TridentSpawn,
+		RegionContext:            original.RegionContext,
+		StartedAt:                &now,
+		RepeatOfID:               &original.Id,
+		Mirrored:                 factors.Repeat.Mirror,
+	}
+	if err := util.Retry(ctx, 3, 50*time.Millisecond, func() error {
+		return s.repo.Create(ctx, clone)
+	}); err != nil {
+		return nil, err
+	}
+	return s.replay(ctx, clone, condition, currentStep, totalSteps)
+}
+
 func (s *service) GetScenarioByID(ctx context.Context, id uuid.UUID) (*Scenario, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+// fallbackVehicle stands in for a large vehicle whose footprint does not fit the road
+const fallbackVehicle = "vehicle_car"
+
+// placeEntity fills in the tiles an entity covers. Multi-tile vehicles may be moved along
+// their lane to fit; one that cannot fit is swapped for a car on the tile it was given.
+func (s *service) placeEntity(layoutID uuid.UUID, side domain.DrivingSide, entity *EnrichedEntity) {
+	placement, err := s.templateService.PlaceEntity(layoutID, side, entity.Type, entity.Row, entity.Col, domain.Direction(entity.Metadata.Orientation))
+	if err != nil {
+		log.Printf("Placing %s as %s: %v", entity.Type, fallbackVehicle, err)
+		entity.Type = fallbackVehicle
+		entity.Cells = []domain.Coordinate{{Row: entity.Row, Col: entity.Col}}
+		return
+	}
+	entity.Row, entity.Col = placement.Row, placement.Col
+	entity.Metadata.Orientation = string(placement.Orientation)
+	entity.Cells = placement.Cells
+}
+
+// markCollisions lists, for each path, the entities with a tile the path sweeps. The ego
+// is where every path starts, so it never counts.
+func markCollisions(trajectories domain.Trajectories, entities []EnrichedEntity) domain.Trajectories {
+	occupants := make(map[domain.Coordinate][]string)
+	for _, entity := range entities {
+		if entity.Metadata.IsEgo {
+			continue
+		}
+		for _, cell := range entity.Cells {
+			occupants[cell] = append(occupants[cell], entity.ID)
+		}
+	}
+
+	mark := func(path domain.Trajectory) domain.Trajectory {
+		path.Collisions = nil
+		hit := make(map[string]bool)
+		for _, tile := range path.Tiles {
+			for _, id := range occupants[tile.Coordinate] {
+				if !hit[id] {
+					hit[id] = true
+					path.Collisions = append(path.Collisions, id)
+				}
+			}
+		}
+		return path
+	}
+
+	trajectories.Maintain = mark(trajectories.Maintain)
+	trajectories.SwerveLeft = mark(trajectories.SwerveLeft)
+	trajectories.SwerveRight = mark(trajectories.SwerveRight)
+	return trajectories
+}
+
+// markCrossings records the pedestrian signal under every person or animal standing on a
+// signalised crosswalk
+func markCrossings(entities []EnrichedEntity, signals []domain.SignalHead) {
+	walk := make(map[domain.Coordinate]domain.SignalPhase)
+	for _, head := range signals {
+		if head.Kind == domain.SignalKindPedestrian {
+			walk[head.Coordinate] = head.State
+		}
+	}
+	if len(walk) == 0 {
+		return
+	}
+
+	for i := range entities {
+		if domain.EntityUsage(entities[i].Type) != domain.UsagePedestrian {
+			continue
+		}
+		for _, cell := range entities[i].Cells {
+			if state, ok := walk[cell]; ok {
+				entities[i].Signal = state
+				break
+			}
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package session
 
+import (
+	"time"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/google/uuid"
+)
+
 type CreateSessionInput struct {
 	AgeRange          int    `json:"age_range" validate:"required,min=1,max=6"`
 	Gender            int    `json:"gender" validate:"required,min=1,max=4"`
@@ -8,7 +15,56 @@ type CreateSessionInput struct {
 	DrivingExperience int    `json:"driving_experience" validate:"min=1,max=3"`
 	Fingerprint       string `json:"fingerprint" validate:"required"`
 	SelfReportedNew   bool   `json:"self_reported_new"`
+	Experiment        string `json:"experiment"` // study slug, the default experiment when empty
+}
+
+// DesignPreviewInput picks the experiment to preview; set fields override its design
+type DesignPreviewInput struct {
+	Experiment string
+	Strategy   string
+	Trials     int
+	Factors    []string
 }
 
+// SessionFeedback is the participant's feedback: the LLM's reading of their choices and how
+// consistently they answered repeated trials
 type SessionFeedback struct {
+	domain.FeedbackLLMResponse
+	Consistency *domain.ConsistencyReport `json:"consistency,omitempty"`
+}
+
+// SessionExport is one participant of the researcher export
+type SessionExport struct {
+	SessionID         uuid.UUID                 `json:"session_id"`
+	Arm               string                    `json:"arm,omitempty"`
+	Status            SessionStatus             `json:"status"`
+	CreatedAt         time.Time                 `json:"created_at"`
+	IsDuplicate       bool                      `json:"is_duplicate"`
+	AgeRange          int                       `json:"age_range"`
+	Gender            int                       `json:"gender"`
+	Country           string                    `json:"country"`
+	Occupation        string                    `json:"occupation"`
+	DrivingExperience int                       `json:"driving_experience"`
+	AttentionChecks   int                       `json:"attention_checks"`
+	AttentionPassed   int                       `json:"attention_passed"`
+	Consistency       *domain.ConsistencyReport `json:"consistency,omitempty"`
+	Trials            []TrialExport             `json:"trials"`
+}
+
+// TrialExport is one scenario a participant was shown, with their response if any
+type TrialExport struct {
+	Step           int                    `json:"step"`
+	ScenarioID     uuid.UUID              `json:"scenario_id"`
+	Factors        domain.ScenarioFactors `json:"factors"`
+	AttentionCheck string                 `json:"attention_check,omitempty"`
+	RepeatOf       *uuid.UUID             `json:"repeat_of,omitempty"`
+	Mirrored       bool                   `json:"mirrored,omitempty"`
+	Answered       bool                   `json:"answered"`
+	// Ranking as given; mirrored repeats are also mapped back to the original's options
+	RankingOrder    []string `json:"ranking_order,omitempty"`
+	UnmirroredOrder []string `json:"unmirrored_order,omitempty"`
+	ResponseTimeMs  int64    `json:"response_time_ms,omitempty"`
+	IsTimeout       bool     `json:"is_timeout,omitempty"`
+	HasInteracted   bool     `json:"has_interacted,omitempty"`
+	AttentionPassed *bool    `json:"attention_passed,omitempty"`
 }
@@ -1,8 +1,12 @@
 package session
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/direwen/go-server/internal/experiment"
 	"github.com/direwen/go-server/internal/util"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -31,6 +35,12 @@ func (h *Handler) Create(c echo.Context) error {
 	}
 
 	token, err := h.service.RegisterSession(c.Request().Context(), input)
+	if errors.Is(err, experiment.ErrExperimentNotFound) {
+		return util.ErrorResponse(c, http.StatusNotFound, "Study not found", err)
+	}
+	if errors.Is(err, experiment.ErrExperimentClosed) {
+		return util.ErrorResponse(c, http.StatusForbidden, "Study is closed", err)
+	}
 	if err != nil {
 		return util.ErrorResponse(c, http.StatusInternalServerError, "Failed to create session", err)
 	}
@@ -56,3 +66,46 @@ func (h *Handler) GetSessionFeedback(c echo.Context) error {
 	return util.SuccessResponse(c, http.StatusOK, "Session feedback retrieved successfully", response)
 
 }
+
+// PreviewDesign generates a plan with its balance report for an experiment (?experiment=, the
+// default one when absent); the other query params override its design
+func (h *Handler) PreviewDesign(c echo.Context) error {
+	input := DesignPreviewInput{
+		Experiment: c.QueryParam("experiment"),
+		Strategy:   c.QueryParam("strategy"),
+	}
+	if val := c.QueryParam("trials"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 1 {
+			return util.ErrorResponse(c, http.StatusBadRequest, "Invalid trial count", err)
+		}
+		input.Trials = parsed
+	}
+	if val := c.QueryParam("factors"); val != "" {
+		input.Factors = strings.Split(val, ",")
+	}
+
+	plan, err := h.service.PreviewDesign(c.Request().Context(), input)
+	if errors.Is(err, experiment.ErrExperimentNotFound) {
+		return util.ErrorResponse(c, http.StatusNotFound, "Experiment not found", err)
+	}
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusBadRequest, "Failed to generate design", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Design generated", plan)
+}
+
+// Export lists every session of an experiment (?experiment=, the default one when absent)
+// with its trials and responses
+func (h *Handler) Export(c echo.Context) error {
+	exports, err := h.service.ExportSessions(c.Request().Context(), c.QueryParam("experiment"))
+	if errors.Is(err, experiment.ErrExperimentNotFound) {
+		return util.ErrorResponse(c, http.StatusNotFound, "Experiment not found", err)
+	}
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusInternalServerError, "Failed to export sessions", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Sessions exported successfully", exports)
+}
@@ -14,6 +14,7 @@ type Repository interface {
 	GetByID(ctx context.Context, id uuid.UUID, opts ...database.QueryOption) (*Session, error)
 	Update(ctx context.Context, session *Session) error
 	CountSessions(ctx context.Context, opts ...database.QueryOption) (int64, error)
+	GetAll(ctx context.Context, opts ...database.QueryOption) ([]Session, error)
 }
 
 type repository struct {
@@ -63,3 +64,11 @@ func (r *repository) CountSessions(ctx context.Context, opts ...database.QueryOp
 
 	return count, err
 }
+
+func (r *repository) GetAll(ctx context.Context, opts ...database.QueryOption) ([]Session, error) {
+	var sessions []Session
+	db := database.GetDB(ctx, r.db).WithContext(ctx).Model(&Session{})
+	db = database.ApplyOptions(db, opts...)
+	err := db.Order("created_at").Find(&sessions).Error
+	return sessions, err
+}
@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"slices"
 	"time"
 
+	"github.com/direwen/go-server/internal/condition"
+	"github.com/direwen/go-server/internal/experiment"
+	"github.com/direwen/go-server/internal/quota"
 	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/shared/models"
 	"github.com/direwen/go-server/internal/util"
 	"github.com/direwen/go-server/pkg/database"
 	"github.com/google/uuid"
@@ -19,20 +24,28 @@ type Service interface {
 	ValidateSession(ctx context.Context, session Session) error
 	GetSession(ctx context.Context, sessionID uuid.UUID) (*Session, error)
 	CompleteSession(ctx context.Context, session Session) error
-	GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (*domain.FeedbackLLMResponse, error)
+	GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (*SessionFeedback, error)
+	ExportSessions(ctx context.Context, slug string) ([]SessionExport, error)
+	PreviewDesign(ctx context.Context, input DesignPreviewInput) (*domain.DesignPlan, error)
+	GetExperiment(session Session) (*experiment.Settings, error)
+	GetCondition(session Session) (*experiment.Condition, error)
 }
 
 type service struct {
-	repo    Repository
-	llmPool domain.LLMPool
-	experimentTargetCount int
+	repo        Repository
+	llmPool     domain.LLMPool
+	experiments experiment.Service
+	conditions  condition.Service
+	quotas      quota.Service
 }
 
-func NewService(repo Repository, llmPool domain.LLMPool, experimentTargetCount int) Service {
+func NewService(repo Repository, llmPool domain.LLMPool, experiments experiment.Service, conditions condition.Service, quotas quota.Service) Service {
 	return &service{
-		repo:    repo,
-		llmPool: llmPool,
-		experimentTargetCount: experimentTargetCount,
+		repo:        repo,
+		llmPool:     llmPool,
+		experiments: experiments,
+		conditions:  conditions,
+		quotas:      quotas,
 	}
 }
 
@@ -47,8 +60,33 @@ func (s *service) RegisterSession(ctx context.Context, input CreateSessionInput)
 		return "", err
 	}
 
-	experimentPlan := domain.GenerateBalancedDesign(s.experimentTargetCount)
-	planInJSON, err := json.Marshal(experimentPlan)
+	study, err := s.experiments.GetBySlug(input.Experiment)
+	if err != nil {
+		return "", err
+	}
+	if !study.Active {
+		return "", experiment.ErrExperimentClosed
+	}
+
+	arm, err := s.conditions.Assign(ctx, *study)
+	if err != nil {
+		return "", err
+	}
+
+	quotaCounts, err := s.quotas.GetCounts(ctx, study.ID)
+	if err != nil {
+		return "", err
+	}
+
+	experimentPlan, err := domain.GenerateBalancedDesign(study.Design, quotaCounts)
+	if err != nil {
+		return "", err
+	}
+	planInJSON, err := json.Marshal(experimentPlan.Trials)
+	if err != nil {
+		return "", err
+	}
+	reportInJSON, err := json.Marshal(experimentPlan.Report)
 	if err != nil {
 		return "", err
 	}
@@ -64,7 +102,10 @@ func (s *service) RegisterSession(ctx context.Context, input CreateSessionInput)
 		IsDuplicate:       exists,
 		Status:            StatusActive,
 		ExpiresAt:         time.Now().Add(session_expiration_duration),
+		ExperimentID:      &study.ID,
+		Arm:               arm,
 		ExperimentPlan:    datatypes.JSON(planInJSON),
+		DesignReport:      datatypes.JSON(reportInJSON),
 	}
 
 	if err := s.repo.Create(ctx, &sess); err != nil {
@@ -82,6 +123,50 @@ func (s *service) RegisterSession(ctx context.Context, input CreateSessionInput)
 	return signedToken, nil
 }
 
+// PreviewDesign generates a plan for an experiment without creating a session, to check a
+// design before a study runs. Set fields of the input override the experiment's design.
+func (s *service) PreviewDesign(ctx context.Context, input DesignPreviewInput) (*domain.DesignPlan, error) {
+	study, err := s.experiments.GetBySlug(input.Experiment)
+	if err != nil {
+		return nil, err
+	}
+
+	config := study.Design
+	if input.Strategy != "" {
+		config.Strategy = domain.DesignStrategyName(input.Strategy)
+	}
+	if input.Trials > 0 {
+		config.Trials = input.Trials
+	}
+	if len(input.Factors) > 0 {
+		config.Factors = input.Factors
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	quotaCounts, err := s.quotas.GetCounts(ctx, study.ID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.GenerateBalancedDesign(config, quotaCounts)
+}
+
+// GetExperiment returns the experiment a session registered for
+func (s *service) GetExperiment(session Session) (*experiment.Settings, error) {
+	return s.experiments.GetByID(session.ExperimentID)
+}
+
+// GetCondition returns what the session's arm is shown: its timer, narrative and framing
+func (s *service) GetCondition(session Session) (*experiment.Condition, error) {
+	study, err := s.GetExperiment(session)
+	if err != nil {
+		return nil, err
+	}
+	condition := study.Condition(session.Arm)
+	return &condition, nil
+}
+
 func (s *service) ValidateSession(ctx context.Context, session Session) error {
 
 	// Status Validation
@@ -111,11 +196,21 @@ func (s *service) GetSession(ctx context.Context, sessionID uuid.UUID) (*Session
 }
 
 func (s *service) CompleteSession(ctx context.Context, session Session) error {
+	study, err := s.GetExperiment(session)
+	if err != nil {
+		return err
+	}
+
 	session.Status = StatusCompleted
-	return s.repo.Update(ctx, &session)
+	if err := s.repo.Update(ctx, &session); err != nil {
+		return err
+	}
+
+	// Count what this participant saw toward the experiment's quotas
+	return s.quotas.RecordSession(ctx, study.ID, session.Id)
 }
 
-func (s *service) GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (*domain.FeedbackLLMResponse, error) {
+func (s *service) GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (*SessionFeedback, error) {
 	// Get session with all scenarios and responses preloaded
 	session, err := s.repo.GetByID(ctx, sessionID, database.WithPreload("Scenarios.Response"))
 	if err != nil {
@@ -133,7 +228,7 @@ func (s *service) GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (
 		if err := json.Unmarshal(session.Feedback, &feedback); err != nil {
 			return nil, errors.New("failed to parse cached feedback")
 		}
-		return &feedback, nil
+		return &SessionFeedback{FeedbackLLMResponse: feedback, Consistency: consistencyOf(session.Scenarios)}, nil
 	}
 
 	// Build demographic data
@@ -148,7 +243,9 @@ func (s *service) GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (
 	// Build enriched responses from preloaded data
 	responses := []domain.EnrichedResponse{}
 	for _, scenario := range session.Scenarios {
-		if scenario.Response == nil {
+		// Attention checks say nothing about the participant's choices, and repeats are
+		// measured for consistency instead
+		if scenario.Response == nil || scenario.AttentionCheck != "" || scenario.RepeatOfID != nil {
 			continue
 		}
 
@@ -195,5 +292,110 @@ func (s *service) GetSessionFeedback(ctx context.Context, sessionID uuid.UUID) (
 		return nil, err
 	}
 
-	return feedback, nil
+	return &SessionFeedback{FeedbackLLMResponse: *feedback, Consistency: consistencyOf(session.Scenarios)}, nil
+}
+
+// ExportSessions lists every session of an experiment, the default one when slug is empty,
+// with its trials in the order shown, responses, attention result and consistency
+func (s *service) ExportSessions(ctx context.Context, slug string) ([]SessionExport, error) {
+	study, err := s.experiments.GetBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := s.experiments.GetBySlug(experiment.DefaultSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sessions from before experiments belong to the default one
+	sessions, err := s.repo.GetAll(ctx,
+		database.WithFilter("COALESCE(experiment_id, ?) = ?", fallback.ID, study.ID),
+		database.WithPreload("Scenarios.Response"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exports := make([]SessionExport, 0, len(sessions))
+	for _, session := range sessions {
+		export := SessionExport{
+			SessionID:         session.Id,
+			Arm:               session.Arm,
+			Status:            session.Status,
+			CreatedAt:         session.CreatedAt,
+			IsDuplicate:       session.IsDuplicate,
+			AgeRange:          session.AgeRange,
+			Gender:            session.Gender,
+			Country:           session.Country,
+			Occupation:        session.Occupation,
+			DrivingExperience: session.DrivingExperience,
+			AttentionChecks:   session.AttentionChecks,
+			AttentionPassed:   session.AttentionPassed,
+			Consistency:       consistencyOf(session.Scenarios),
+			Trials:            make([]TrialExport, 0, len(session.Scenarios)),
+		}
+
+		scenarios := slices.Clone(session.Scenarios)
+		slices.SortFunc(scenarios, func(a, b models.Scenario) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+		for i, scenario := range scenarios {
+			trial := TrialExport{
+				Step:           i + 1,
+				ScenarioID:     scenario.Id,
+				AttentionCheck: scenario.AttentionCheck,
+				RepeatOf:       scenario.RepeatOfID,
+				Mirrored:       scenario.Mirrored,
+			}
+			if err := json.Unmarshal(scenario.Factors, &trial.Factors); err != nil {
+				return nil, err
+			}
+			if response := scenario.Response; response != nil {
+				trial.Answered = true
+				trial.ResponseTimeMs = response.ResponseTimeMs
+				trial.IsTimeout = response.IsTimeout
+				trial.HasInteracted = response.HasInteracted
+				trial.AttentionPassed = response.AttentionPassed
+				if err := json.Unmarshal(response.RankingOrder, &trial.RankingOrder); err != nil {
+					return nil, err
+				}
+				if scenario.Mirrored {
+					trial.UnmirroredOrder = domain.UnmirrorRanking(trial.RankingOrder)
+				}
+			}
+			export.Trials = append(export.Trials, trial)
+		}
+		exports = append(exports, export)
+	}
+	return exports, nil
+}
+
+// consistencyOf pairs every answered repeat with its answered original. A timeout or an
+// untouched ranking says nothing about a choice, so those pairs are left out.
+func consistencyOf(scenarios []models.Scenario) *domain.ConsistencyReport {
+	byID := make(map[uuid.UUID]models.Scenario, len(scenarios))
+	for _, scenario := range scenarios {
+		byID[scenario.Id] = scenario
+	}
+
+	var pairs []domain.RankingPair
+	for _, repeat := range scenarios {
+		if repeat.RepeatOfID == nil {
+			continue
+		}
+		original, ok := byID[*repeat.RepeatOfID]
+		if !ok || !answered(original.Response) || !answered(repeat.Response) {
+			continue
+		}
+		var first, second []string
+		if json.Unmarshal(original.Response.RankingOrder, &first) != nil || json.Unmarshal(repeat.Response.RankingOrder, &second) != nil {
+			continue
+		}
+		pairs = append(pairs, domain.RankingPair{Original: first, Repeat: second, Mirrored: repeat.Mirrored})
+	}
+	return domain.MeasureConsistency(pairs)
+}
+
+func answered(response *models.Response) bool {
+	return response != nil && !response.IsTimeout && response.HasInteracted
 }
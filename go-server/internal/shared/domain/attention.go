@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// AttentionCheckKind is how a catch trial tells careless participants from real ones
+type AttentionCheckKind string
+
+const (
+	CheckClearPath   AttentionCheckKind = "clear_path"  // one swerve leads past an empty side zone; ranking it first passes
+	CheckInstruction AttentionCheckKind = "instruction" // the narrative names the option to rank first
+)
+
+var AttentionCheckKinds = []AttentionCheckKind{CheckClearPath, CheckInstruction}
+
+// AttentionCheck places a catch trial in a plan. Catch trials are left out of the design,
+// quotas, completion counts and the dashboard, and only scored for attention.
+type AttentionCheck struct {
+	Position int                `json:"position"`       // 1-based trial number, counting the checks
+	Kind     AttentionCheckKind `json:"kind,omitempty"` // clear_path when empty
+}
+
+// attentionTrial draws the levels of a catch trial within the rules. A clear-path check
+// prefers a lone violating star, no tailgater and no signal so the empty side is plainly harmless.
+func (s *ruleSolver) attentionTrial(kind AttentionCheckKind) (ScenarioFactors, error) {
+	preferred := map[string]string{}
+	if kind == CheckClearPath {
+		preferred[FactorPrimaryBehavior] = string(BehaviorViolation)
+		preferred[FactorHasTailgater] = strconv.FormatBool(false)
+		preferred[FactorSignalPhase] = string(SignalPhaseNone)
+		preferred[FactorGroupSize] = "1"
+	}
+	prefer := func(factor DesignFactor, _ map[string]string) []string {
+		first, ok := preferred[factor.Name]
+		if !ok || !slices.Contains(factor.Levels, first) {
+			first = drawLevel(factor)
+		}
+		return preferLevel(factor.Levels, first)
+	}
+
+	assign := make(map[string]string, len(s.factors))
+	if !s.solve(assign, s.factors, prefer) {
+		return ScenarioFactors{}, fmt.Errorf("constraints cannot all be satisfied: %s", joinRules(s.rules))
+	}
+	factors := ScenarioFactors{AttentionCheck: kind}
+	for name, level := range assign {
+		factors.SetLevel(name, level)
+	}
+	// Instruction checks are written by the LLM like any trial; clear-path checks are staged bare
+	if kind == CheckInstruction {
+		factors.BackgroundEntities = castBackground()
+	}
+	return factors, nil
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "slices"
+
+// Option keys a participant ranks, as stored in the ranking order
+const (
+	OptionMaintain    = "maintain"
+	OptionSwerveLeft  = "swerve_left"
+	OptionSwerveRight = "swerve_right"
+)
+
+// RankingPair is how a participant ranked a trial and, later, its repeat
+type RankingPair struct {
+	Original []string
+	Repeat   []string
+	Mirrored bool // the repeat was shown mirrored, so its swerves are swapped
+}
+
+// ConsistencyReport is how alike a participant ranked trials and their repeats
+type ConsistencyReport struct {
+	Pairs          int     `json:"pairs"`            // repeats answered together with their original
+	TopChoiceMatch float64 `json:"top_choice_match"` // share of pairs with the same first choice, 0-1
+	RankAgreement  float64 `json:"rank_agreement"`   // mean share of option pairs ranked in the same order, 0-1
+}
+
+// UnmirrorRanking maps a ranking given on a mirrored scene back to the original's options
+func UnmirrorRanking(ranking []string) []string {
+	unmirrored := make([]string, len(ranking))
+	for i, option := range ranking {
+		switch option {
+		case OptionSwerveLeft:
+			option = OptionSwerveRight
+		case OptionSwerveRight:
+			option = OptionSwerveLeft
+		}
+		unmirrored[i] = option
+	}
+	return unmirrored
+}
+
+// MeasureConsistency compares the rankings of each pair, nil when there are none
+func MeasureConsistency(pairs []RankingPair) *ConsistencyReport {
+	if len(pairs) == 0 {
+		return nil
+	}
+	report := &ConsistencyReport{Pairs: len(pairs)}
+	for _, pair := range pairs {
+		repeat := pair.Repeat
+		if pair.Mirrored {
+			repeat = UnmirrorRanking(repeat)
+		}
+		if len(pair.Original) > 0 && len(repeat) > 0 && pair.Original[0] == repeat[0] {
+			report.TopChoiceMatch++
+		}
+		report.RankAgreement += rankAgreement(pair.Original, repeat)
+	}
+	report.TopChoiceMatch /= float64(len(pairs))
+	report.RankAgreement /= float64(len(pairs))
+	return report
+}
+
+// rankAgreement is the share of option pairs two rankings put in the same order, one minus
+// the normalised Kendall distance
+func rankAgreement(a, b []string) float64 {
+	agree, total := 0, 0
+	for i, first := range a {
+		for _, second := range a[i+1:] {
+			bi, bj := slices.Index(b, first), slices.Index(b, second)
+			if bi < 0 || bj < 0 {
+				continue
+			}
+			total++
+			if bi < bj {
+				agree++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(agree) / float64(total)
+}
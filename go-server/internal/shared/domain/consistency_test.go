@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMeasureConsistency(t *testing.T) {
+	m, l, r := OptionMaintain, OptionSwerveLeft, OptionSwerveRight
+
+	tests := []struct {
+		name  string
+		pairs []RankingPair
+		want  *ConsistencyReport
+	}{
+		{name: "no pairs", pairs: nil, want: nil},
+		{
+			name:  "identical rankings",
+			pairs: []RankingPair{{Original: []string{m, l, r}, Repeat: []string{m, l, r}}},
+			want:  &ConsistencyReport{Pairs: 1, TopChoiceMatch: 1, RankAgreement: 1},
+		},
+		{
+			name:  "reversed rankings",
+			pairs: []RankingPair{{Original: []string{m, l, r}, Repeat: []string{r, l, m}}},
+			want:  &ConsistencyReport{Pairs: 1, TopChoiceMatch: 0, RankAgreement: 0},
+		},
+		{
+			name:  "same top choice, last two swapped",
+			pairs: []RankingPair{{Original: []string{m, l, r}, Repeat: []string{m, r, l}}},
+			want:  &ConsistencyReport{Pairs: 1, TopChoiceMatch: 1, RankAgreement: 2.0 / 3},
+		},
+		{
+			name:  "mirrored repeat ranked the same scene the same way",
+			pairs: []RankingPair{{Original: []string{l, m, r}, Repeat: []string{r, m, l}, Mirrored: true}},
+			want:  &ConsistencyReport{Pairs: 1, TopChoiceMatch: 1, RankAgreement: 1},
+		},
+		{
+			name:  "mirrored repeat that kept the screen order",
+			pairs: []RankingPair{{Original: []string{l, m, r}, Repeat: []string{l, m, r}, Mirrored: true}},
+			want:  &ConsistencyReport{Pairs: 1, TopChoiceMatch: 0, RankAgreement: 0},
+		},
+		{
+			name: "averaged over pairs",
+			pairs: []RankingPair{
+				{Original: []string{m, l, r}, Repeat: []string{m, l, r}},
+				{Original: []string{m, l, r}, Repeat: []string{l, m, r}},
+			},
+			want: &ConsistencyReport{Pairs: 2, TopChoiceMatch: 0.5, RankAgreement: (1 + 2.0/3) / 2},
+		},
+		{
+			name:  "an empty ranking counts as a disagreement",
+			pairs: []RankingPair{{Original: []string{m, l, r}, Repeat: nil}},
+			want:  &ConsistencyReport{Pairs: 1, TopChoiceMatch: 0, RankAgreement: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MeasureConsistency(tt.pairs)
+			if got == nil || tt.want == nil {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("report = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+			if got.Pairs != tt.want.Pairs ||
+				math.Abs(got.TopChoiceMatch-tt.want.TopChoiceMatch) > 1e-9 ||
+				math.Abs(got.RankAgreement-tt.want.RankAgreement) > 1e-9 {
+				t.Errorf("report = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmirrorRanking(t *testing.T) {
+	ranking := []string{OptionSwerveLeft, OptionMaintain, OptionSwerveRight}
+	want := []string{OptionSwerveRight, OptionMaintain, OptionSwerveLeft}
+	if got := UnmirrorRanking(ranking); !reflect.DeepEqual(got, want) {
+		t.Errorf("unmirrored = %v, want %v", got, want)
+	}
+	if got := UnmirrorRanking(UnmirrorRanking(ranking)); !reflect.DeepEqual(got, ranking) {
+		t.Errorf("unmirroring twice = %v, want %v", got, ranking)
+	}
+}
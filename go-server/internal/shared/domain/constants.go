@@ -55,12 +55,52 @@ const (
 	BehaviorCompliant Behavior = "Compliant"
 )
 
+// Signal phases. The factor is what the AV's signal shows, None when it has no signal;
+// pedestrian heads show Walk or DontWalk.
+type SignalPhase string
+
+const (
+	SignalPhaseNone     SignalPhase = "None"
+	SignalPhaseGreen    SignalPhase = "Green"
+	SignalPhaseAmber    SignalPhase = "Amber"
+	SignalPhaseRed      SignalPhase = "Red"
+	SignalPhaseWalk     SignalPhase = "Walk"
+	SignalPhaseDontWalk SignalPhase = "DontWalk"
+)
+
+// Region kinds: metadata layers a template lays over its tiles
+type RegionKind string
+
+const (
+	RegionSpeedLimit         RegionKind = "speed_limit"
+	RegionSchoolZone         RegionKind = "school_zone"
+	RegionPedestrianPriority RegionKind = "pedestrian_priority"
+	RegionConstruction       RegionKind = "construction"
+)
+
+// Framing is the point of view a scenario is told from, set by the participant's experiment arm
+type Framing string
+
+const (
+	FramingObserver  Framing = "observer"  // a bystander deciding for the AV (the default)
+	FramingPassenger Framing = "passenger" // the participant rides in the AV
+)
+
+// Signal head kinds
+const (
+	SignalKindVehicle    = "vehicle"
+	SignalKindPedestrian = "pedestrian"
+)
+
 var (
 	Visibilities   = []Visibility{VisibilityClear, VisibilityFog, VisibilityNight, VisibilityRain}
 	RoadConditions = []RoadCondition{RoadConditionDry, RoadConditionWet, RoadConditionIcy}
 	Locations      = []Location{LocationUS, LocationUK, LocationCN, LocationFR}
 	BrakeStatuses  = []BrakeStatus{BrakeStatusActive, BrakeStatusFailed, BrakeStatusFade}
 	Speeds         = []Speed{SpeedLow, SpeedMedium, SpeedHigh}
+	SignalPhases   = []SignalPhase{SignalPhaseNone, SignalPhaseGreen, SignalPhaseAmber, SignalPhaseRed}
+	RegionKinds    = []RegionKind{RegionSpeedLimit, RegionSchoolZone, RegionPedestrianPriority, RegionConstruction}
+	Framings       = []Framing{FramingObserver, FramingPassenger}
 )
 
 // Direction constants for lane config
@@ -73,5 +113,45 @@ const (
 	DirectionWest  Direction = "W"
 )
 
+// Axis is the line of travel, "NS" or "EW"
+func (d Direction) Axis() string {
+	if d == DirectionNorth || d == DirectionSouth {
+		return "NS"
+	}
+	return "EW"
+}
+
+// Driving side (traffic rule used to assign lane directions)
+type DrivingSide string
+
+const (
+	DrivingSideRight DrivingSide = "right" // keep right, e.g. US, FR, CN
+	DrivingSideLeft  DrivingSide = "left"  // keep left, e.g. UK
+)
+
+// LocationDrivingSides maps each location to its traffic rule, unlisted locations keep right
+var LocationDrivingSides = map[Location]DrivingSide{
+	LocationUS: DrivingSideRight,
+	LocationUK: DrivingSideLeft,
+	LocationCN: DrivingSideRight,
+	LocationFR: DrivingSideRight,
+}
+
+// DrivingSideFor returns the driving side for a location code
+func DrivingSideFor(location string) DrivingSide {
+	if side, ok := LocationDrivingSides[Location(location)]; ok {
+		return side
+	}
+	return DrivingSideRight
+}
+
+// Opposite returns the other driving side
+func (d DrivingSide) Opposite() DrivingSide {
+	if d == DrivingSideLeft {
+		return DrivingSideRight
+	}
+	return DrivingSideLeft
+}
+
 // LaneConfigMap represents the parsed lane configuration
 type LaneConfigMap map[Direction][][2]int
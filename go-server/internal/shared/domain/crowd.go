@@ -0,0 +1,26 @@
+package domain
+
+// CrowdPerTile is the most members of a group that stack on one tile; larger groups spread
+// over neighbouring tiles of the star's zone
+const CrowdPerTile = 2
+
+// Group returns how many the star stands for, 1 for trials planned before group sizes
+func (f ScenarioFactors) Group() int {
+	if f.GroupSize < 1 {
+		return 1
+	}
+	return f.GroupSize
+}
+
+// CrowdTiles is the fewest tiles a group of size fits on
+func CrowdTiles(size int) int {
+	return (size + CrowdPerTile - 1) / CrowdPerTile
+}
+
+// Members returns how many an entity stands for, 1 unless it is a crowd
+func (m EntityMeta) Members() int {
+	if m.Count < 1 {
+		return 1
+	}
+	return m.Count
+}
@@ -0,0 +1,367 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// DesignStrategyName selects how GenerateBalancedDesign lays out a participant's trials
+type DesignStrategyName string
+
+const (
+	DesignBalanced        DesignStrategyName = "balanced"         // each factor cycles through its levels
+	DesignFullFactorial   DesignStrategyName = "full_factorial"   // every level combination, sampled without repeats
+	DesignLatinSquare     DesignStrategyName = "latin_square"     // rows, columns and symbols of a Latin square
+	DesignOrthogonalArray DesignStrategyName = "orthogonal_array" // strength-2 array, each pair of factors crossed evenly
+	DesignDOptimal        DesignStrategyName = "d_optimal"        // coordinate exchange on the main-effects model
+)
+
+// Design factor names, the JSON keys of ScenarioFactors
+const (
+	FactorVisibility      = "visibility"
+	FactorRoadCondition   = "road_condition"
+	FactorLocation        = "location"
+	FactorBrakeStatus     = "brake_status"
+	FactorSpeed           = "speed"
+	FactorHasTailgater    = "has_tailgater"
+	FactorPrimaryEntity   = "primary_entity"
+	FactorPrimaryBehavior = "primary_behavior"
+	FactorSignalPhase     = "signal_phase"
+	FactorGroupSize       = "group_size"
+)
+
+// DesignFactor is a scenario factor a design strategy can control
+type DesignFactor struct {
+	Name   string   `json:"name"`
+	Levels []string `json:"levels"`
+}
+
+// DesignFactors lists every factor a strategy can control, in report order
+var DesignFactors = []DesignFactor{
+	{Name: FactorVisibility, Levels: levelsOf(Visibilities)},
+	{Name: FactorRoadCondition, Levels: levelsOf(RoadConditions)},
+	{Name: FactorLocation, Levels: levelsOf(Locations)},
+	{Name: FactorBrakeStatus, Levels: levelsOf(BrakeStatuses)},
+	{Name: FactorSpeed, Levels: levelsOf(Speeds)},
+	{Name: FactorHasTailgater, Levels: []string{"false", "true"}},
+	{Name: FactorPrimaryEntity, Levels: StarPool},
+	{Name: FactorPrimaryBehavior, Levels: []string{string(BehaviorViolation), string(BehaviorCompliant)}},
+	{Name: FactorSignalPhase, Levels: levelsOf(SignalPhases)},
+	{Name: FactorGroupSize, Levels: []string{"1", "2", "4"}},
+}
+
+// DefaultDesignFactors are the factors balanced when a study does not pick its own
+var DefaultDesignFactors = []string{FactorVisibility, FactorBrakeStatus, FactorPrimaryBehavior}
+
+func levelsOf[T ~string](values []T) []string {
+	levels := make([]string, len(values))
+	for i, value := range values {
+		levels[i] = string(value)
+	}
+	return levels
+}
+
+// DesignFactorByName looks up a design factor
+func DesignFactorByName(name string) (DesignFactor, bool) {
+	for _, factor := range DesignFactors {
+		if factor.Name == name {
+			return factor, true
+		}
+	}
+	return DesignFactor{}, false
+}
+
+// Level returns the level a trial has for a design factor
+func (f ScenarioFactors) Level(name string) string {
+	switch name {
+	case FactorVisibility:
+		return f.Visibility
+	case FactorRoadCondition:
+		return f.RoadCondition
+	case FactorLocation:
+		return f.Location
+	case FactorBrakeStatus:
+		return f.BrakeStatus
+	case FactorSpeed:
+		return f.Speed
+	case FactorHasTailgater:
+		return strconv.FormatBool(f.HasTailgater)
+	case FactorPrimaryEntity:
+		return f.PrimaryEntity
+	case FactorPrimaryBehavior:
+		return f.PrimaryBehavior
+	case FactorSignalPhase:
+		if f.SignalPhase == "" {
+			return string(SignalPhaseNone)
+		}
+		return f.SignalPhase
+	case FactorGroupSize:
+		return strconv.Itoa(f.Group())
+	}
+	return ""
+}
+
+// SetLevel sets the level of a design factor
+func (f *ScenarioFactors) SetLevel(name, level string) {
+	switch name {
+	case FactorVisibility:
+		f.Visibility = level
+	case FactorRoadCondition:
+		f.RoadCondition = level
+	case FactorLocation:
+		f.Location = level
+	case FactorBrakeStatus:
+		f.BrakeStatus = level
+	case FactorSpeed:
+		f.Speed = level
+	case FactorHasTailgater:
+		f.HasTailgater = level == "true"
+	case FactorPrimaryEntity:
+		f.PrimaryEntity = level
+	case FactorPrimaryBehavior:
+		f.PrimaryBehavior = level
+	case FactorSignalPhase:
+		f.SignalPhase = level
+	case FactorGroupSize:
+		f.GroupSize, _ = strconv.Atoi(level)
+	}
+}
+
+// DefaultConstraints apply when a study does not list its own: an icy road is never driven at low speed
+var DefaultConstraints = []FactorRule{
+	MustParseFactorRule("road_condition=Icy forbids speed=Low"),
+}
+
+// DesignConfig is how a study wants its trials laid out
+type DesignConfig struct {
+	Strategy    DesignStrategyName  `json:"strategy"`
+	Trials      int                 `json:"trials"`
+	Factors     []string            `json:"factors"`          // controlled by the strategy, the rest are randomised
+	Levels      map[string][]string `json:"levels,omitempty"` // narrows a factor to these levels, all of them when absent
+	Constraints []FactorRule        `json:"constraints"`      // DefaultConstraints when null, see FactorRule
+	// Catch trials inserted on top of the design trials
+	AttentionChecks []AttentionCheck `json:"attention_checks,omitempty"`
+	// Test-retest repeats of earlier trials
+	Repeats []TrialRepeat `json:"repeats,omitempty"`
+}
+
+// NewDesignConfig fills in the defaults and checks the strategy and factor names
+func NewDesignConfig(strategy string, trials int, factors []string) (DesignConfig, error) {
+	config := DesignConfig{Strategy: DesignStrategyName(strategy), Trials: trials, Factors: factors}
+	config = config.WithDefaults()
+	if err := config.Validate(); err != nil {
+		return DesignConfig{}, err
+	}
+	return config, nil
+}
+
+// WithDefaults fills in the strategy, controlled factors and constraints a config leaves out
+func (c DesignConfig) WithDefaults() DesignConfig {
+	if c.Strategy == "" {
+		c.Strategy = DesignBalanced
+	}
+	if len(c.Factors) == 0 {
+		c.Factors = DefaultDesignFactors
+	}
+	if c.Constraints == nil {
+		c.Constraints = DefaultConstraints
+	}
+	checks := make([]AttentionCheck, len(c.AttentionChecks))
+	for i, check := range c.AttentionChecks {
+		if check.Kind == "" {
+			check.Kind = CheckClearPath
+		}
+		checks[i] = check
+	}
+	if len(checks) > 0 {
+		c.AttentionChecks = checks
+	}
+	return c
+}
+
+func (c DesignConfig) Validate() error {
+	if _, ok := designStrategies[c.Strategy]; !ok {
+		return fmt.Errorf("unknown design strategy %q", c.Strategy)
+	}
+	if c.Trials < 1 {
+		return errors.New("design needs at least one trial")
+	}
+	seen := make(map[string]bool)
+	for _, name := range c.Factors {
+		if _, ok := DesignFactorByName(name); !ok {
+			return fmt.Errorf("unknown design factor %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("design factor %q listed twice", name)
+		}
+		seen[name] = true
+	}
+	for name, levels := range c.Levels {
+		factor, ok := DesignFactorByName(name)
+		if !ok {
+			return fmt.Errorf("levels given for unknown factor %q", name)
+		}
+		if len(levels) == 0 {
+			return fmt.Errorf("factor %q needs at least one level", name)
+		}
+		for _, level := range levels {
+			if levelIndex(factor, level) < 0 {
+				return fmt.Errorf("factor %q has no level %q", name, level)
+			}
+		}
+	}
+	if err := c.validateInserts(); err != nil {
+		return err
+	}
+	return c.validateRules()
+}
+
+// validateRules checks that some trial satisfies every rule and that the rules leave every
+// level of the controlled factors reachable, naming the rules that conflict when they do not
+func (c DesignConfig) validateRules() error {
+	solver := newRuleSolver(c)
+	if !solver.completable(nil) {
+		return fmt.Errorf("constraints cannot all be satisfied; these conflict: %s", joinRules(solver.conflict(nil)))
+	}
+	for _, name := range c.Factors {
+		for _, level := range c.Factor(name).Levels {
+			levels := map[string]string{name: level}
+			if !solver.completable(levels) {
+				return fmt.Errorf("constraints rule out %s=%s, which the design balances: %s", name, level, joinRules(solver.conflict(levels)))
+			}
+		}
+	}
+	return nil
+}
+
+// UnplaceableLevels lists the levels of the controlled factors, written factor=level, that
+// no trial can take in any of the areas the template pool offers for each driving side. Rules
+// on area attributes are only settled when a spawn is picked, so a level they rule out
+// everywhere the pool reaches would fail the session that draws it.
+func (c DesignConfig) UnplaceableLevels(areas map[DrivingSide][]RegionContext) []string {
+	if !slices.ContainsFunc(c.Constraints, FactorRule.testsArea) {
+		return nil
+	}
+	var levels []string
+	for _, name := range c.Factors {
+		for _, level := range c.Factor(name).Levels {
+			if !c.placeable(name, level, areas) {
+				levels = append(levels, name+"="+level)
+			}
+		}
+	}
+	return levels
+}
+
+// placeable reports whether some trial with the level breaks no rule in one of the areas,
+// keeping its location to those that drive on the side of the area's variant
+func (c DesignConfig) placeable(name, level string, areas map[DrivingSide][]RegionContext) bool {
+	for side, regions := range areas {
+		factors := c.AllFactors()
+		for i, factor := range factors {
+			if factor.Name == FactorLocation {
+				factors[i].Levels = slices.DeleteFunc(slices.Clone(factor.Levels), func(location string) bool {
+					return DrivingSideFor(location) != side
+				})
+			}
+		}
+		if name == FactorLocation && DrivingSideFor(level) != side {
+			continue
+		}
+		// The cache keys on design factors only, so each area gets a solver of its own
+		for _, region := range regions {
+			solver := &ruleSolver{factors: factors, rules: c.Constraints, feasible: make(map[string]bool)}
+			assign := map[string]string{
+				name:                   level,
+				AreaSchoolZone:         strconv.FormatBool(region.SchoolZone),
+				AreaPedestrianPriority: strconv.FormatBool(region.PedestrianPriority),
+				AreaConstruction:       strconv.FormatBool(region.Construction),
+			}
+			if solver.solve(assign, factors, naturalOrder) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Factor returns a design factor with the levels this study uses, in their canonical order
+func (c DesignConfig) Factor(name string) DesignFactor {
+	factor, _ := DesignFactorByName(name)
+	allowed, ok := c.Levels[name]
+	if !ok {
+		return factor
+	}
+	narrowed := DesignFactor{Name: name}
+	for _, level := range factor.Levels {
+		if slices.Contains(allowed, level) {
+			narrowed.Levels = append(narrowed.Levels, level)
+		}
+	}
+	return narrowed
+}
+
+// AllFactors returns every design factor with the levels this study uses
+func (c DesignConfig) AllFactors() []DesignFactor {
+	factors := make([]DesignFactor, len(DesignFactors))
+	for i, factor := range DesignFactors {
+		factors[i] = c.Factor(factor.Name)
+	}
+	return factors
+}
+
+// DesignSpace is the set of factors a strategy lays out
+type DesignSpace struct {
+	Factors []DesignFactor
+	solver  *ruleSolver
+}
+
+func newDesignSpace(config DesignConfig) DesignSpace {
+	space := DesignSpace{solver: newRuleSolver(config)}
+	for _, name := range config.Factors {
+		space.Factors = append(space.Factors, config.Factor(name))
+	}
+	return space
+}
+
+// Feasible reports whether a row of level indices can be completed into a trial that breaks
+// none of the constraints, whichever factors they involve
+func (s DesignSpace) Feasible(row []int) bool {
+	if s.solver == nil || len(s.solver.rules) == 0 {
+		return true
+	}
+	levels := make(map[string]string, len(s.Factors))
+	for f, factor := range s.Factors {
+		levels[factor.Name] = factor.Levels[row[f]]
+	}
+	return s.solver.completable(levels)
+}
+
+// MaxLevels is the largest number of levels of any factor in the space
+func (s DesignSpace) MaxLevels() int {
+	most := 0
+	for _, factor := range s.Factors {
+		most = max(most, len(factor.Levels))
+	}
+	return most
+}
+
+// DesignStrategy lays out trials over a design space: one row per trial, one level index per factor
+type DesignStrategy interface {
+	Design(space DesignSpace, trials int) [][]int
+}
+
+var designStrategies = map[DesignStrategyName]DesignStrategy{
+	DesignBalanced:        balancedStrategy{},
+	DesignFullFactorial:   fullFactorialStrategy{},
+	DesignLatinSquare:     squareStrategy{columns: 3},
+	DesignOrthogonalArray: squareStrategy{},
+	DesignDOptimal:        dOptimalStrategy{starts: 3, passes: 10},
+}
+
+// RegisterDesignStrategy adds or replaces a strategy; call it before sessions are created
+func RegisterDesignStrategy(name DesignStrategyName, strategy DesignStrategy) {
+	designStrategies[name] = strategy
+}
@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"math"
+	"slices"
+)
+
+// BalanceReport describes how well a plan supports estimating main effects within a participant
+type BalanceReport struct {
+	Strategy    DesignStrategyName `json:"strategy"`
+	Trials      int                `json:"trials"`
+	Parameters  int                `json:"parameters"`   // intercept plus one per extra level of each controlled factor
+	Estimable   bool               `json:"estimable"`    // every controlled main effect can be estimated together
+	DEfficiency float64            `json:"d_efficiency"` // 0-100 for the main-effects model, 0 when not estimable
+	Factors     []FactorBalance    `json:"factors"`
+	Rules       RuleReport         `json:"rules"`
+	// Catch trials inserted into the plan on top of Trials
+	AttentionChecks int `json:"attention_checks,omitempty"`
+	// Repeats of earlier trials inserted into the plan on top of Trials
+	Repeats int `json:"repeats,omitempty"`
+}
+
+// RuleReport is how the constraints bear on a plan
+type RuleReport struct {
+	Constraints []string `json:"constraints"`
+	Unreachable []string `json:"unreachable,omitempty"` // factor=level pairs no trial can take
+	Repairs     int      `json:"repairs"`               // trials whose planned levels were changed to satisfy the constraints
+}
+
+// FactorBalance is how often each level of a factor appears in a plan
+type FactorBalance struct {
+	Factor     string         `json:"factor"`
+	Controlled bool           `json:"controlled"`
+	Counts     map[string]int `json:"counts"`
+	Balanced   bool           `json:"balanced"`  // level counts differ by at most one
+	Estimable  bool           `json:"estimable"` // controlled and not aliased with the other controlled factors
+}
+
+// NewBalanceReport measures a plan against the factors the design controlled
+func NewBalanceReport(config DesignConfig, trials []ScenarioFactors) BalanceReport {
+	space := newDesignSpace(config)
+	rows := make([][]int, len(trials))
+	for i, trial := range trials {
+		rows[i] = make([]int, len(space.Factors))
+		for f, factor := range space.Factors {
+			rows[i][f] = max(levelIndex(factor, trial.Level(factor.Name)), 0)
+		}
+	}
+
+	x := modelMatrix(space, rows)
+	parameters := 1
+	for _, factor := range space.Factors {
+		parameters += len(factor.Levels) - 1
+	}
+	fullRank := matrixRank(x)
+
+	report := BalanceReport{
+		Strategy:   config.Strategy,
+		Trials:     len(trials),
+		Parameters: parameters,
+		Estimable:  fullRank == parameters,
+	}
+	if report.Estimable {
+		efficiency := 100 * math.Exp(logDet(informationMatrix(x))/float64(parameters)) / float64(len(trials))
+		report.DEfficiency = math.Round(efficiency*10) / 10
+	}
+
+	controlled := make(map[string]int)
+	offset := 1
+	for _, factor := range space.Factors {
+		controlled[factor.Name] = offset
+		offset += len(factor.Levels) - 1
+	}
+
+	for _, factor := range config.AllFactors() {
+		balance := FactorBalance{Factor: factor.Name, Counts: make(map[string]int)}
+		for _, level := range factor.Levels {
+			balance.Counts[level] = 0
+		}
+		for _, trial := range trials {
+			balance.Counts[trial.Level(factor.Name)]++
+		}
+		low, high := len(trials), 0
+		for _, count := range balance.Counts {
+			low, high = min(low, count), max(high, count)
+		}
+		balance.Balanced = high-low <= 1
+
+		if start, ok := controlled[factor.Name]; ok {
+			balance.Controlled = true
+			width := len(factor.Levels) - 1
+			balance.Estimable = fullRank-matrixRank(dropColumns(x, start, width)) == width
+		}
+		report.Factors = append(report.Factors, balance)
+	}
+
+	report.Rules.Constraints = make([]string, len(config.Constraints))
+	for i, rule := range config.Constraints {
+		report.Rules.Constraints[i] = rule.String()
+	}
+	report.Rules.Unreachable = space.solver.unreachable()
+	return report
+}
+
+// levelIndex is the position of a level in a factor, -1 when the factor has no such level
+func levelIndex(factor DesignFactor, level string) int {
+	return slices.Index(factor.Levels, level)
+}
+
+// modelMatrix is the main-effects model: an intercept, then k-1 normalised Helmert contrasts
+// for a factor with k levels. The coding is orthonormal over the levels, so a balanced
+// orthogonal design has X'X = N*I and a D-efficiency of 100.
+func modelMatrix(space DesignSpace, rows [][]int) [][]float64 {
+	x := make([][]float64, len(rows))
+	for i, row := range rows {
+		x[i] = []float64{1}
+		for f, factor := range space.Factors {
+			k := len(factor.Levels)
+			for j := 1; j < k; j++ {
+				x[i] = append(x[i], helmert(row[f], j, k))
+			}
+		}
+	}
+	return x
+}
+
+// helmert is contrast j of k at a level, scaled so its squares sum to k over the levels
+func helmert(level, j, k int) float64 {
+	scale := math.Sqrt(float64(k) / float64(j*(j+1)))
+	switch {
+	case level < j:
+		return -scale
+	case level == j:
+		return float64(j) * scale
+	}
+	return 0
+}
+
+func dropColumns(x [][]float64, start, width int) [][]float64 {
+	out := make([][]float64, len(x))
+	for i, row := range x {
+		out[i] = append(append([]float64(nil), row[:start]...), row[start+width:]...)
+	}
+	return out
+}
+
+// informationMatrix is X'X
+func informationMatrix(x [][]float64) [][]float64 {
+	if len(x) == 0 {
+		return nil
+	}
+	p := len(x[0])
+	info := make([][]float64, p)
+	for i := range info {
+		info[i] = make([]float64, p)
+	}
+	for _, row := range x {
+		for i := 0; i < p; i++ {
+			for j := 0; j < p; j++ {
+				info[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	return info
+}
+
+// logDet is the log determinant of a square matrix, -Inf when it is singular
+func logDet(m [][]float64) float64 {
+	a := copyMatrix(m)
+	total := 0.0
+	for col := range a {
+		pivot := col
+		for row := col + 1; row < len(a); row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return math.Inf(-1)
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		total += math.Log(math.Abs(a[col][col]))
+		for row := col + 1; row < len(a); row++ {
+			factor := a[row][col] / a[col][col]
+			for k := col; k < len(a); k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+	return total
+}
+
+// matrixRank counts the independent columns of a matrix
+func matrixRank(m [][]float64) int {
+	if len(m) == 0 {
+		return 0
+	}
+	a := copyMatrix(m)
+	rank := 0
+	for col := 0; col < len(a[0]) && rank < len(a); col++ {
+		pivot := rank
+		for row := rank + 1; row < len(a); row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-9 {
+			continue
+		}
+		a[rank], a[pivot] = a[pivot], a[rank]
+		for row := rank + 1; row < len(a); row++ {
+			factor := a[row][col] / a[rank][col]
+			for k := col; k < len(a[row]); k++ {
+				a[row][k] -= factor * a[rank][k]
+			}
+		}
+		rank++
+	}
+	return rank
+}
+
+func copyMatrix(m [][]float64) [][]float64 {
+	out := make([][]float64, len(m))
+	for i, row := range m {
+		out[i] = append([]float64(nil), row...)
+	}
+	return out
+}
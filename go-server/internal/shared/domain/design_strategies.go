@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"math"
+	"math/rand"
+)
+
+// balancedStrategy cycles every factor through its levels, trial i getting level i mod k.
+// Factors with the same number of levels move in lockstep, so only use it for factors whose
+// effects are not estimated against each other.
+type balancedStrategy struct{}
+
+func (balancedStrategy) Design(space DesignSpace, trials int) [][]int {
+	rows := make([][]int, trials)
+	for i := range rows {
+		rows[i] = make([]int, len(space.Factors))
+		for f, factor := range space.Factors {
+			rows[i][f] = i % len(factor.Levels)
+		}
+	}
+	return rows
+}
+
+// fullFactorialStrategy deals the feasible level combinations in random order, starting a
+// fresh replicate once every combination has been used
+type fullFactorialStrategy struct{}
+
+func (fullFactorialStrategy) Design(space DesignSpace, trials int) [][]int {
+	var cells [][]int
+	row := make([]int, len(space.Factors))
+	for {
+		if space.Feasible(row) {
+			cells = append(cells, append([]int(nil), row...))
+		}
+		// Mixed-radix increment over the factor levels
+		f := len(row) - 1
+		for ; f >= 0; f-- {
+			row[f]++
+			if row[f] < len(space.Factors[f].Levels) {
+				break
+			}
+			row[f] = 0
+		}
+		if f < 0 {
+			break
+		}
+	}
+	return dealRuns(cells, trials)
+}
+
+// squareStrategy builds an n x n square and reads each factor off one of its columns: the row,
+// the column, then the symbols (r + m*c) mod n for m = 1, 2, ... A Latin square uses three of
+// them. n is the least common multiple of the factors' levels, so the n symbols of a column
+// split evenly over each factor's levels. Two symbol columns are orthogonal when their m
+// differ by a unit mod n, which holds for every m below the smallest prime factor p of n,
+// giving a strength-2 orthogonal array of p + 1 factors; factors beyond the columns read off
+// are randomised.
+type squareStrategy struct {
+	columns int // factors read off the square, 0 for as many as stay orthogonal
+}
+
+func (s squareStrategy) Design(space DesignSpace, trials int) [][]int {
+	if space.MaxLevels() == 0 {
+		return make([][]int, trials)
+	}
+	columns, n := s.square(space)
+
+	symbols := make([][]int, columns)
+	for f := range symbols {
+		symbols[f] = rand.Perm(n)
+	}
+
+	var runs [][]int
+	for r := 0; r < n; r++ {
+		for c := 0; c < n; c++ {
+			row := make([]int, len(space.Factors))
+			for f, factor := range space.Factors {
+				levels := len(factor.Levels)
+				if f >= columns {
+					row[f] = rand.Intn(levels)
+					continue
+				}
+				// Each level takes a block of n/levels symbols
+				row[f] = symbols[f][squareColumn(r, c, f, n)] * levels / n
+			}
+			if space.Feasible(row) {
+				runs = append(runs, row)
+			}
+		}
+	}
+	return dealRuns(runs, trials)
+}
+
+// square picks how many factors to read off the square and its size: the most leading
+// factors whose square has enough orthogonal columns for them
+func (s squareStrategy) square(space DesignSpace) (columns, n int) {
+	columns = len(space.Factors)
+	if s.columns > 0 {
+		columns = min(columns, s.columns)
+	}
+	for ; columns > 1; columns-- {
+		n = 1
+		for _, factor := range space.Factors[:columns] {
+			n = lcm(n, len(factor.Levels))
+		}
+		if smallestPrimeFactor(n)+1 >= columns {
+			return columns, n
+		}
+	}
+	return columns, len(space.Factors[0].Levels)
+}
+
+func squareColumn(r, c, j, n int) int {
+	switch j {
+	case 0:
+		return r
+	case 1:
+		return c
+	}
+	return (r + (j-1)*c) % n
+}
+
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
+
+func smallestPrimeFactor(n int) int {
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return d
+		}
+	}
+	return n
+}
+
+// dealRuns shuffles the runs and takes trials of them, reshuffling for each further replicate
+func dealRuns(runs [][]int, trials int) [][]int {
+	rows := make([][]int, 0, trials)
+	if len(runs) == 0 {
+		return rows
+	}
+	for len(rows) < trials {
+		rand.Shuffle(len(runs), func(i, j int) {
+			runs[i], runs[j] = runs[j], runs[i]
+		})
+		for _, run := range runs {
+			if len(rows) == trials {
+				break
+			}
+			rows = append(rows, append([]int(nil), run...))
+		}
+	}
+	return rows
+}
+
+// dOptimalStrategy runs coordinate exchange from random starts: each level of each trial is
+// swapped for whichever level most increases det(X'X) of the main-effects model. A small
+// ridge keeps the search moving when there are fewer trials than parameters.
+type dOptimalStrategy struct {
+	starts int
+	passes int
+}
+
+const dOptimalRidge = 1e-3
+
+func (s dOptimalStrategy) Design(space DesignSpace, trials int) [][]int {
+	var best [][]int
+	bestScore := math.Inf(-1)
+	for start := 0; start < s.starts; start++ {
+		rows := randomFeasibleRows(space, trials)
+		score := s.improve(space, rows)
+		if score > bestScore {
+			best, bestScore = rows, score
+		}
+	}
+	return best
+}
+
+func (s dOptimalStrategy) improve(space DesignSpace, rows [][]int) float64 {
+	score := designScore(space, rows)
+	for pass := 0; pass < s.passes; pass++ {
+		improved := false
+		for _, row := range rows {
+			for f, factor := range space.Factors {
+				current := row[f]
+				bestLevel := current
+				for level := range factor.Levels {
+					if level == current {
+						continue
+					}
+					row[f] = level
+					if !space.Feasible(row) {
+						continue
+					}
+					if candidate := designScore(space, rows); candidate > score+1e-9 {
+						score, bestLevel = candidate, level
+						improved = true
+					}
+				}
+				row[f] = bestLevel
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return score
+}
+
+func designScore(space DesignSpace, rows [][]int) float64 {
+	info := informationMatrix(modelMatrix(space, rows))
+	for i := range info {
+		info[i][i] += dOptimalRidge
+	}
+	return logDet(info)
+}
+
+func randomFeasibleRows(space DesignSpace, trials int) [][]int {
+	rows := make([][]int, trials)
+	for i := range rows {
+		row := make([]int, len(space.Factors))
+		for attempt := 0; attempt < 100; attempt++ {
+			for f, factor := range space.Factors {
+				row[f] = rand.Intn(len(factor.Levels))
+			}
+			if space.Feasible(row) {
+				break
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
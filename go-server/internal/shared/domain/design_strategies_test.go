@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testSpace is an unconstrained design space with factors of the given level counts
+func testSpace(levels ...int) DesignSpace {
+	space := DesignSpace{}
+	for f, count := range levels {
+		factor := DesignFactor{Name: fmt.Sprintf("f%d", f)}
+		for level := 0; level < count; level++ {
+			factor.Levels = append(factor.Levels, fmt.Sprintf("l%d", level))
+		}
+		space.Factors = append(space.Factors, factor)
+	}
+	return space
+}
+
+// levelCounts counts how often each level of a factor appears
+func levelCounts(rows [][]int, f, levels int) []int {
+	counts := make([]int, levels)
+	for _, row := range rows {
+		counts[row[f]]++
+	}
+	return counts
+}
+
+// pairCounts counts how often each combination of levels of two factors appears
+func pairCounts(rows [][]int, f, g int) map[[2]int]int {
+	counts := make(map[[2]int]int)
+	for _, row := range rows {
+		counts[[2]int{row[f], row[g]}]++
+	}
+	return counts
+}
+
+// checkRows fails unless every row has a level in range for each factor
+func checkRows(t *testing.T, space DesignSpace, rows [][]int, trials int) {
+	t.Helper()
+	if len(rows) != trials {
+		t.Fatalf("%d rows, want %d", len(rows), trials)
+	}
+	for i, row := range rows {
+		if len(row) != len(space.Factors) {
+			t.Fatalf("row %d has %d factors, want %d", i, len(row), len(space.Factors))
+		}
+		for f, level := range row {
+			if level < 0 || level >= len(space.Factors[f].Levels) {
+				t.Fatalf("row %d: factor %d has level %d of %d", i, f, level, len(space.Factors[f].Levels))
+			}
+		}
+	}
+}
+
+// checkBalanced fails unless each of the factors shows every level equally often
+func checkBalanced(t *testing.T, space DesignSpace, rows [][]int, factors int) {
+	t.Helper()
+	for f := 0; f < factors; f++ {
+		levels := len(space.Factors[f].Levels)
+		for level, count := range levelCounts(rows, f, levels) {
+			if count != len(rows)/levels {
+				t.Errorf("factor %d level %d appears %d times, want %d", f, level, count, len(rows)/levels)
+			}
+		}
+	}
+}
+
+// checkOrthogonal fails unless each pair of the factors shows every combination equally often
+func checkOrthogonal(t *testing.T, space DesignSpace, rows [][]int, factors int) {
+	t.Helper()
+	for f := 0; f < factors; f++ {
+		for g := f + 1; g < factors; g++ {
+			cells := len(space.Factors[f].Levels) * len(space.Factors[g].Levels)
+			counts := pairCounts(rows, f, g)
+			if len(counts) != cells {
+				t.Errorf("factors %d and %d cross in %d of %d combinations", f, g, len(counts), cells)
+			}
+			for pair, count := range counts {
+				if count != len(rows)/cells {
+					t.Errorf("factors %d and %d: %v appears %d times, want %d", f, g, pair, count, len(rows)/cells)
+				}
+			}
+		}
+	}
+}
+
+func TestDesignStrategies(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   DesignStrategy
+		levels     []int
+		trials     int
+		balanced   int // leading factors that show every level equally often
+		orthogonal int // leading factors whose pairs cross evenly
+		reached    int // leading factors that take every level at least once
+	}{
+		{name: "balanced", strategy: balancedStrategy{}, levels: []int{4, 3, 2}, trials: 12, balanced: 3},
+		{name: "full factorial", strategy: fullFactorialStrategy{}, levels: []int{4, 3, 2}, trials: 24, balanced: 3, orthogonal: 3},
+		{name: "full factorial replicated", strategy: fullFactorialStrategy{}, levels: []int{2, 3}, trials: 12, balanced: 2, orthogonal: 2},
+		{name: "latin square, mixed levels", strategy: squareStrategy{columns: 3}, levels: []int{4, 3, 2}, trials: 144, balanced: 3, orthogonal: 3},
+		{name: "latin square, fourth factor randomised", strategy: squareStrategy{columns: 3}, levels: []int{3, 3, 3, 2}, trials: 9, balanced: 3, orthogonal: 3},
+		{name: "orthogonal array, prime levels", strategy: squareStrategy{}, levels: []int{3, 3, 3, 3}, trials: 9, balanced: 4, orthogonal: 4},
+		{name: "orthogonal array, mixed levels", strategy: squareStrategy{}, levels: []int{4, 2, 2}, trials: 16, balanced: 3, orthogonal: 3},
+		{name: "orthogonal array, more factors than columns", strategy: squareStrategy{}, levels: []int{2, 2, 2, 2}, trials: 4, balanced: 3, orthogonal: 3},
+		{name: "d-optimal", strategy: dOptimalStrategy{starts: 3, passes: 10}, levels: []int{2, 2, 2}, trials: 8, reached: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space := testSpace(tt.levels...)
+			rows := tt.strategy.Design(space, tt.trials)
+			checkRows(t, space, rows, tt.trials)
+			checkBalanced(t, space, rows, tt.balanced)
+			checkOrthogonal(t, space, rows, tt.orthogonal)
+			for f := 0; f < tt.reached; f++ {
+				for level, count := range levelCounts(rows, f, tt.levels[f]) {
+					if count == 0 {
+						t.Errorf("factor %d never takes level %d", f, level)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSquareSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    squareStrategy
+		levels      []int
+		wantColumns int
+		wantN       int
+	}{
+		{name: "latin square keeps three factors", strategy: squareStrategy{columns: 3}, levels: []int{3, 3, 3, 3}, wantColumns: 3, wantN: 3},
+		{name: "latin square over mixed levels", strategy: squareStrategy{columns: 3}, levels: []int{4, 3, 2}, wantColumns: 3, wantN: 12},
+		{name: "prime levels give p + 1 columns", strategy: squareStrategy{}, levels: []int{5, 5, 5, 5, 5, 5, 5}, wantColumns: 6, wantN: 5},
+		{name: "an even square stops at three columns", strategy: squareStrategy{}, levels: []int{4, 2, 2, 2}, wantColumns: 3, wantN: 4},
+		{name: "dropping a factor can shrink the square", strategy: squareStrategy{}, levels: []int{3, 3, 3, 3, 2}, wantColumns: 4, wantN: 3},
+		{name: "single-level factors", strategy: squareStrategy{}, levels: []int{1, 1}, wantColumns: 2, wantN: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, n := tt.strategy.square(testSpace(tt.levels...))
+			if columns != tt.wantColumns || n != tt.wantN {
+				t.Errorf("square = %d columns of %d, want %d of %d", columns, n, tt.wantColumns, tt.wantN)
+			}
+		})
+	}
+}
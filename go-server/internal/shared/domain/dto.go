@@ -11,6 +11,17 @@ type ScenarioFactors struct {
 	PrimaryEntity      string   `json:"primary_entity"`
 	PrimaryBehavior    string   `json:"primary_behavior"`
 	BackgroundEntities []string `json:"background_entities"`
+	SignalPhase        string   `json:"signal_phase,omitempty"` // AV's signal, empty or None when unsignalised
+	GroupSize          int      `json:"group_size,omitempty"`   // people or animals the star stands for, 1 when empty
+	// Set on catch trials, which the design and analysis leave out
+	AttentionCheck AttentionCheckKind `json:"attention_check,omitempty"`
+	// Set on repeats of an earlier trial
+	Repeat *TrialRepeat `json:"repeat,omitempty"`
+}
+
+// Signalised reports whether the scenario puts the AV in front of a traffic signal
+func (f ScenarioFactors) Signalised() bool {
+	return f.SignalPhase != "" && f.SignalPhase != string(SignalPhaseNone)
 }
 
 type Coordinate struct {
@@ -40,6 +51,30 @@ type TridentZones struct {
 	ZoneC TridentZone `json:"zone_c"`
 }
 
+// TrajectoryPoint is a position in tile units; whole numbers are tile centres
+type TrajectoryPoint struct {
+	Row float64 `json:"row"`
+	Col float64 `json:"col"`
+}
+
+// Trajectory is the path the AV drives for one dilemma option
+type Trajectory struct {
+	Points         []TrajectoryPoint    `json:"points"`               // polyline starting at the spawn
+	Tiles          []EnrichedCoordinate `json:"tiles"`                // every tile the path sweeps, in order
+	Feasible       bool                 `json:"feasible"`             // false if the path is blocked or out of reach
+	Reason         string               `json:"reason,omitempty"`     // why it is not feasible
+	LeavesRoad     bool                 `json:"leaves_road"`          // sweeps tiles off the carriageway
+	EntersOncoming bool                 `json:"enters_oncoming"`      // sweeps a lane running against the AV
+	Collisions     []string             `json:"collisions,omitempty"` // IDs of entities with a tile on the path, in path order
+}
+
+// Trajectories holds one path per dilemma option (zone A, B and C)
+type Trajectories struct {
+	Maintain    Trajectory `json:"maintain"`
+	SwerveLeft  Trajectory `json:"swerve_left"`
+	SwerveRight Trajectory `json:"swerve_right"`
+}
+
 type Demographic struct {
 	AgeRange          int    `json:"age_range"`
 	Gender            int    `json:"gender"`
@@ -56,3 +91,39 @@ type EnrichedResponse struct {
 	ResponseTimeMs int64           `json:"response_time_ms"`
 	IsTimeout      bool            `json:"is_timeout"`
 }
+
+// SignalHead is a signal attached to a tile of a signalised junction. Vehicle heads sit on
+// the last lane tile before the junction and control traffic heading Direction. Pedestrian
+// heads sit on crosswalk tiles; Axis is the traffic the crossing cuts across.
+type SignalHead struct {
+	Junction string `json:"junction"`
+	Kind     string `json:"kind"` // vehicle or pedestrian
+	Coordinate
+	Direction Direction   `json:"direction,omitempty"`
+	Axis      string      `json:"axis"`
+	State     SignalPhase `json:"state,omitempty"`
+}
+
+// Region is a rectangular metadata layer on a template, Top/Left to Bottom/Right inclusive
+type Region struct {
+	Kind       RegionKind `json:"kind"`
+	Top        int        `json:"top"`
+	Left       int        `json:"left"`
+	Bottom     int        `json:"bottom"`
+	Right      int        `json:"right"`
+	SpeedLimit int        `json:"speed_limit,omitempty"` // km/h, speed_limit regions only
+}
+
+// Contains reports whether a tile lies in the region
+func (r Region) Contains(row, col int) bool {
+	return row >= r.Top && row <= r.Bottom && col >= r.Left && col <= r.Right
+}
+
+// RegionContext is what the regions say about the tiles a scenario plays out on: the AV's
+// spawn and its trident zones. It is stored with the scenario as an analysis covariate.
+type RegionContext struct {
+	SpeedLimit         int  `json:"speed_limit,omitempty"` // km/h posted at the AV, 0 when none
+	SchoolZone         bool `json:"school_zone"`
+	PedestrianPriority bool `json:"pedestrian_priority"`
+	Construction       bool `json:"construction"`
+}
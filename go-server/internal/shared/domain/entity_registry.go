@@ -1,10 +1,47 @@
 package domain
 
 type Entity struct {
-	TypeID   string   `json:"type_id"`
-	BaseName string   `json:"base_name"`
-	Emoji    string   `json:"emoji"`
-	Tags     []string `json:"tags"`
+	TypeID    string    `json:"type_id"`
+	BaseName  string    `json:"base_name"`
+	Emoji     string    `json:"emoji"`
+	Tags      []string  `json:"tags"`
+	Footprint Footprint `json:"footprint"`
+}
+
+// Footprint is the tiles an entity covers: Length along its heading, Width across it.
+// Zero values mean one tile.
+type Footprint struct {
+	Length int `json:"length"`
+	Width  int `json:"width"`
+}
+
+// HasTag reports whether the entity carries a tag
+func (e Entity) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// EntityUsage returns the usage an entity needs from a surface (see SurfaceRules.Usage):
+// vehicle, cyclist, or pedestrian for people and animals. Empty for anything that fits
+// anywhere (obstacles).
+func EntityUsage(typeID string) string {
+	entity, ok := EntityRegistry[typeID]
+	switch {
+	case !ok:
+		return ""
+	case entity.HasTag("vehicle"):
+		return UsageVehicle
+	case entity.HasTag("cyclist"):
+		return UsageCyclist
+	case entity.HasTag("pedestrian"), entity.HasTag("animal"):
+		return UsagePedestrian
+	default:
+		return ""
+	}
 }
 
 var EntityRegistry = map[string]Entity{
@@ -57,11 +94,13 @@ var EntityRegistry = map[string]Entity{
 	},
 	"vehicle_bus": {
 		TypeID: "vehicle_bus", BaseName: "Bus", Emoji: "🚌",
-		Tags: []string{"background", "vehicle", "large"},
+		Tags:      []string{"background", "vehicle", "large"},
+		Footprint: Footprint{Length: 3, Width: 1},
 	},
 	"vehicle_truck": {
 		TypeID: "vehicle_truck", BaseName: "Delivery Truck", Emoji: "🚚",
-		Tags: []string{"background", "vehicle", "large"},
+		Tags:      []string{"background", "vehicle", "large"},
+		Footprint: Footprint{Length: 2, Width: 1},
 	},
 	"vehicle_motorcycle": {
 		TypeID: "vehicle_motorcycle", BaseName: "Motorcycle", Emoji: "🏍️",
@@ -0,0 +1,41 @@
+package domain
+
+// FootprintOf returns the footprint of an entity type, at least one tile each way
+func FootprintOf(typeID string) Footprint {
+	footprint := EntityRegistry[typeID].Footprint
+	if footprint.Length < 1 {
+		footprint.Length = 1
+	}
+	if footprint.Width < 1 {
+		footprint.Width = 1
+	}
+	return footprint
+}
+
+// IsMultiTile reports whether an entity type covers more than one tile
+func IsMultiTile(typeID string) bool {
+	footprint := FootprintOf(typeID)
+	return footprint.Length > 1 || footprint.Width > 1
+}
+
+// FootprintCells lists the tiles an entity covers when its front tile is at row, col and it
+// faces orientation. The body trails behind the front and extends to the entity's right.
+// Without a known heading only the front tile is returned.
+func FootprintCells(typeID string, row, col int, orientation Direction) []Coordinate {
+	fRow, fCol, _, _, rRow, rCol := CalculateTridentZones(TridentSpawn{Orientation: orientation})
+	if fRow == 0 && fCol == 0 {
+		return []Coordinate{{Row: row, Col: col}}
+	}
+
+	footprint := FootprintOf(typeID)
+	cells := make([]Coordinate, 0, footprint.Length*footprint.Width)
+	for back := 0; back < footprint.Length; back++ {
+		for side := 0; side < footprint.Width; side++ {
+			cells = append(cells, Coordinate{
+				Row: row - back*fRow + side*rRow,
+				Col: col - back*fCol + side*rCol,
+			})
+		}
+	}
+	return cells
+}
@@ -1,59 +1,95 @@
 package domain
 
 import (
+	"fmt"
 	"math/rand"
 	"os"
 	"strconv"
 )
 
-func GenerateBalancedDesign(count int) []ScenarioFactors {
-
-	var deck []ScenarioFactors
+// DesignPlan is a participant's trials together with how well they balance the design factors
+type DesignPlan struct {
+	Trials []ScenarioFactors `json:"trials"`
+	Report BalanceReport     `json:"report"`
+}
 
-	for i := 0; i < count; i++ {
+// GenerateBalancedDesign lays out the controlled factors with the configured strategy and
+// randomises the rest, then shuffles the trial order and inserts the attention checks and
+// repeats. Quotas bias both toward the levels and combinations completed participants have
+// seen least.
+func GenerateBalancedDesign(config DesignConfig, quotas QuotaCounts) (*DesignPlan, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
-		// CRITICAL FACTORS (requires the balance)
-		vis := Visibilities[i%len(Visibilities)]
-		brake := BrakeStatuses[i%len(BrakeStatuses)]
+	space := quotas.OrderLevels(newDesignSpace(config))
+	rows := designStrategies[config.Strategy].Design(space, config.Trials)
+	if len(rows) != config.Trials {
+		return nil, fmt.Errorf("design strategy %q produced %d of %d trials", config.Strategy, len(rows), config.Trials)
+	}
 
-		// Balance Legal Status exactly 50/50
-		var behavior Behavior
-		if i%2 == 0 {
-			behavior = BehaviorViolation
-		} else {
-			behavior = BehaviorCompliant
+	controlled := make(map[string]bool)
+	for _, name := range config.Factors {
+		controlled[name] = true
+	}
+	// The strategy's factors are settled first so the solver keeps their levels when it can
+	order := append([]DesignFactor(nil), space.Factors...)
+	for _, factor := range config.AllFactors() {
+		if !controlled[factor.Name] {
+			order = append(order, factor)
 		}
+	}
 
-		// RANDOMIZED FACTORS
-		road := RoadConditions[rand.Intn(len(RoadConditions))]
-		loc := Locations[rand.Intn(len(Locations))]
-		speed := Speeds[rand.Intn(len(Speeds))]
+	var deck []ScenarioFactors
+	repairs := 0
+
+	for _, row := range rows {
+		planned := make(map[string]string, len(space.Factors))
+		for f, factor := range space.Factors {
+			planned[factor.Name] = factor.Levels[row[f]]
+		}
 
 		// LOGIC CONSTRAINTS
-		if road == RoadConditionIcy && speed == SpeedLow {
-			speed = SpeedMedium
+		// Controlled factors prefer the strategy's level; the rest prefer a level drawn at
+		// random, or toward under-filled cells when there are quotas. The solver backtracks
+		// to the next preference whenever a rule breaks.
+		prefer := func(factor DesignFactor, assign map[string]string) []string {
+			first, ok := planned[factor.Name]
+			if !ok {
+				first = drawLevel(factor)
+				if len(quotas) > 0 {
+					var trial ScenarioFactors
+					var set []DesignFactor
+					for _, other := range order {
+						if level, ok := assign[other.Name]; ok {
+							trial.SetLevel(other.Name, level)
+							set = append(set, other)
+						}
+					}
+					first = quotas.Draw(factor, trial, set)
+				}
+			}
+			return preferLevel(factor.Levels, first)
+		}
+		assign := make(map[string]string, len(order))
+		if !space.solver.solve(assign, order, prefer) {
+			return nil, fmt.Errorf("constraints cannot all be satisfied: %s", joinRules(space.solver.rules))
+		}
+		for name, level := range planned {
+			if assign[name] != level {
+				repairs++
+				break
+			}
 		}
 
-		// CASTING
-		// STAR selection
-		primaryEntity := CastPrimaryEntity()
-		// Background Noise Selection
-		minEntities, _ := strconv.Atoi(os.Getenv("BACKGROUND_ENTITIES_MIN"))
-		maxEntities, _ := strconv.Atoi(os.Getenv("BACKGROUND_ENTITIES_MAX"))
-		backgroundEntities := CastTridentKit(minEntities, maxEntities)
-
-		factors := ScenarioFactors{
-			Visibility:         string(vis),
-			BrakeStatus:        string(brake),
-			RoadCondition:      string(road),
-			Location:           string(loc),
-			Speed:              string(speed),
-			HasTailgater:       rand.Intn(2) == 1,
-			PrimaryEntity:      primaryEntity,
-			PrimaryBehavior:    string(behavior),
-			BackgroundEntities: backgroundEntities,
+		var factors ScenarioFactors
+		for name, level := range assign {
+			factors.SetLevel(name, level)
 		}
 
+		// CASTING
+		factors.BackgroundEntities = castBackground()
+
 		deck = append(deck, factors)
 	}
 
@@ -62,7 +98,48 @@ func GenerateBalancedDesign(count int) []ScenarioFactors {
 		deck[i], deck[j] = deck[j], deck[i]
 	})
 
-	return deck
+	report := NewBalanceReport(config, deck)
+	report.Rules.Repairs = repairs
+
+	// ATTENTION CHECKS AND REPEATS (after the report, which covers the design trials only)
+	deck, err := assemblePlan(deck, config, space.solver)
+	if err != nil {
+		return nil, err
+	}
+	report.AttentionChecks = len(config.AttentionChecks)
+	report.Repeats = len(config.Repeats)
+
+	return &DesignPlan{Trials: deck, Report: report}, nil
+}
+
+// castBackground selects the background noise of a trial
+func castBackground() []string {
+	minEntities, _ := strconv.Atoi(os.Getenv("BACKGROUND_ENTITIES_MIN"))
+	maxEntities, _ := strconv.Atoi(os.Getenv("BACKGROUND_ENTITIES_MAX"))
+	return CastTridentKit(minEntities, maxEntities)
+}
+
+// preferLevel puts a level first and shuffles the others behind it
+func preferLevel(levels []string, first string) []string {
+	ordered := []string{first}
+	for _, i := range rand.Perm(len(levels)) {
+		if levels[i] != first {
+			ordered = append(ordered, levels[i])
+		}
+	}
+	return ordered
+}
+
+// drawLevel picks a level by its target share
+func drawLevel(factor DesignFactor) string {
+	pick := rand.Float64()
+	for _, level := range factor.Levels {
+		pick -= share(factor, level)
+		if pick < 0 {
+			return level
+		}
+	}
+	return factor.Levels[len(factor.Levels)-1]
 }
 
 func CalculateTridentZones(tridentSpawn TridentSpawn) (fRow, fCol, lRow, lCol, rRow, rCol int) {
@@ -49,6 +49,7 @@ type ScenarioLLMRequest struct {
 	TemplateName   string          `json:"template_name"`
 	GridDimensions string          `json:"grid_dimensions"`
 	Factors        ScenarioFactors `json:"factors"`
+	DrivingSide    DrivingSide     `json:"driving_side"`
 
 	// Ego AV (pre-determined position)
 	EgoPosition    Coordinate `json:"ego_position"`
@@ -56,6 +57,15 @@ type ScenarioLLMRequest struct {
 
 	// Trident zones (enriched with surface/orientation)
 	TridentZones TridentZones `json:"trident_zones"`
+
+	// Signal heads with their state, empty when unsignalised
+	Signals []SignalHead `json:"signals,omitempty"`
+
+	// Speed limit, school zone and other area rules where the scenario plays out
+	Regions RegionContext `json:"regions"`
+
+	// Point of view of the narrative, from the participant's experiment arm
+	Framing Framing `json:"framing"`
 }
 
 // ScenarioLLMResponse is the response from the LLM for scenario generation
@@ -80,6 +90,7 @@ type EntityMeta struct {
 	IsViolation bool   `json:"is_violation"`
 	Action      string `json:"action"`
 	Orientation string `json:"orientation"`
+	Count       int    `json:"count,omitempty"` // members of a crowd stacked on the entity's tile, 1 when empty
 }
 
 // RawEntity represents an entity returned by the LLM
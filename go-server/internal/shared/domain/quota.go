@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// QuotaCell is a factor level, or a pair of levels, whose population-wide fill is tracked.
+// Pairs join the factor names (sorted) and their levels with "&".
+type QuotaCell struct {
+	Factor string `json:"factor"`
+	Level  string `json:"level"`
+}
+
+// QuotaCellsOf lists the cells a trial fills: one per design factor and one per pair of them
+func QuotaCellsOf(trial ScenarioFactors) []QuotaCell {
+	cells := make([]QuotaCell, 0, len(DesignFactors)*(len(DesignFactors)+1)/2)
+	for i, factor := range DesignFactors {
+		cells = append(cells, QuotaCell{Factor: factor.Name, Level: trial.Level(factor.Name)})
+		for _, other := range DesignFactors[i+1:] {
+			cells = append(cells, pairCell(factor.Name, trial.Level(factor.Name), other.Name, trial.Level(other.Name)))
+		}
+	}
+	return cells
+}
+
+func pairCell(factor, level, other, otherLevel string) QuotaCell {
+	if other < factor {
+		factor, level, other, otherLevel = other, otherLevel, factor, level
+	}
+	return QuotaCell{Factor: factor + "&" + other, Level: level + "&" + otherLevel}
+}
+
+// QuotaCounts is how often each cell was shown to completed participants. The zero value
+// means nothing was recorded yet and leaves plans unbiased.
+type QuotaCounts map[QuotaCell]int64
+
+// Minimum fill used when weighting, so an empty cell is at most this many times likelier
+// than one on target
+const quotaFillFloor = 0.2
+
+// share is the proportion of trials a level should get when a factor is left to chance
+func share(factor DesignFactor, level string) float64 {
+	if factor.Name == FactorSignalPhase && len(factor.Levels) > 1 && slices.Contains(factor.Levels, string(SignalPhaseNone)) {
+		// Half the trials have no signal; the rest see green, amber or red
+		if level == string(SignalPhaseNone) {
+			return 0.5
+		}
+		return 0.5 / float64(len(factor.Levels)-1)
+	}
+	return 1 / float64(len(factor.Levels))
+}
+
+// fill is a level's count over its target share of the factor total, 1 on target. The second
+// return is false when nothing has been recorded for the factor.
+func (q QuotaCounts) fill(factor DesignFactor, level string) (float64, bool) {
+	var total int64
+	for _, candidate := range factor.Levels {
+		total += q[QuotaCell{Factor: factor.Name, Level: candidate}]
+	}
+	if total == 0 {
+		return 0, false
+	}
+	count := q[QuotaCell{Factor: factor.Name, Level: level}]
+	return float64(count) / (float64(total) * share(factor, level)), true
+}
+
+// pairFill is the fill of a level next to a level of another factor already set on the trial
+func (q QuotaCounts) pairFill(factor DesignFactor, level string, other DesignFactor, otherLevel string) (float64, bool) {
+	var total int64
+	for _, candidate := range factor.Levels {
+		total += q[pairCell(factor.Name, candidate, other.Name, otherLevel)]
+	}
+	if total == 0 {
+		return 0, false
+	}
+	count := q[pairCell(factor.Name, level, other.Name, otherLevel)]
+	return float64(count) / (float64(total) * share(factor, level)), true
+}
+
+// OrderLevels puts the least-filled levels of each factor first, so strategies that give
+// spare trials to the first levels top up the population
+func (q QuotaCounts) OrderLevels(space DesignSpace) DesignSpace {
+	if len(q) == 0 {
+		return space
+	}
+	ordered := DesignSpace{Factors: make([]DesignFactor, len(space.Factors)), solver: space.solver}
+	for f, factor := range space.Factors {
+		// Shuffle first so levels with the same fill are ordered at random
+		levels := append([]string(nil), factor.Levels...)
+		rand.Shuffle(len(levels), func(i, j int) {
+			levels[i], levels[j] = levels[j], levels[i]
+		})
+		sort.SliceStable(levels, func(i, j int) bool {
+			fi, _ := q.fill(factor, levels[i])
+			fj, _ := q.fill(factor, levels[j])
+			return fi < fj
+		})
+		ordered.Factors[f] = DesignFactor{Name: factor.Name, Levels: levels}
+	}
+	return ordered
+}
+
+// Draw picks a level for a factor left to chance. Each level is weighted by its target share
+// over its fill, averaged across the level on its own and next to every level already set.
+func (q QuotaCounts) Draw(factor DesignFactor, trial ScenarioFactors, set []DesignFactor) string {
+	weights := make([]float64, len(factor.Levels))
+	total := 0.0
+	for i, level := range factor.Levels {
+		fills := []float64{}
+		if fill, ok := q.fill(factor, level); ok {
+			fills = append(fills, fill)
+		}
+		for _, other := range set {
+			if fill, ok := q.pairFill(factor, level, other, trial.Level(other.Name)); ok {
+				fills = append(fills, fill)
+			}
+		}
+
+		weights[i] = share(factor, level)
+		if len(fills) > 0 {
+			mean := 0.0
+			for _, fill := range fills {
+				mean += fill
+			}
+			weights[i] /= max(mean/float64(len(fills)), quotaFillFloor)
+		}
+		total += weights[i]
+	}
+
+	pick := rand.Float64() * total
+	for i, weight := range weights {
+		if pick < weight {
+			return factor.Levels[i]
+		}
+		pick -= weight
+	}
+	return factor.Levels[len(factor.Levels)-1]
+}
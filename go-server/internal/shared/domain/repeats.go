@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TrialRepeat shows an earlier trial again later in the session, to measure how consistently
+// a participant chooses. The repeat is a copy of the stored scenario, not a new generation.
+type TrialRepeat struct {
+	Of       int  `json:"of"`               // 1-based position of the trial repeated, a design trial
+	Position int  `json:"position"`         // 1-based position of the repeat, after Of
+	Mirror   bool `json:"mirror,omitempty"` // show the scene mirrored left to right
+}
+
+// PlanLength is the number of scenarios a plan holds: the design trials, attention checks
+// and repeats
+func (c DesignConfig) PlanLength() int {
+	return c.Trials + len(c.AttentionChecks) + len(c.Repeats)
+}
+
+// validateInserts checks that attention checks and repeats take distinct positions of the
+// plan and that every repeat follows the design trial it repeats
+func (c DesignConfig) validateInserts() error {
+	total := c.PlanLength()
+	taken := make(map[int]bool)
+	take := func(position int, what string) error {
+		if position < 1 || position > total {
+			return fmt.Errorf("%s position %d is outside trials 1-%d", what, position, total)
+		}
+		if taken[position] {
+			return fmt.Errorf("%s position %d is already taken", what, position)
+		}
+		taken[position] = true
+		return nil
+	}
+
+	for _, check := range c.AttentionChecks {
+		if !slices.Contains(AttentionCheckKinds, check.Kind) {
+			return fmt.Errorf("unknown attention check kind %q", check.Kind)
+		}
+		if err := take(check.Position, "attention check"); err != nil {
+			return err
+		}
+	}
+	for _, repeat := range c.Repeats {
+		if err := take(repeat.Position, "repeat"); err != nil {
+			return err
+		}
+	}
+	for _, repeat := range c.Repeats {
+		if repeat.Of < 1 || repeat.Of >= repeat.Position {
+			return fmt.Errorf("repeat at position %d must repeat an earlier trial, not %d", repeat.Position, repeat.Of)
+		}
+		if taken[repeat.Of] {
+			return fmt.Errorf("repeat at position %d must repeat a design trial, position %d is a check or repeat", repeat.Position, repeat.Of)
+		}
+	}
+	return nil
+}
+
+// assemblePlan lays the shuffled design trials around the attention checks and repeats
+func assemblePlan(deck []ScenarioFactors, config DesignConfig, solver *ruleSolver) ([]ScenarioFactors, error) {
+	plan := make([]ScenarioFactors, config.PlanLength())
+	inserted := make([]bool, len(plan))
+	for _, check := range config.AttentionChecks {
+		trial, err := solver.attentionTrial(check.Kind)
+		if err != nil {
+			return nil, err
+		}
+		plan[check.Position-1], inserted[check.Position-1] = trial, true
+	}
+	for _, repeat := range config.Repeats {
+		inserted[repeat.Position-1] = true
+	}
+
+	next := 0
+	for i := range plan {
+		if !inserted[i] {
+			plan[i] = deck[next]
+			next++
+		}
+	}
+	for _, repeat := range config.Repeats {
+		trial := plan[repeat.Of-1]
+		trial.Repeat = &repeat
+		plan[repeat.Position-1] = trial
+	}
+	return plan, nil
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateInserts(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  []AttentionCheck
+		repeats []TrialRepeat
+		wantErr string
+	}{
+		{name: "nothing inserted"},
+		{
+			name:    "checks and repeats in free positions",
+			checks:  []AttentionCheck{{Position: 3, Kind: CheckClearPath}, {Position: 6, Kind: CheckInstruction}},
+			repeats: []TrialRepeat{{Of: 2, Position: 7}, {Of: 1, Position: 8, Mirror: true}},
+		},
+		{
+			name:    "position past the end of the plan",
+			checks:  []AttentionCheck{{Position: 6, Kind: CheckClearPath}},
+			wantErr: "attention check position 6 is outside trials 1-5",
+		},
+		{
+			name:    "position zero",
+			repeats: []TrialRepeat{{Of: 1, Position: 0}},
+			wantErr: "repeat position 0 is outside trials 1-5",
+		},
+		{
+			name:    "two inserts at one position",
+			checks:  []AttentionCheck{{Position: 3, Kind: CheckClearPath}},
+			repeats: []TrialRepeat{{Of: 1, Position: 3}},
+			wantErr: "repeat position 3 is already taken",
+		},
+		{
+			name:    "unknown check kind",
+			checks:  []AttentionCheck{{Position: 2, Kind: "pop_quiz"}},
+			wantErr: `unknown attention check kind "pop_quiz"`,
+		},
+		{
+			name:    "repeat before the trial it repeats",
+			repeats: []TrialRepeat{{Of: 4, Position: 2}},
+			wantErr: "must repeat an earlier trial, not 4",
+		},
+		{
+			name:    "repeat of itself",
+			repeats: []TrialRepeat{{Of: 3, Position: 3}},
+			wantErr: "must repeat an earlier trial, not 3",
+		},
+		{
+			name:    "repeat of a check",
+			checks:  []AttentionCheck{{Position: 2, Kind: CheckClearPath}},
+			repeats: []TrialRepeat{{Of: 2, Position: 5}},
+			wantErr: "position 2 is a check or repeat",
+		},
+		{
+			name:    "repeat of a repeat",
+			repeats: []TrialRepeat{{Of: 1, Position: 3}, {Of: 3, Position: 5}},
+			wantErr: "position 3 is a check or repeat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Four design trials plus the inserts
+			config := DesignConfig{Trials: 4, AttentionChecks: tt.checks, Repeats: tt.repeats}
+			err := config.validateInserts()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// planLayout writes a plan as D<n> for the nth design trial of the deck, C for a check and
+// R<of> for a repeat
+func planLayout(plan []ScenarioFactors) string {
+	parts := make([]string, len(plan))
+	for i, trial := range plan {
+		switch {
+		case trial.Repeat != nil:
+			parts[i] = fmt.Sprintf("R%d", trial.Repeat.Of)
+		case trial.AttentionCheck != "":
+			parts[i] = "C"
+		default:
+			parts[i] = fmt.Sprintf("D%d", trial.GroupSize)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestAssemblePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		trials  int
+		checks  []AttentionCheck
+		repeats []TrialRepeat
+		want    string
+	}{
+		{name: "design trials only", trials: 3, want: "D1 D2 D3"},
+		{
+			name:   "checks between trials",
+			trials: 3,
+			checks: []AttentionCheck{{Position: 1, Kind: CheckClearPath}, {Position: 4, Kind: CheckInstruction}},
+			want:   "C D1 D2 C D3",
+		},
+		{
+			name:    "repeats of design trials",
+			trials:  3,
+			checks:  []AttentionCheck{{Position: 2, Kind: CheckClearPath}},
+			repeats: []TrialRepeat{{Of: 3, Position: 5}, {Of: 1, Position: 6, Mirror: true}},
+			want:    "D1 C D2 D3 R3 R1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DesignConfig{Trials: tt.trials, AttentionChecks: tt.checks, Repeats: tt.repeats}
+			deck := make([]ScenarioFactors, tt.trials)
+			for i := range deck {
+				deck[i] = ScenarioFactors{GroupSize: i + 1, Speed: fmt.Sprintf("speed %d", i+1)}
+			}
+			plan, err := assemblePlan(deck, config, newRuleSolver(config.WithDefaults()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := planLayout(plan); got != tt.want {
+				t.Fatalf("plan = %s, want %s", got, tt.want)
+			}
+			for _, repeat := range tt.repeats {
+				copied, original := plan[repeat.Position-1], plan[repeat.Of-1]
+				if copied.Speed != original.Speed || copied.Repeat.Mirror != repeat.Mirror {
+					t.Errorf("repeat at %d = %+v, want a copy of %+v", repeat.Position, copied, original)
+				}
+				if original.Repeat != nil {
+					t.Errorf("trial %d was marked as a repeat of itself", repeat.Of)
+				}
+			}
+			for _, check := range tt.checks {
+				if kind := plan[check.Position-1].AttentionCheck; kind != check.Kind {
+					t.Errorf("check at %d is %q, want %q", check.Position, kind, check.Kind)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Area attributes a rule can test besides the design factors. They come from the template
+// regions at the spawn, so rules on them hold when the spawn is picked rather than when the
+// plan is laid out.
+const (
+	AreaSchoolZone         = "school_zone"
+	AreaPedestrianPriority = "pedestrian_priority"
+	AreaConstruction       = "construction"
+)
+
+var areaAttributes = []string{AreaSchoolZone, AreaPedestrianPriority, AreaConstruction}
+
+// Rule kinds
+const (
+	RuleForbids  = "forbids"
+	RuleRequires = "requires"
+)
+
+// LevelTest holds when an attribute has one of the levels, or none of them when negated
+type LevelTest struct {
+	Factor string
+	Levels []string
+	Negate bool
+}
+
+func (t LevelTest) String() string {
+	op := "="
+	if t.Negate {
+		op = "!="
+	}
+	return t.Factor + op + strings.Join(t.Levels, "|")
+}
+
+func (t LevelTest) holds(level string) bool {
+	return slices.Contains(t.Levels, level) != t.Negate
+}
+
+// FactorRule is a declarative constraint on the levels a trial combines, written as text:
+//
+//	brake_status=Failed forbids speed=Low           never both
+//	visibility=Night requires speed=Low|Medium      the second whenever the first
+//	never ped_child & Violation & school_zone       never all of them
+//
+// Terms are joined with &. A term lists levels with | and negates them with !=; a bare level
+// stands for the one factor that has it, and a bare area attribute for attribute=true.
+// Factor names may be CamelCase (BrakeStatus) and levels in any case.
+type FactorRule struct {
+	When []LevelTest
+	Kind string
+	Then []LevelTest // empty for "never" rules
+}
+
+// ParseFactorRule reads a rule written as text
+func ParseFactorRule(text string) (FactorRule, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return FactorRule{}, errors.New("rule is empty")
+	}
+
+	rule := FactorRule{Kind: RuleForbids}
+	var when, then []string
+	if strings.EqualFold(fields[0], "never") {
+		when = fields[1:]
+	} else {
+		split := slices.IndexFunc(fields, func(field string) bool {
+			return strings.EqualFold(field, RuleForbids) || strings.EqualFold(field, RuleRequires)
+		})
+		if split < 0 {
+			return FactorRule{}, fmt.Errorf("rule %q needs \"forbids\", \"requires\" or a leading \"never\"", text)
+		}
+		rule.Kind = strings.ToLower(fields[split])
+		when, then = fields[:split], fields[split+1:]
+		if len(then) == 0 {
+			return FactorRule{}, fmt.Errorf("rule %q has nothing after %q", text, rule.Kind)
+		}
+	}
+
+	var err error
+	if rule.When, err = parseTerms(when); err != nil {
+		return FactorRule{}, fmt.Errorf("rule %q: %w", text, err)
+	}
+	if len(then) > 0 {
+		if rule.Then, err = parseTerms(then); err != nil {
+			return FactorRule{}, fmt.Errorf("rule %q: %w", text, err)
+		}
+	}
+	return rule, nil
+}
+
+// MustParseFactorRule is ParseFactorRule for rules fixed in code
+func MustParseFactorRule(text string) FactorRule {
+	rule, err := ParseFactorRule(text)
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+func parseTerms(fields []string) ([]LevelTest, error) {
+	var tests []LevelTest
+	for _, term := range strings.Split(strings.Join(fields, ""), "&") {
+		if term == "" {
+			return nil, errors.New("empty term")
+		}
+		test, err := parseTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, test)
+	}
+	return tests, nil
+}
+
+func parseTerm(term string) (LevelTest, error) {
+	name, value, negate := "", term, false
+	if i := strings.Index(term, "!="); i >= 0 {
+		name, value, negate = term[:i], term[i+2:], true
+	} else if i := strings.Index(term, "="); i >= 0 {
+		name, value = term[:i], term[i+1:]
+	}
+
+	if name == "" {
+		// A bare area attribute or level
+		if area := normaliseFactorName(value); slices.Contains(areaAttributes, area) {
+			return LevelTest{Factor: area, Levels: []string{"true"}}, nil
+		}
+		owner := ""
+		for _, factor := range DesignFactors {
+			if canonicalLevel(factor.Levels, value) == "" {
+				continue
+			}
+			if owner != "" {
+				return LevelTest{}, fmt.Errorf("level %q belongs to both %s and %s, write factor=level", value, owner, factor.Name)
+			}
+			owner = factor.Name
+		}
+		if owner == "" {
+			return LevelTest{}, fmt.Errorf("no factor has level %q", value)
+		}
+		name = owner
+	}
+
+	name = normaliseFactorName(name)
+	levels, ok := ruleLevels(name)
+	if !ok {
+		return LevelTest{}, fmt.Errorf("unknown factor %q", name)
+	}
+	test := LevelTest{Factor: name, Negate: negate}
+	for _, value := range strings.Split(value, "|") {
+		level := canonicalLevel(levels, value)
+		if level == "" {
+			return LevelTest{}, fmt.Errorf("factor %q has no level %q", name, value)
+		}
+		test.Levels = append(test.Levels, level)
+	}
+	return test, nil
+}
+
+// ruleLevels lists the levels a rule can test an attribute for
+func ruleLevels(name string) ([]string, bool) {
+	if slices.Contains(areaAttributes, name) {
+		return []string{"false", "true"}, true
+	}
+	factor, ok := DesignFactorByName(name)
+	return factor.Levels, ok
+}
+
+func canonicalLevel(levels []string, value string) string {
+	for _, level := range levels {
+		if strings.EqualFold(level, value) {
+			return level
+		}
+	}
+	return ""
+}
+
+// normaliseFactorName turns BrakeStatus or brakeStatus into brake_status
+func normaliseFactorName(name string) string {
+	var out strings.Builder
+	for i, r := range strings.TrimSpace(name) {
+		if unicode.IsUpper(r) {
+			if i > 0 && !strings.HasSuffix(out.String(), "_") {
+				out.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+func (r FactorRule) String() string {
+	when := joinTests(r.When)
+	if len(r.Then) == 0 {
+		return "never " + when
+	}
+	return when + " " + r.Kind + " " + joinTests(r.Then)
+}
+
+func joinTests(tests []LevelTest) string {
+	terms := make([]string, len(tests))
+	for i, test := range tests {
+		terms[i] = test.String()
+	}
+	return strings.Join(terms, " & ")
+}
+
+func (r FactorRule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON reads a rule string, or a map of levels never shown together
+// ({"road_condition": "Icy", "speed": "Low"}) as earlier configs wrote them
+func (r *FactorRule) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var combination map[string]string
+		if json.Unmarshal(data, &combination) != nil {
+			return fmt.Errorf("a rule is a string such as \"brake_status=Failed forbids speed=Low\"")
+		}
+		if len(combination) == 0 {
+			return errors.New("rule is empty")
+		}
+		terms := make([]string, 0, len(combination))
+		for name, level := range combination {
+			terms = append(terms, name+"="+level)
+		}
+		slices.Sort(terms)
+		text = "never " + strings.Join(terms, " & ")
+	}
+	rule, err := ParseFactorRule(text)
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+// broken reports whether the levels looked up break the rule. Attributes not looked up yet
+// could still go either way, so they never break it.
+func (r FactorRule) broken(lookup func(name string) (string, bool)) bool {
+	for _, test := range r.When {
+		level, ok := lookup(test.Factor)
+		if !ok || !test.holds(level) {
+			return false
+		}
+	}
+	if r.Kind == RuleRequires {
+		for _, test := range r.Then {
+			if level, ok := lookup(test.Factor); ok && !test.holds(level) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, test := range r.Then {
+		level, ok := lookup(test.Factor)
+		if !ok || !test.holds(level) {
+			return false
+		}
+	}
+	return true
+}
+
+// testsArea reports whether the rule tests an area attribute
+func (r FactorRule) testsArea() bool {
+	for _, test := range slices.Concat(r.When, r.Then) {
+		if slices.Contains(areaAttributes, test.Factor) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsArea reports whether a trial placed in an area breaks none of the rules
+func AllowsArea(rules []FactorRule, trial ScenarioFactors, regions RegionContext) bool {
+	lookup := func(name string) (string, bool) {
+		switch name {
+		case AreaSchoolZone:
+			return strconv.FormatBool(regions.SchoolZone), true
+		case AreaPedestrianPriority:
+			return strconv.FormatBool(regions.PedestrianPriority), true
+		case AreaConstruction:
+			return strconv.FormatBool(regions.Construction), true
+		}
+		return trial.Level(name), true
+	}
+	for _, rule := range rules {
+		if rule.broken(lookup) {
+			return false
+		}
+	}
+	return true
+}
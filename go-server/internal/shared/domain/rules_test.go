@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFactorRule(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    FactorRule
+		wantErr string
+	}{
+		{
+			text: "brake_status=Failed forbids speed=Low",
+			want: FactorRule{
+				When: []LevelTest{{Factor: FactorBrakeStatus, Levels: []string{"Failed"}}},
+				Kind: RuleForbids,
+				Then: []LevelTest{{Factor: FactorSpeed, Levels: []string{"Low"}}},
+			},
+		},
+		{
+			text: "visibility=Night requires speed=Low|Medium",
+			want: FactorRule{
+				When: []LevelTest{{Factor: FactorVisibility, Levels: []string{"Night"}}},
+				Kind: RuleRequires,
+				Then: []LevelTest{{Factor: FactorSpeed, Levels: []string{"Low", "Medium"}}},
+			},
+		},
+		{
+			text: "never ped_child & Violation & school_zone",
+			want: FactorRule{
+				When: []LevelTest{
+					{Factor: FactorPrimaryEntity, Levels: []string{"ped_child"}},
+					{Factor: FactorPrimaryBehavior, Levels: []string{"Violation"}},
+					{Factor: AreaSchoolZone, Levels: []string{"true"}},
+				},
+				Kind: RuleForbids,
+			},
+		},
+		{
+			text: "BrakeStatus=failed REQUIRES speed!=high",
+			want: FactorRule{
+				When: []LevelTest{{Factor: FactorBrakeStatus, Levels: []string{"Failed"}}},
+				Kind: RuleRequires,
+				Then: []LevelTest{{Factor: FactorSpeed, Levels: []string{"High"}, Negate: true}},
+			},
+		},
+		{
+			text: "never road_condition=Icy&speed=Low & construction=false",
+			want: FactorRule{
+				When: []LevelTest{
+					{Factor: FactorRoadCondition, Levels: []string{"Icy"}},
+					{Factor: FactorSpeed, Levels: []string{"Low"}},
+					{Factor: AreaConstruction, Levels: []string{"false"}},
+				},
+				Kind: RuleForbids,
+			},
+		},
+		{text: "   ", wantErr: "rule is empty"},
+		{text: "speed=Low speed=High", wantErr: `needs "forbids", "requires" or a leading "never"`},
+		{text: "speed=Low forbids", wantErr: `has nothing after "forbids"`},
+		{text: "never speed=Low &", wantErr: "empty term"},
+		{text: "never altitude=High", wantErr: `unknown factor "altitude"`},
+		{text: "never speed=Warp", wantErr: `factor "speed" has no level "Warp"`},
+		{text: "never Teleport", wantErr: `no factor has level "Teleport"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseFactorRule(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rule = %#v, want %#v", got, tt.want)
+			}
+			// The text form reads back as the same rule
+			again, err := ParseFactorRule(got.String())
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("%q does not read back: %#v, %v", got.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestAllowsArea(t *testing.T) {
+	rules := []FactorRule{
+		MustParseFactorRule("never ped_child & Violation & school_zone"),
+		MustParseFactorRule("construction requires speed=Low"),
+	}
+	child := ScenarioFactors{PrimaryEntity: "ped_child", PrimaryBehavior: "Violation", Speed: "Low"}
+	fast := ScenarioFactors{PrimaryEntity: "ped_elderly", PrimaryBehavior: "Compliant", Speed: "High"}
+
+	tests := []struct {
+		name    string
+		trial   ScenarioFactors
+		regions RegionContext
+		want    bool
+	}{
+		{name: "violating child outside a school zone", trial: child, want: true},
+		{name: "violating child in a school zone", trial: child, regions: RegionContext{SchoolZone: true}, want: false},
+		{name: "low speed through construction", trial: child, regions: RegionContext{Construction: true}, want: true},
+		{name: "high speed through construction", trial: fast, regions: RegionContext{Construction: true}, want: false},
+		{name: "high speed elsewhere", trial: fast, regions: RegionContext{PedestrianPriority: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AllowsArea(rules, tt.trial, tt.regions); got != tt.want {
+				t.Errorf("AllowsArea = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
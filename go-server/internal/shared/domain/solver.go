@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"strings"
+)
+
+// ruleSolver completes partial trials so that no rule is broken: a depth-first search over
+// the factors that tries the preferred levels of each factor first and backtracks when a
+// rule breaks. Rules on area attributes are left to spawn picking.
+type ruleSolver struct {
+	factors  []DesignFactor // every design factor with the levels the study uses
+	rules    []FactorRule
+	feasible map[string]bool // completable partial trials, by their levels
+}
+
+func newRuleSolver(config DesignConfig) *ruleSolver {
+	return &ruleSolver{
+		factors:  config.AllFactors(),
+		rules:    config.Constraints,
+		feasible: make(map[string]bool),
+	}
+}
+
+// levelOrder picks the levels to try for a factor, best first, given the levels set so far
+type levelOrder func(factor DesignFactor, assign map[string]string) []string
+
+func naturalOrder(factor DesignFactor, _ map[string]string) []string {
+	return factor.Levels
+}
+
+// solve sets the factors of order that assign leaves open, keeping the levels already there.
+// On failure assign is as it was.
+func (s *ruleSolver) solve(assign map[string]string, order []DesignFactor, prefer levelOrder) bool {
+	if s.broken(assign) {
+		return false
+	}
+	for i, factor := range order {
+		if _, set := assign[factor.Name]; set {
+			continue
+		}
+		for _, level := range prefer(factor, assign) {
+			assign[factor.Name] = level
+			if s.solve(assign, order[i+1:], prefer) {
+				return true
+			}
+		}
+		delete(assign, factor.Name)
+		return false
+	}
+	return true
+}
+
+func (s *ruleSolver) broken(assign map[string]string) bool {
+	lookup := func(name string) (string, bool) {
+		level, ok := assign[name]
+		return level, ok
+	}
+	for _, rule := range s.rules {
+		if rule.broken(lookup) {
+			return true
+		}
+	}
+	return false
+}
+
+// completable reports whether some trial with the given levels breaks no rule
+func (s *ruleSolver) completable(levels map[string]string) bool {
+	key := assignKey(levels)
+	if feasible, ok := s.feasible[key]; ok {
+		return feasible
+	}
+	assign := make(map[string]string, len(s.factors))
+	for name, level := range levels {
+		assign[name] = level
+	}
+	feasible := s.solve(assign, s.factors, naturalOrder)
+	s.feasible[key] = feasible
+	return feasible
+}
+
+func assignKey(levels map[string]string) string {
+	var key strings.Builder
+	for _, factor := range DesignFactors {
+		if level, ok := levels[factor.Name]; ok {
+			key.WriteString(factor.Name + "=" + level + ";")
+		}
+	}
+	return key.String()
+}
+
+// conflict narrows the rules down to a set that still leaves the given levels with no valid
+// trial but satisfies them once any one of its rules is dropped
+func (s *ruleSolver) conflict(levels map[string]string) []FactorRule {
+	core := append([]FactorRule(nil), s.rules...)
+	for i := len(core) - 1; i >= 0; i-- {
+		without := append(append([]FactorRule(nil), core[:i]...), core[i+1:]...)
+		trial := &ruleSolver{factors: s.factors, rules: without, feasible: make(map[string]bool)}
+		if !trial.completable(levels) {
+			core = without
+		}
+	}
+	return core
+}
+
+// unreachable lists the levels, written factor=level, that no trial can take under the rules
+func (s *ruleSolver) unreachable() []string {
+	var levels []string
+	for _, factor := range s.factors {
+		for _, level := range factor.Levels {
+			if !s.completable(map[string]string{factor.Name: level}) {
+				levels = append(levels, factor.Name+"="+level)
+			}
+		}
+	}
+	return levels
+}
+
+func joinRules(rules []FactorRule) string {
+	texts := make([]string, len(rules))
+	for i, rule := range rules {
+		texts[i] = rule.String()
+	}
+	return strings.Join(texts, "; ")
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parseRules(texts ...string) []FactorRule {
+	parsed := make([]FactorRule, len(texts))
+	for i, text := range texts {
+		parsed[i] = MustParseFactorRule(text)
+	}
+	return parsed
+}
+
+func TestRuleSolverCompletable(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []FactorRule
+		levels map[string]string
+		want   bool
+	}{
+		{name: "no rules", levels: map[string]string{FactorSpeed: "Low"}, want: true},
+		{
+			name:   "forbidden pair",
+			rules:  parseRules("road_condition=Icy forbids speed=Low"),
+			levels: map[string]string{FactorRoadCondition: "Icy", FactorSpeed: "Low"},
+			want:   false,
+		},
+		{
+			name:   "forbidden pair completed around",
+			rules:  parseRules("road_condition=Icy forbids speed=Low"),
+			levels: map[string]string{FactorRoadCondition: "Icy"},
+			want:   true,
+		},
+		{
+			name:   "requirement chained through another factor",
+			rules:  parseRules("visibility=Night requires speed=Low", "speed=Low requires road_condition=Dry"),
+			levels: map[string]string{FactorVisibility: "Night", FactorRoadCondition: "Wet"},
+			want:   false,
+		},
+		{
+			name:   "requirement met by the rest of the trial",
+			rules:  parseRules("visibility=Night requires speed=Low", "speed=Low requires road_condition=Dry"),
+			levels: map[string]string{FactorVisibility: "Night"},
+			want:   true,
+		},
+		{
+			name:   "rules that no trial satisfies",
+			rules:  parseRules("never has_tailgater=true", "never has_tailgater=false"),
+			levels: nil,
+			want:   false,
+		},
+		{
+			name:   "area rules are left to spawn picking",
+			rules:  parseRules("never speed=Low & school_zone"),
+			levels: map[string]string{FactorSpeed: "Low"},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solver := newRuleSolver(DesignConfig{Constraints: tt.rules})
+			if got := solver.completable(tt.levels); got != tt.want {
+				t.Errorf("completable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleSolverConflict(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []FactorRule
+		levels map[string]string
+		want   []FactorRule
+	}{
+		{
+			name:   "the two rules that clash, not the bystander",
+			rules:  parseRules("never has_tailgater=true", "road_condition=Icy forbids speed=Low", "never has_tailgater=false"),
+			levels: nil,
+			want:   parseRules("never has_tailgater=true", "never has_tailgater=false"),
+		},
+		{
+			name:   "the chain that rules out a level",
+			rules:  parseRules("visibility=Night requires speed=Low", "never brake_status=Fade", "speed=Low requires road_condition=Dry", "never road_condition=Dry"),
+			levels: map[string]string{FactorVisibility: "Night"},
+			want:   parseRules("visibility=Night requires speed=Low", "speed=Low requires road_condition=Dry", "never road_condition=Dry"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solver := newRuleSolver(DesignConfig{Constraints: tt.rules})
+			got := solver.conflict(tt.levels)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conflict = %s, want %s", joinRules(got), joinRules(tt.want))
+			}
+		})
+	}
+}
+
+func TestUnplaceableLevels(t *testing.T) {
+	plain := RegionContext{}
+	school := RegionContext{SchoolZone: true}
+
+	tests := []struct {
+		name  string
+		rules []FactorRule
+		areas map[DrivingSide][]RegionContext
+		want  []string
+	}{
+		{
+			name:  "no area rules",
+			rules: parseRules("road_condition=Icy forbids speed=Low"),
+			areas: nil,
+			want:  nil,
+		},
+		{
+			name:  "a level that needs an area the pool lacks",
+			rules: parseRules("speed=Low requires school_zone"),
+			areas: map[DrivingSide][]RegionContext{DrivingSideRight: {plain}, DrivingSideLeft: {plain}},
+			want:  []string{"speed=Low"},
+		},
+		{
+			name:  "the area is in the pool",
+			rules: parseRules("speed=Low requires school_zone"),
+			areas: map[DrivingSide][]RegionContext{DrivingSideRight: {plain, school}, DrivingSideLeft: {plain}},
+			want:  nil,
+		},
+		{
+			name:  "the area only exists for the other driving side",
+			rules: parseRules("location=UK requires school_zone"),
+			areas: map[DrivingSide][]RegionContext{DrivingSideRight: {school}, DrivingSideLeft: {plain}},
+			want:  []string{"location=UK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DesignConfig{
+				Factors:     []string{FactorSpeed, FactorLocation},
+				Constraints: tt.rules,
+			}
+			if got := config.UnplaceableLevels(tt.areas); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unplaceable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,13 @@
 package domain
 
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
 type Tile struct {
 	Name          string         `json:"name"`
 	IsInteractive bool           `json:"is_interactive"`
@@ -18,49 +26,131 @@ const (
 	SurfaceWalkable   SurfaceType = "walkable"   // Sidewalks
 	SurfaceBuilding   SurfaceType = "building"   // Walls/Roofs (No placement allowed)
 	SurfaceRestricted SurfaceType = "restricted" // Road markings (No placement allowed)
+	SurfaceBikeLane   SurfaceType = "bike_lane"  // Cycle lanes beside the carriageway
+	SurfaceVerge      SurfaceType = "verge"      // Grass off the road
+	SurfaceParking    SurfaceType = "parking"    // Kerbside parking bays
+	SurfaceTramTrack  SurfaceType = "tram_track" // Tram rails in the carriageway
+	SurfaceMedian     SurfaceType = "median"     // Raised divider between opposing lanes
 )
 
 const (
 	UsageVehicle    = "vehicle"
 	UsagePedestrian = "pedestrian"
+	UsageCyclist    = "cyclist"
+	UsageTram       = "tram"
+)
+
+// ScanRule is what the side-zone scan does when it meets a surface
+type ScanRule string
+
+const (
+	ScanTake ScanRule = "take" // the tile can join zone B/C
+	ScanSkip ScanRule = "skip" // look past it (paint, medians)
+	ScanStop ScanRule = "stop" // wall, stop scanning this way
+)
+
+// SurfaceRules says how the generator, zones and paths treat a surface
+type SurfaceRules struct {
+	Description string   `json:"description"` // shown to the LLM
+	Usage       []string `json:"usage"`       // entity kinds that belong here
+	Road        bool     `json:"road"`        // carriageway: lanes and zone A may cover it
+	Spawn       bool     `json:"spawn"`       // the AV (or a tailgater) may start here
+	Scan        ScanRule `json:"scan"`
+}
+
+// Allows reports whether an entity kind belongs on the surface
+func (r SurfaceRules) Allows(usage string) bool {
+	for _, u := range r.Usage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// tileRegistryFile is the layout of tiles.json
+type tileRegistryFile struct {
+	Surfaces map[SurfaceType]SurfaceRules `json:"surfaces"`
+	Tiles    []struct {
+		ID int `json:"id"`
+		Tile
+	} `json:"tiles"`
+}
+
+//go:embed tiles.json
+var defaultTileRegistry []byte
+
+var (
+	// TileRegistry maps tile IDs in grid_data to their definition
+	TileRegistry map[int]Tile
+	// SurfaceRegistry holds the rules for every surface a tile can have
+	SurfaceRegistry map[SurfaceType]SurfaceRules
 )
 
-var TileRegistry = map[int]Tile{
-	// OBSTACLES (Buildings) (Cannot place anything here)
-	0: {Name: "Roof", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceBuilding, DefaultUsage: []string{}}},
-	1: {Name: "Building Edge Top", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceBuilding, DefaultUsage: []string{}}},
-	2: {Name: "Building Edge Bottom", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceBuilding, DefaultUsage: []string{}}},
-
-	// --- SIDEWALKS (Safe Zones) ---
-	// Physics: Concrete
-	// Rules: Pedestrians only
-	3:  {Name: "Sidewalk Top", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	4:  {Name: "Sidewalk Bottom", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	5:  {Name: "Sidewalk Corner Top-Right", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	6:  {Name: "Sidewalk Corner Bottom-Right", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	7:  {Name: "Sidewalk Corner Top-Left", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	8:  {Name: "Sidewalk Corner Bottom-Left", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	19: {Name: "Sidewalk Left", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-	20: {Name: "Sidewalk Right", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceWalkable, DefaultUsage: []string{UsagePedestrian}}},
-
-	// --- ROADS (Drivable) ---
-	// Physics: Asphalt
-	// Rules: Vehicles only (usually)
-	9:  {Name: "Asphalt Horizontal", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceDrivable, DefaultUsage: []string{UsageVehicle}}},
-	10: {Name: "Asphalt Vertical", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceDrivable, DefaultUsage: []string{UsageVehicle}}},
-	11: {Name: "Intersection Box", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceDrivable, DefaultUsage: []string{UsageVehicle}}},
-
-	// --- ROAD MARKINGS (Restricted - No placement allowed) ---
-	// Physics: Asphalt (It's just paint on road)
-	12: {Name: "Yellow Line Dash", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceRestricted, DefaultUsage: []string{}}},
-	13: {Name: "Double Yellow Horizontal", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceRestricted, DefaultUsage: []string{}}},
-	14: {Name: "Double Yellow Vertical", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceRestricted, DefaultUsage: []string{}}},
-	17: {Name: "Yellow Line Vertical", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceRestricted, DefaultUsage: []string{}}},
-	18: {Name: "Yellow Line Horizontal", IsInteractive: false, Definition: TileDefinition{SurfaceType: SurfaceRestricted, DefaultUsage: []string{}}},
-
-	// --- CROSSWALKS (Shared Zones) ---
-	// Physics: Asphalt (Cars can drive over it)
-	// Rules: SHARED (Both Vehicles and Pedestrians belong here)
-	15: {Name: "Crosswalk Vertical", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceDrivable, DefaultUsage: []string{UsageVehicle, UsagePedestrian}}},
-	16: {Name: "Crosswalk Horizontal", IsInteractive: true, Definition: TileDefinition{SurfaceType: SurfaceDrivable, DefaultUsage: []string{UsageVehicle, UsagePedestrian}}},
+func init() {
+	if err := LoadTileRegistry(defaultTileRegistry); err != nil {
+		panic(fmt.Sprintf("embedded tiles.json: %v", err))
+	}
+}
+
+// LoadTileRegistry replaces the tile and surface registries with the contents of a
+// tiles.json document. Call it at startup, before anything reads the registries.
+func LoadTileRegistry(data []byte) error {
+	var file tileRegistryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	for surface, rules := range file.Surfaces {
+		switch rules.Scan {
+		case ScanTake, ScanSkip, ScanStop:
+		default:
+			return fmt.Errorf("surface %q has unknown scan rule %q", surface, rules.Scan)
+		}
+	}
+
+	tiles := make(map[int]Tile, len(file.Tiles))
+	for _, entry := range file.Tiles {
+		if _, exists := tiles[entry.ID]; exists {
+			return fmt.Errorf("tile %d is defined twice", entry.ID)
+		}
+		if _, ok := file.Surfaces[entry.Definition.SurfaceType]; !ok {
+			return fmt.Errorf("tile %d (%s) has unknown surface %q", entry.ID, entry.Name, entry.Definition.SurfaceType)
+		}
+		tiles[entry.ID] = entry.Tile
+	}
+
+	TileRegistry = tiles
+	SurfaceRegistry = file.Surfaces
+	return nil
+}
+
+// LoadTileRegistryFile loads a tiles.json from disk, see LoadTileRegistry
+func LoadTileRegistryFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := LoadTileRegistry(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// RulesFor returns the rules for a surface. Unknown surfaces are treated like buildings.
+func RulesFor(surface SurfaceType) SurfaceRules {
+	if rules, ok := SurfaceRegistry[surface]; ok {
+		return rules
+	}
+	return SurfaceRules{Scan: ScanStop}
+}
+
+// Surfaces lists the registered surfaces in name order
+func Surfaces() []SurfaceType {
+	surfaces := make([]SurfaceType, 0, len(SurfaceRegistry))
+	for surface := range SurfaceRegistry {
+		surfaces = append(surfaces, surface)
+	}
+	sort.Slice(surfaces, func(i, j int) bool { return surfaces[i] < surfaces[j] })
+	return surfaces
 }
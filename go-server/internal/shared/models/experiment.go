@@ -0,0 +1,17 @@
+package models
+
+import "gorm.io/datatypes"
+
+// Experiment is one study: its design, the maps it draws from and its timer. Sessions
+// register against an experiment by slug, so a pilot and a main study can run side by side.
+type Experiment struct {
+	BaseModel
+	Slug            string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"slug"`
+	Name            string         `gorm:"type:varchar(255);not null" json:"name"`
+	Active          bool           `gorm:"type:boolean;not null" json:"active"` // accepts new sessions
+	Design          datatypes.JSON `gorm:"type:jsonb;not null" json:"design"`   // domain.DesignConfig
+	TemplatePool    datatypes.JSON `gorm:"type:jsonb" json:"template_pool"`     // template meta IDs, every template when empty
+	Arms            datatypes.JSON `gorm:"type:jsonb" json:"arms"`              // between-subject conditions
+	TimerDurationMs int64          `gorm:"type:bigint;not null" json:"timer_duration_ms"`
+	NetworkBufferMs int64          `gorm:"type:bigint;not null" json:"network_buffer_ms"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "github.com/google/uuid"
+
+// FactorQuota counts how often a factor level, or a pair of levels, was shown to completed
+// participants of an experiment. Pairs join their factor names and levels with "&" (see
+// domain.QuotaCell). Cells counted before experiments existed have no experiment until
+// startup assigns them to the default one.
+type FactorQuota struct {
+	BaseModel
+	ExperimentID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_experiment_quota_cell" json:"experiment_id"`
+	Factor       string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_experiment_quota_cell" json:"factor"`
+	Level        string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_experiment_quota_cell" json:"level"`
+	Count        int64     `gorm:"not null;default:0" json:"count"`
+}
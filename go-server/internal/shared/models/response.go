@@ -13,4 +13,6 @@ type Response struct {
 	IsTimeout      bool           `gorm:"type:bool;default:false;not null" json:"is_timeout"`
 	HasInteracted  bool           `gorm:"type:bool;default:true;not null" json:"has_interacted"`
 	ResponseTimeMs int64          `gorm:"not null" json:"response_time_ms"`
+	// Whether an attention check was passed, nil for design trials
+	AttentionPassed *bool `gorm:"type:bool" json:"attention_passed,omitempty"`
 }
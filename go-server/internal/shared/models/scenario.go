@@ -11,14 +11,27 @@ type Scenario struct {
 	BaseModel
 	ContextTemplateID uuid.UUID        `gorm:"type:uuid;not null" json:"context_template_id"`
 	ContextTemplate   *ContextTemplate `gorm:"foreignKey:ContextTemplateID" json:"context_template,omitempty"`
-	SessionID         uuid.UUID        `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE" json:"session_id"`
-	Session           *Session         `gorm:"foreignKey:SessionID" json:"-"`
-	Entities          datatypes.JSON   `gorm:"type:jsonb" json:"entities"`
-	Factors           datatypes.JSON   `gorm:"type:jsonb" json:"factors"`
-	DilemmaOptions    datatypes.JSON   `gorm:"type:jsonb" json:"dilemma_options"`
-	Narrative         string           `gorm:"type:text" json:"narrative"`
-	TridentSpawn      datatypes.JSON   `gorm:"type:jsonb" json:"trident_spawn"`
-	StartedAt         *time.Time       `gorm:"type:timestamp" json:"started_at"`
+	// Exact map revision shown to the participant (nil for scenarios created before versioning)
+	ContextTemplateVersionID *uuid.UUID              `gorm:"type:uuid" json:"context_template_version_id,omitempty"`
+	ContextTemplateVersion   *ContextTemplateVersion `gorm:"foreignKey:ContextTemplateVersionID" json:"context_template_version,omitempty"`
+	SessionID                uuid.UUID               `gorm:"type:uuid;not null;index:idx_scenario_step;constraint:OnDelete:CASCADE" json:"session_id"`
+	Session                  *Session                `gorm:"foreignKey:SessionID" json:"-"`
+	Entities                 datatypes.JSON          `gorm:"type:jsonb" json:"entities"`
+	Factors                  datatypes.JSON          `gorm:"type:jsonb" json:"factors"`
+	DilemmaOptions           datatypes.JSON          `gorm:"type:jsonb" json:"dilemma_options"`
+	Narrative                string                  `gorm:"type:text" json:"narrative"`
+	TridentSpawn             datatypes.JSON          `gorm:"type:jsonb" json:"trident_spawn"`
+	RegionContext            datatypes.JSON          `gorm:"type:jsonb" json:"region_context"` // regions at the spawn and zones, see domain.RegionContext
+	StartedAt                *time.Time              `gorm:"type:timestamp" json:"started_at"`
+	// 1-based position in the session's plan
+	Step int `gorm:"type:int;not null;default:0;index:idx_scenario_step" json:"step"`
+	// Catch trials: the kind of check and the option that passes it, empty for design trials
+	AttentionCheck string `gorm:"type:varchar(20);not null;default:'';index" json:"attention_check,omitempty"`
+	ExpectedChoice string `gorm:"type:varchar(20)" json:"expected_choice,omitempty"`
+	// Repeats: the earlier scenario this one copies, and whether it is shown mirrored left to
+	// right (its ranking order is then in the mirrored frame)
+	RepeatOfID *uuid.UUID `gorm:"type:uuid;index" json:"repeat_of_id,omitempty"`
+	Mirrored   bool       `gorm:"type:bool;not null;default:false" json:"mirrored,omitempty"`
 	// Relationship
 	Response *Response `gorm:"foreignKey:ScenarioID" json:"response,omitempty"`
 }
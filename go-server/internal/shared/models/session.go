@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
 
@@ -61,9 +62,18 @@ type Session struct {
 	// State Management
 	Status    SessionStatus `gorm:"type:smallint;default:1;not null" json:"status"`
 	ExpiresAt time.Time     `gorm:"type:timestamp;not null" json:"expires_at"`
+	// Study the session registered for (nil for sessions created before experiments, which
+	// belong to the default experiment)
+	ExperimentID *uuid.UUID  `gorm:"type:uuid;index" json:"experiment_id,omitempty"`
+	Experiment   *Experiment `gorm:"foreignKey:ExperimentID" json:"-"`
+	Arm          string      `gorm:"type:varchar(100);index" json:"arm,omitempty"` // between-subject condition, empty when the experiment has none
 	// Experiment Plan & Feedback
 	ExperimentPlan datatypes.JSON `gorm:"type:jsonb" json:"experiment_plan"`
+	DesignReport   datatypes.JSON `gorm:"type:jsonb" json:"design_report,omitempty"` // balance report of the plan
 	Feedback       datatypes.JSON `gorm:"type:jsonb" json:"feedback,omitempty"`
+	// Attention result, recorded on completion: catch trials answered and passed
+	AttentionChecks int `gorm:"type:smallint;not null;default:0" json:"attention_checks"`
+	AttentionPassed int `gorm:"type:smallint;not null;default:0" json:"attention_passed"`
 	// Relationships
 	Scenarios []Scenario `gorm:"foreignKey:SessionID" json:"scenarios,omitempty"`
 }
@@ -1,6 +1,9 @@
 package models
 
-import "gorm.io/datatypes"
+import (
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
 
 type ContextTemplate struct {
 	BaseModel
@@ -10,4 +13,22 @@ type ContextTemplate struct {
 	GridData   datatypes.JSON `gorm:"type:jsonb;not null" json:"grid_data"`
 	LaneConfig datatypes.JSON `gorm:"type:jsonb" json:"lane_config"`
 	Meta       datatypes.JSON `gorm:"type:jsonb" json:"meta"`
+	// Revision tracking
+	Version          int        `gorm:"type:int;default:0;not null" json:"version"`
+	CurrentVersionID *uuid.UUID `gorm:"type:uuid" json:"current_version_id,omitempty"`
+}
+
+// ContextTemplateVersion is an immutable snapshot of a template, written on every edit.
+// Scenarios pin one so replay always uses the map the participant actually saw.
+type ContextTemplateVersion struct {
+	BaseModel
+	ContextTemplateID uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_template_version" json:"context_template_id"`
+	ContextTemplate   *ContextTemplate `gorm:"foreignKey:ContextTemplateID" json:"-"`
+	Version           int              `gorm:"type:int;not null;uniqueIndex:idx_template_version" json:"version"`
+	Name              string           `gorm:"not null" json:"name"`
+	Width             int              `gorm:"type:int;not null" json:"width"`
+	Height            int              `gorm:"type:int;not null" json:"height"`
+	GridData          datatypes.JSON   `gorm:"type:jsonb;not null" json:"grid_data"`
+	LaneConfig        datatypes.JSON   `gorm:"type:jsonb" json:"lane_config"`
+	Meta              datatypes.JSON   `gorm:"type:jsonb" json:"meta"`
 }
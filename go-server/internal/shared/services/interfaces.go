@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 
+	"github.com/direwen/go-server/internal/experiment"
 	"github.com/direwen/go-server/internal/shared/models"
 	"github.com/google/uuid"
 )
@@ -12,6 +13,7 @@ type SessionValidator interface {
 	GetSession(ctx context.Context, id uuid.UUID) (*models.Session, error)
 	ValidateSession(ctx context.Context, session models.Session) error
 	CompleteSession(ctx context.Context, session models.Session) error
+	GetCondition(session models.Session) (*experiment.Condition, error)
 }
 
 // ScenarioReader provides read access to scenarios
@@ -0,0 +1,44 @@
+package template
+
+import (
+	"encoding/json"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// TemplateFile is the on-disk shape of a context template (one JSON file per map)
+type TemplateFile struct {
+	Name       string          `json:"name"`
+	Width      int             `json:"width"`
+	Height     int             `json:"height"`
+	Meta       json.RawMessage `json:"meta"`
+	GridData   json.RawMessage `json:"grid_data"`
+	LaneConfig json.RawMessage `json:"lane_config"`
+}
+
+// TemplateMeta holds the parsed fields of ContextTemplate.Meta
+type TemplateMeta struct {
+	ID          string             `json:"id"`                     // stable identifier, e.g. TPL_002_INTERSECTION
+	DrivingSide domain.DrivingSide `json:"driving_side,omitempty"` // rule the lanes were drawn for, defaults to keep right
+	CustomLanes bool               `json:"custom_lanes,omitempty"` // skip the check against inferred lanes
+	Trident     *TridentGeometry   `json:"trident,omitempty"`      // zone geometry, defaults to the TRIDENT_ZONE_* env
+	Generator   *GenerateOptions   `json:"generator,omitempty"`    // parameters the map was generated from
+	Signals     []SignalJunction   `json:"signals,omitempty"`      // signalised junctions
+	Regions     []domain.Region    `json:"regions,omitempty"`      // speed limits, school zones and other area layers
+}
+
+// TemplateDiff lists the fields that changed for a single template during sync
+type TemplateDiff struct {
+	MetaID  string   `json:"meta_id"`
+	Version int      `json:"version"` // revision created by this sync
+	Changes []string `json:"changes"`
+}
+
+// SyncReport summarises what a template sync did to the database
+type SyncReport struct {
+	Created   []string       `json:"created"`
+	Updated   []TemplateDiff `json:"updated"`
+	Unchanged []string       `json:"unchanged"`
+	Restored  []string       `json:"restored"` // retired earlier and back on disk
+	Orphaned  []string       `json:"orphaned"` // no longer on disk, retired: soft-deleted and no longer picked
+}
@@ -0,0 +1,595 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"gorm.io/datatypes"
+)
+
+// RoadKind is the road layout built by the generator
+type RoadKind string
+
+const (
+	RoadStraight   RoadKind = "straight"
+	RoadCrossroads RoadKind = "crossroads"
+	RoadTJunction  RoadKind = "t_junction"
+	RoadRoundabout RoadKind = "roundabout"
+	RoadArterial   RoadKind = "arterial" // wide main road crossed by a narrow side street
+)
+
+// CrosswalkPlacement says where the generator paints crosswalks
+type CrosswalkPlacement string
+
+const (
+	CrosswalkNone     CrosswalkPlacement = "none"
+	CrosswalkJunction CrosswalkPlacement = "junction" // on every arm, just outside the junction
+	CrosswalkMidblock CrosswalkPlacement = "midblock" // halfway along the west arm of the main road
+)
+
+// GenerateOptions are the parameters of a generated map. Zero values pick the defaults for the kind.
+type GenerateOptions struct {
+	Kind           RoadKind           `json:"kind"`
+	Lanes          int                `json:"lanes"`       // lanes per direction on the main (horizontal) road
+	MinorLanes     int                `json:"minor_lanes"` // lanes per direction on the crossing road
+	Sidewalks      bool               `json:"sidewalks"`
+	Crosswalks     CrosswalkPlacement `json:"crosswalks"`
+	CrosswalkWidth int                `json:"crosswalk_width"` // tiles along the road
+	Width          int                `json:"width"`
+	Height         int                `json:"height"`
+	DrivingSide    domain.DrivingSide `json:"driving_side"`
+	Trident        *TridentGeometry   `json:"-"` // stored as meta.trident
+	ID             string             `json:"-"`
+	Name           string             `json:"-"`
+}
+
+// Tile IDs the generator paints with (see domain.TileRegistry)
+const (
+	tileRoof               = 0
+	tileBuildingEdgeTop    = 1
+	tileBuildingEdgeBottom = 2
+	tileSidewalkTop        = 3
+	tileSidewalkBottom     = 4
+	tileSidewalkCornerTR   = 5
+	tileSidewalkCornerBR   = 6
+	tileSidewalkCornerTL   = 7
+	tileSidewalkCornerBL   = 8
+	tileIntersectionBox    = 11
+	tileDoubleYellowH      = 13
+	tileDoubleYellowV      = 14
+	tileCrosswalkVertical  = 15
+	tileCrosswalkHoriz     = 16
+	tileYellowLineV        = 17
+	tileYellowLineH        = 18
+	tileSidewalkLeft       = 19
+	tileSidewalkRight      = 20
+)
+
+const (
+	maxGeneratedLanes      = 4
+	roundaboutIslandMargin = 1 // island half-size beyond the ring width
+)
+
+// WithDefaults fills unset options with the defaults for the road kind
+func (o GenerateOptions) WithDefaults() GenerateOptions {
+	if o.Kind == "" {
+		o.Kind = RoadStraight
+	}
+	if o.Lanes == 0 {
+		o.Lanes = 1
+		if o.Kind == RoadArterial {
+			o.Lanes = 3
+		}
+	}
+	if o.MinorLanes == 0 {
+		o.MinorLanes = o.Lanes
+		if o.Kind == RoadArterial {
+			o.MinorLanes = 1
+		}
+	}
+	if o.Crosswalks == "" {
+		o.Crosswalks = CrosswalkJunction
+		if o.Kind == RoadStraight {
+			o.Crosswalks = CrosswalkMidblock
+		}
+	}
+	if o.CrosswalkWidth == 0 {
+		o.CrosswalkWidth = 1
+	}
+	if o.Width == 0 || o.Height == 0 {
+		width, height := 20, 11
+		switch o.Kind {
+		case RoadArterial:
+			width, height = 24, 17
+		case RoadRoundabout:
+			size := 2*(6+o.Lanes+o.Lanes+roundaboutIslandMargin) + 1
+			width, height = size, size
+		}
+		if o.Width == 0 {
+			o.Width = width
+		}
+		if o.Height == 0 {
+			o.Height = height
+		}
+	}
+	if o.DrivingSide == "" {
+		o.DrivingSide = domain.DrivingSideRight
+	}
+	if o.ID == "" {
+		o.ID = fmt.Sprintf("GEN_%s_%dL", strings.ToUpper(string(o.Kind)), o.Lanes)
+	}
+	if o.Name == "" {
+		o.Name = fmt.Sprintf("Generated %s (%d lane(s) each way)", strings.ReplaceAll(string(o.Kind), "_", " "), o.Lanes)
+	}
+	return o
+}
+
+// GenerateTemplate builds a map from the options and validates it like any map file.
+// The result is rejected if it has no valid trident spawn for either driving side.
+func GenerateTemplate(opts GenerateOptions) (ContextTemplate, error) {
+	opts = opts.WithDefaults()
+	if err := checkGenerateOptions(opts); err != nil {
+		return ContextTemplate{}, err
+	}
+
+	c := newCanvas(opts.Height, opts.Width)
+	var laneConfig domain.LaneConfigMap
+	customLanes := false
+
+	switch opts.Kind {
+	case RoadRoundabout:
+		if err := c.drawRoundabout(opts); err != nil {
+			return ContextTemplate{}, err
+		}
+		laneConfig = c.roundaboutLanes(opts)
+		if opts.DrivingSide == domain.DrivingSideLeft {
+			laneConfig = ReverseLaneConfig(laneConfig)
+		}
+		// Ring lanes turn, which lane inference cannot derive
+		customLanes = true
+	default:
+		if err := c.drawJunction(opts); err != nil {
+			return ContextTemplate{}, err
+		}
+	}
+	c.dress(opts.Sidewalks)
+
+	if laneConfig == nil {
+		inferred, err := InferLaneConfig(c.grid, opts.DrivingSide)
+		if err != nil {
+			return ContextTemplate{}, fmt.Errorf("lane inference failed: %w", err)
+		}
+		laneConfig = inferred
+	}
+
+	gridJSON, err := json.Marshal(c.grid)
+	if err != nil {
+		return ContextTemplate{}, err
+	}
+	laneJSON, err := json.Marshal(laneConfig)
+	if err != nil {
+		return ContextTemplate{}, err
+	}
+	metaJSON, err := json.Marshal(TemplateMeta{
+		ID:          opts.ID,
+		DrivingSide: opts.DrivingSide,
+		CustomLanes: customLanes,
+		Trident:     opts.Trident,
+		Generator:   &opts,
+	})
+	if err != nil {
+		return ContextTemplate{}, err
+	}
+
+	tpl := ContextTemplate{
+		Name:       opts.Name,
+		Width:      opts.Width,
+		Height:     opts.Height,
+		GridData:   datatypes.JSON(gridJSON),
+		LaneConfig: datatypes.JSON(laneJSON),
+		Meta:       datatypes.JSON(metaJSON),
+	}
+
+	if report := ValidateTemplate(tpl); report.HasIssues() {
+		return ContextTemplate{}, &ValidationError{Reports: []*ValidationReport{report}}
+	}
+	return tpl, nil
+}
+
+// checkGenerateOptions rejects parameter combinations the generator cannot draw
+func checkGenerateOptions(opts GenerateOptions) error {
+	switch opts.Kind {
+	case RoadStraight, RoadCrossroads, RoadTJunction, RoadRoundabout, RoadArterial:
+	default:
+		return fmt.Errorf("unknown road kind %q", opts.Kind)
+	}
+	switch opts.Crosswalks {
+	case CrosswalkNone, CrosswalkJunction, CrosswalkMidblock:
+	default:
+		return fmt.Errorf("unknown crosswalk placement %q", opts.Crosswalks)
+	}
+	switch opts.DrivingSide {
+	case domain.DrivingSideRight, domain.DrivingSideLeft:
+	default:
+		return fmt.Errorf("unknown driving side %q", opts.DrivingSide)
+	}
+	if opts.Lanes < 1 || opts.Lanes > maxGeneratedLanes || opts.MinorLanes < 1 || opts.MinorLanes > maxGeneratedLanes {
+		return fmt.Errorf("lanes must be between 1 and %d per direction", maxGeneratedLanes)
+	}
+	if opts.Kind == RoadArterial && opts.Lanes < 2 {
+		return fmt.Errorf("an arterial needs at least 2 lanes per direction")
+	}
+	if opts.Kind == RoadStraight && opts.Crosswalks == CrosswalkJunction {
+		return fmt.Errorf("a straight road has no junction for crosswalks, use %q", CrosswalkMidblock)
+	}
+	if opts.CrosswalkWidth < 1 {
+		return fmt.Errorf("crosswalk width must be at least 1")
+	}
+	return nil
+}
+
+// band is a road cross-section: lanes one way, the centre line, lanes the other way
+type band struct {
+	start, lanes int
+}
+
+func (b band) end() int    { return b.start + 2*b.lanes }
+func (b band) centre() int { return b.start + b.lanes }
+
+// centredBand places a road of the given lanes around the middle of a dimension
+func centredBand(size, lanes int) band {
+	return band{start: size/2 - lanes, lanes: lanes}
+}
+
+// canvas is a grid under construction; road cells are painted first, sidewalks and
+// building edges are derived from them in dress
+type canvas struct {
+	grid          [][]int
+	height, width int
+}
+
+func newCanvas(height, width int) *canvas {
+	grid := make([][]int, height)
+	for row := range grid {
+		grid[row] = make([]int, width) // tileRoof
+	}
+	return &canvas{grid: grid, height: height, width: width}
+}
+
+func (c *canvas) inBounds(row, col int) bool {
+	return row >= 0 && row < c.height && col >= 0 && col < c.width
+}
+
+func (c *canvas) set(row, col, tile int) {
+	if c.inBounds(row, col) {
+		c.grid[row][col] = tile
+	}
+}
+
+func (c *canvas) isRoad(row, col int) bool {
+	if !c.inBounds(row, col) {
+		return false
+	}
+	surface := domain.TileRegistry[c.grid[row][col]].Definition.SurfaceType
+	return domain.RulesFor(surface).Road
+}
+
+// horizontalRoad paints lanes and the centre line of a west-east road between two columns
+func (c *canvas) horizontalRoad(b band, fromCol, toCol int) {
+	centre := tileYellowLineH
+	if b.lanes > 1 {
+		centre = tileDoubleYellowH
+	}
+	for col := fromCol; col <= toCol; col++ {
+		for row := b.start; row <= b.end(); row++ {
+			tile := tileAsphaltHorizontal
+			if row == b.centre() {
+				tile = centre
+			}
+			c.set(row, col, tile)
+		}
+	}
+}
+
+// verticalRoad paints lanes and the centre line of a north-south road between two rows
+func (c *canvas) verticalRoad(b band, fromRow, toRow int) {
+	centre := tileYellowLineV
+	if b.lanes > 1 {
+		centre = tileDoubleYellowV
+	}
+	for row := fromRow; row <= toRow; row++ {
+		for col := b.start; col <= b.end(); col++ {
+			tile := tileAsphaltVertical
+			if col == b.centre() {
+				tile = centre
+			}
+			c.set(row, col, tile)
+		}
+	}
+}
+
+// box paints an intersection box over a rectangle
+func (c *canvas) box(fromRow, toRow, fromCol, toCol int) {
+	for row := fromRow; row <= toRow; row++ {
+		for col := fromCol; col <= toCol; col++ {
+			c.set(row, col, tileIntersectionBox)
+		}
+	}
+}
+
+// crosswalkAcrossHorizontal paints a crosswalk over a west-east road starting at col
+func (c *canvas) crosswalkAcrossHorizontal(b band, col, width int) {
+	for i := 0; i < width; i++ {
+		for row := b.start; row <= b.end(); row++ {
+			c.set(row, col+i, tileCrosswalkHoriz)
+		}
+	}
+}
+
+// crosswalkAcrossVertical paints a crosswalk over a north-south road starting at row
+func (c *canvas) crosswalkAcrossVertical(b band, row, width int) {
+	for i := 0; i < width; i++ {
+		for col := b.start; col <= b.end(); col++ {
+			c.set(row+i, col, tileCrosswalkVertical)
+		}
+	}
+}
+
+// drawJunction paints the straight, crossroads, T-junction and arterial layouts
+func (c *canvas) drawJunction(opts GenerateOptions) error {
+	main := centredBand(c.height, opts.Lanes)
+	if main.start < 0 || main.end() >= c.height {
+		return fmt.Errorf("a %d-lane road does not fit in %d rows", opts.Lanes, c.height)
+	}
+	c.horizontalRoad(main, 0, c.width-1)
+
+	sidewalk := 0
+	if opts.Sidewalks {
+		sidewalk = 1
+	}
+
+	// West arm of the main road ends where the crossing road (and its sidewalk) begins
+	westArmEnd := c.width - 1
+	if opts.Kind != RoadStraight {
+		minor := centredBand(c.width, opts.MinorLanes)
+		if minor.start < 0 || minor.end() >= c.width {
+			return fmt.Errorf("a %d-lane crossing road does not fit in %d columns", opts.MinorLanes, c.width)
+		}
+		westArmEnd = minor.start - 1 - sidewalk
+
+		if opts.Kind == RoadTJunction {
+			// Side street joins from the north and ends at the main road's near lanes
+			c.verticalRoad(minor, 0, main.start-1)
+			c.box(main.start, main.centre()-1, minor.start, minor.end())
+		} else {
+			c.verticalRoad(minor, 0, c.height-1)
+			c.box(main.start, main.end(), minor.start, minor.end())
+		}
+
+		if opts.Crosswalks == CrosswalkJunction {
+			w := opts.CrosswalkWidth
+			c.crosswalkAcrossHorizontal(main, westArmEnd-w+1, w)
+			c.crosswalkAcrossHorizontal(main, minor.end()+1+sidewalk, w)
+			c.crosswalkAcrossVertical(minor, main.start-1-sidewalk-w+1, w)
+			if opts.Kind != RoadTJunction {
+				c.crosswalkAcrossVertical(minor, main.end()+1+sidewalk, w)
+			}
+		}
+	}
+
+	if opts.Crosswalks == CrosswalkMidblock {
+		c.crosswalkAcrossHorizontal(main, westArmEnd/2-opts.CrosswalkWidth/2, opts.CrosswalkWidth)
+	}
+	return nil
+}
+
+// roundaboutGeometry returns the ring's outer rectangle; the island fills what the ring encloses
+func roundaboutGeometry(opts GenerateOptions) (top, bottom, left, right int) {
+	reach := opts.Lanes + roundaboutIslandMargin + opts.Lanes
+	centreRow, centreCol := opts.Height/2, opts.Width/2
+	return centreRow - reach, centreRow + reach, centreCol - reach, centreCol + reach
+}
+
+// drawRoundabout paints a square ring around a central island with four approach roads
+func (c *canvas) drawRoundabout(opts GenerateOptions) error {
+	top, bottom, left, right := roundaboutGeometry(opts)
+	if top < 1 || left < 1 || bottom >= c.height-1 || right >= c.width-1 {
+		return fmt.Errorf("a %d-lane roundabout does not fit in %dx%d", opts.Lanes, c.width, c.height)
+	}
+	n := opts.Lanes
+
+	approachH := centredBand(c.height, n)
+	approachV := centredBand(c.width, n)
+	c.horizontalRoad(approachH, 0, left-1)
+	c.horizontalRoad(approachH, right+1, c.width-1)
+	c.verticalRoad(approachV, 0, top-1)
+	c.verticalRoad(approachV, bottom+1, c.height-1)
+
+	// Ring: straight sides plus intersection boxes where it turns
+	for row := top; row <= bottom; row++ {
+		for col := left; col <= right; col++ {
+			onTop, onBottom := row < top+n, row > bottom-n
+			onLeft, onRight := col < left+n, col > right-n
+			switch {
+			case (onTop || onBottom) && (onLeft || onRight):
+				c.set(row, col, tileIntersectionBox)
+			case onTop || onBottom:
+				c.set(row, col, tileAsphaltHorizontal)
+			case onLeft || onRight:
+				c.set(row, col, tileAsphaltVertical)
+			}
+		}
+	}
+	if opts.Crosswalks != CrosswalkNone {
+		// Every arm gets a crosswalk just before the ring
+		w := opts.CrosswalkWidth
+		sidewalk := 0
+		if opts.Sidewalks {
+			sidewalk = 1
+		}
+		c.crosswalkAcrossHorizontal(approachH, left-sidewalk-w, w)
+		c.crosswalkAcrossHorizontal(approachH, right+1+sidewalk, w)
+		c.crosswalkAcrossVertical(approachV, top-sidewalk-w, w)
+		c.crosswalkAcrossVertical(approachV, bottom+1+sidewalk, w)
+	}
+	return nil
+}
+
+// roundaboutLanes builds keep-right lanes: approaches as on any two-way road, the ring
+// counter-clockwise with each corner taking the direction of the side it turns into
+func (c *canvas) roundaboutLanes(opts GenerateOptions) domain.LaneConfigMap {
+	top, bottom, left, right := roundaboutGeometry(opts)
+	n := opts.Lanes
+	approachH := centredBand(c.height, n)
+	approachV := centredBand(c.width, n)
+
+	config := domain.LaneConfigMap{
+		domain.DirectionNorth: {},
+		domain.DirectionSouth: {},
+		domain.DirectionEast:  {},
+		domain.DirectionWest:  {},
+	}
+	add := func(direction domain.Direction, row, col int) {
+		config[direction] = append(config[direction], [2]int{row, col})
+	}
+
+	// Approaches
+	for col := 0; col < c.width; col++ {
+		if col >= left && col <= right {
+			continue
+		}
+		for row := approachH.start; row < approachH.centre(); row++ {
+			add(domain.DirectionWest, row, col)
+		}
+		for row := approachH.centre() + 1; row <= approachH.end(); row++ {
+			add(domain.DirectionEast, row, col)
+		}
+	}
+	for row := 0; row < c.height; row++ {
+		if row >= top && row <= bottom {
+			continue
+		}
+		for col := approachV.start; col < approachV.centre(); col++ {
+			add(domain.DirectionSouth, row, col)
+		}
+		for col := approachV.centre() + 1; col <= approachV.end(); col++ {
+			add(domain.DirectionNorth, row, col)
+		}
+	}
+
+	// Ring
+	for row := top; row <= bottom; row++ {
+		for col := left; col <= right; col++ {
+			onTop, onBottom := row < top+n, row > bottom-n
+			onLeft, onRight := col < left+n, col > right-n
+			switch {
+			case onLeft && !onBottom:
+				add(domain.DirectionSouth, row, col)
+			case onBottom && !onRight:
+				add(domain.DirectionEast, row, col)
+			case onRight && !onTop:
+				add(domain.DirectionNorth, row, col)
+			case onTop:
+				add(domain.DirectionWest, row, col)
+			}
+		}
+	}
+
+	return config
+}
+
+// dress turns the roof next to roads into sidewalks (or building edges when there are
+// none) and picks the sidewalk and edge tiles from which side the road is on
+func (c *canvas) dress(sidewalks bool) {
+	if sidewalks {
+		var curb [][2]int
+		for row := 0; row < c.height; row++ {
+			for col := 0; col < c.width; col++ {
+				if c.isRoad(row, col) {
+					continue
+				}
+				if c.touchesRoad(row, col) {
+					curb = append(curb, [2]int{row, col})
+				}
+			}
+		}
+		for _, cell := range curb {
+			c.grid[cell[0]][cell[1]] = c.sidewalkTile(cell[0], cell[1])
+		}
+	}
+
+	isWalkable := func(row, col int) bool {
+		return c.inBounds(row, col) && domain.TileRegistry[c.grid[row][col]].Definition.SurfaceType == domain.SurfaceWalkable
+	}
+	isOpen := func(row, col int) bool {
+		return c.isRoad(row, col) || isWalkable(row, col)
+	}
+	for row := 0; row < c.height; row++ {
+		for col := 0; col < c.width; col++ {
+			if c.grid[row][col] != tileRoof {
+				continue
+			}
+			switch {
+			case isOpen(row+1, col):
+				c.grid[row][col] = tileBuildingEdgeTop
+			case isOpen(row-1, col):
+				c.grid[row][col] = tileBuildingEdgeBottom
+			}
+		}
+	}
+}
+
+// touchesRoad reports whether any of the eight neighbours is road
+func (c *canvas) touchesRoad(row, col int) bool {
+	for dRow := -1; dRow <= 1; dRow++ {
+		for dCol := -1; dCol <= 1; dCol++ {
+			if dRow == 0 && dCol == 0 {
+				continue
+			}
+			if c.isRoad(row+dRow, col+dCol) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sidewalkTile picks the sidewalk tile for a curb cell following the hand-drawn maps:
+// "Top" sits above a road, "Left" sits left of one, corners wrap two roads
+func (c *canvas) sidewalkTile(row, col int) int {
+	below, above := c.isRoad(row+1, col), c.isRoad(row-1, col)
+	right, left := c.isRoad(row, col+1), c.isRoad(row, col-1)
+
+	switch {
+	case below && right:
+		return tileSidewalkCornerTR
+	case below && left:
+		return tileSidewalkCornerTL
+	case above && right:
+		return tileSidewalkCornerBR
+	case above && left:
+		return tileSidewalkCornerBL
+	case below:
+		return tileSidewalkTop
+	case above:
+		return tileSidewalkBottom
+	case right:
+		return tileSidewalkLeft
+	case left:
+		return tileSidewalkRight
+	}
+
+	// Only a diagonal neighbour: the outside corner of a junction
+	switch {
+	case c.isRoad(row+1, col+1):
+		return tileSidewalkCornerTR
+	case c.isRoad(row+1, col-1):
+		return tileSidewalkCornerTL
+	case c.isRoad(row-1, col+1):
+		return tileSidewalkCornerBR
+	default:
+		return tileSidewalkCornerBL
+	}
+}
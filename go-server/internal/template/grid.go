@@ -0,0 +1,138 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// Grid is an immutable, dense view of a template's tiles. Cells are stored in flat
+// slices indexed by row*width+col; once built a Grid is safe to share between goroutines.
+type Grid struct {
+	height, width int
+	tiles         []int
+	surfaces      []domain.SurfaceType
+}
+
+// Row/col step for one tile of travel in each direction
+var directionSteps = map[domain.Direction][2]int{
+	domain.DirectionNorth: {-1, 0},
+	domain.DirectionSouth: {1, 0},
+	domain.DirectionEast:  {0, 1},
+	domain.DirectionWest:  {0, -1},
+}
+
+// NewGrid copies a 2D tile array into a Grid and resolves every surface from the TileRegistry
+func NewGrid(rows [][]int) (*Grid, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, errors.New("grid is empty")
+	}
+
+	height, width := len(rows), len(rows[0])
+	g := &Grid{
+		height:   height,
+		width:    width,
+		tiles:    make([]int, height*width),
+		surfaces: make([]domain.SurfaceType, height*width),
+	}
+	for row, cols := range rows {
+		if len(cols) != width {
+			return nil, fmt.Errorf("row %d has %d tiles, expected %d", row, len(cols), width)
+		}
+		for col, tileID := range cols {
+			i := row*width + col
+			g.tiles[i] = tileID
+			if tile, exists := domain.TileRegistry[tileID]; exists {
+				g.surfaces[i] = tile.Definition.SurfaceType
+			}
+		}
+	}
+	return g, nil
+}
+
+func (g *Grid) Height() int { return g.height }
+func (g *Grid) Width() int  { return g.width }
+
+func (g *Grid) InBounds(row, col int) bool {
+	return row >= 0 && row < g.height && col >= 0 && col < g.width
+}
+
+// index returns the flat slice index of a cell, -1 if out of bounds
+func (g *Grid) index(row, col int) int {
+	if !g.InBounds(row, col) {
+		return -1
+	}
+	return row*g.width + col
+}
+
+// Tile returns the tile ID at a cell, -1 if out of bounds
+func (g *Grid) Tile(row, col int) int {
+	if i := g.index(row, col); i >= 0 {
+		return g.tiles[i]
+	}
+	return -1
+}
+
+// Surface returns the surface at a cell; everything outside the grid counts as building
+func (g *Grid) Surface(row, col int) domain.SurfaceType {
+	if i := g.index(row, col); i >= 0 {
+		return g.surfaces[i]
+	}
+	return domain.SurfaceBuilding
+}
+
+// Rows copies the tiles back into a 2D array (for rendering and JSON)
+func (g *Grid) Rows() [][]int {
+	rows := make([][]int, g.height)
+	for row := range rows {
+		rows[row] = append([]int(nil), g.tiles[row*g.width:(row+1)*g.width]...)
+	}
+	return rows
+}
+
+// Neighbour returns the adjacent cell in a direction and whether it is inside the grid
+func (g *Grid) Neighbour(row, col int, direction domain.Direction) (int, int, bool) {
+	step, ok := directionSteps[direction]
+	if !ok {
+		return row, col, false
+	}
+	row, col = row+step[0], col+step[1]
+	return row, col, g.InBounds(row, col)
+}
+
+// Cast walks up to maxSteps cells from (row, col), excluding the start, calling visit on
+// each. The walk stops at the grid edge or as soon as visit returns false.
+func (g *Grid) Cast(row, col, dRow, dCol, maxSteps int, visit func(row, col int, surface domain.SurfaceType) bool) {
+	for step := 1; step <= maxSteps; step++ {
+		r, c := row+dRow*step, col+dCol*step
+		i := g.index(r, c)
+		if i < 0 || !visit(r, c, g.surfaces[i]) {
+			return
+		}
+	}
+}
+
+// laneGrid is the dense lane direction per cell for one driving side ("" where there is no lane)
+type laneGrid []domain.Direction
+
+func newLaneGrid(g *Grid, laneConfig domain.LaneConfigMap) laneGrid {
+	lanes := make(laneGrid, g.height*g.width)
+	// Fixed order so cells shared by two lanes (junction boxes) resolve the same way every load
+	for _, direction := range []domain.Direction{domain.DirectionNorth, domain.DirectionSouth, domain.DirectionEast, domain.DirectionWest} {
+		for _, coord := range laneConfig[direction] {
+			if i := g.index(coord[0], coord[1]); i >= 0 {
+				lanes[i] = direction
+			}
+		}
+	}
+	return lanes
+}
+
+// at returns the lane direction at a cell, "" if none or out of bounds
+func (l laneGrid) at(g *Grid, row, col int) domain.Direction {
+	if i := g.index(row, col); i >= 0 && i < len(l) {
+		return l[i]
+	}
+	return ""
+}
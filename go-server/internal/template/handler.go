@@ -0,0 +1,120 @@
+package template
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/internal/util"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Reload(c echo.Context) error {
+	report, err := h.service.Reload(c.Request().Context())
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return c.JSON(http.StatusUnprocessableEntity, util.StandardResponse{
+				Success: false,
+				Message: "Template validation failed",
+				Data:    validationErr.Reports,
+				Error:   err.Error(),
+			})
+		}
+		return util.ErrorResponse(c, http.StatusInternalServerError, "Failed to reload templates", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Templates reloaded", report)
+}
+
+// Render draws a template (or template version) with a spawn and its trident zones.
+// Query: format=ascii|svg, side=right|left, spawn=row,col,direction (random valid spawn if omitted).
+func (h *Handler) Render(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusBadRequest, "Invalid template ID", err)
+	}
+
+	side, err := parseSide(c.QueryParam("side"))
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusBadRequest, "Invalid driving side", err)
+	}
+
+	var spawn *domain.TridentSpawn
+	if value := c.QueryParam("spawn"); value != "" {
+		spawn, err = ParseSpawn(value)
+		if err != nil {
+			return util.ErrorResponse(c, http.StatusBadRequest, "Invalid spawn", err)
+		}
+	}
+
+	scene, err := h.service.Scene(id, side, spawn)
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusNotFound, "Failed to build scene", err)
+	}
+
+	format := RenderFormat(c.QueryParam("format"))
+	out, err := scene.Render(format)
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusBadRequest, "Invalid format", err)
+	}
+
+	if format == RenderSVG {
+		return c.Blob(http.StatusOK, "image/svg+xml", []byte(out))
+	}
+	return c.String(http.StatusOK, out)
+}
+
+// Spawns lists the pre-computed spawns of a template (or version) with their zones, swerve
+// paths and quality metrics.
+// Query: side=right|left, orientation=N|S|E|W, zone_a/zone_b/zone_c=<surface>, feasible=true,
+// signalised=true.
+func (h *Handler) Spawns(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusBadRequest, "Invalid template ID", err)
+	}
+
+	side, err := parseSide(c.QueryParam("side"))
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusBadRequest, "Invalid driving side", err)
+	}
+
+	criteria := SpawnCriteria{
+		Orientation:     domain.Direction(strings.ToUpper(c.QueryParam("orientation"))),
+		ZoneA:           domain.SurfaceType(c.QueryParam("zone_a")),
+		ZoneB:           domain.SurfaceType(c.QueryParam("zone_b")),
+		ZoneC:           domain.SurfaceType(c.QueryParam("zone_c")),
+		FeasibleSwerves: c.QueryParam("feasible") == "true",
+		Signalised:      c.QueryParam("signalised") == "true",
+	}
+
+	spawns, err := h.service.GetSpawns(id, side, criteria)
+	if err != nil {
+		return util.ErrorResponse(c, http.StatusNotFound, "Failed to list spawns", err)
+	}
+
+	return util.SuccessResponse(c, http.StatusOK, "Spawns retrieved", spawns)
+}
+
+// parseSide reads the side query param, defaulting to keep-right
+func parseSide(value string) (domain.DrivingSide, error) {
+	side := domain.DrivingSide(value)
+	if side == "" {
+		return domain.DrivingSideRight, nil
+	}
+	if side != domain.DrivingSideRight && side != domain.DrivingSideLeft {
+		return "", errors.New("side must be right or left")
+	}
+	return side, nil
+}
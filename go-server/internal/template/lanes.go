@@ -0,0 +1,270 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// Tile IDs the lane inference keys on
+const (
+	tileAsphaltHorizontal = 9
+	tileAsphaltVertical   = 10
+	tileMedian            = 28
+)
+
+var (
+	// Centre lines splitting a horizontal road into north/south halves
+	horizontalCentreLines = map[int]bool{12: true, 13: true, 18: true, tileMedian: true}
+	// Centre lines splitting a vertical road into west/east halves
+	verticalCentreLines = map[int]bool{14: true, 17: true, tileMedian: true}
+)
+
+// InferLaneConfig derives lane directions from the road tiles of a grid.
+//
+// Every row containing Asphalt Horizontal is a horizontal lane and every column containing
+// Asphalt Vertical is a vertical lane. Each lane runs along its contiguous stretch of road
+// (intersection boxes, crosswalks and markings included) and its direction comes from the
+// side of the centre line it sits on plus the driving side rule.
+func InferLaneConfig(grid [][]int, side domain.DrivingSide) (domain.LaneConfigMap, error) {
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return nil, fmt.Errorf("grid is empty")
+	}
+	height, width := len(grid), len(grid[0])
+
+	inBounds := func(row, col int) bool {
+		return row >= 0 && row < height && col >= 0 && col < width
+	}
+	isRoad := func(row, col int) bool {
+		if !inBounds(row, col) {
+			return false
+		}
+		return domain.RulesFor(domain.TileRegistry[grid[row][col]].Definition.SurfaceType).Road
+	}
+
+	config := domain.LaneConfigMap{
+		domain.DirectionNorth: {},
+		domain.DirectionSouth: {},
+		domain.DirectionEast:  {},
+		domain.DirectionWest:  {},
+	}
+
+	// Horizontal lanes
+	for row := 0; row < height; row++ {
+		for _, run := range roadRuns(width, func(i int) bool { return isRoad(row, i) }, func(i int) bool { return grid[row][i] == tileAsphaltHorizontal }) {
+			// Vote across the run: is the centre line above (south half) or below (north half)?
+			north, south := 0, 0
+			for col := run[0]; col <= run[1]; col++ {
+				if grid[row][col] != tileAsphaltHorizontal {
+					continue
+				}
+				switch centreLineSide(row, col, -1, 0, isRoad, func(r, c int) bool { return inBounds(r, c) && horizontalCentreLines[grid[r][c]] }) {
+				case 1:
+					north++
+				case -1:
+					south++
+				}
+			}
+			if north == south {
+				return nil, fmt.Errorf("row %d cols %d-%d: cannot tell which side of the centre line the lane is on", row, run[0], run[1])
+			}
+
+			direction := laneDirection(north > south, domain.DirectionWest, domain.DirectionEast, side)
+			for col := run[0]; col <= run[1]; col++ {
+				config[direction] = append(config[direction], [2]int{row, col})
+			}
+		}
+	}
+
+	// Vertical lanes
+	for col := 0; col < width; col++ {
+		for _, run := range roadRuns(height, func(i int) bool { return isRoad(i, col) }, func(i int) bool { return grid[i][col] == tileAsphaltVertical }) {
+			// Vote across the run: is the centre line to the right (west half) or left (east half)?
+			west, east := 0, 0
+			for row := run[0]; row <= run[1]; row++ {
+				if grid[row][col] != tileAsphaltVertical {
+					continue
+				}
+				switch centreLineSide(row, col, 0, -1, isRoad, func(r, c int) bool { return inBounds(r, c) && verticalCentreLines[grid[r][c]] }) {
+				case 1:
+					west++
+				case -1:
+					east++
+				}
+			}
+			if west == east {
+				return nil, fmt.Errorf("col %d rows %d-%d: cannot tell which side of the centre line the lane is on", col, run[0], run[1])
+			}
+
+			direction := laneDirection(west > east, domain.DirectionSouth, domain.DirectionNorth, side)
+			for row := run[0]; row <= run[1]; row++ {
+				config[direction] = append(config[direction], [2]int{row, col})
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// laneDirection picks the travel direction for a lane on the first or second half of a road.
+// Keeping right, the north half of a horizontal road runs west and the west half of a vertical
+// road runs south; keeping left flips both.
+func laneDirection(firstHalf bool, firstDir, secondDir domain.Direction, side domain.DrivingSide) domain.Direction {
+	if side == domain.DrivingSideLeft {
+		firstHalf = !firstHalf
+	}
+	if firstHalf {
+		return firstDir
+	}
+	return secondDir
+}
+
+// oppositeDirections reverses travel along the same lane
+var oppositeDirections = map[domain.Direction]domain.Direction{
+	domain.DirectionNorth: domain.DirectionSouth,
+	domain.DirectionSouth: domain.DirectionNorth,
+	domain.DirectionEast:  domain.DirectionWest,
+	domain.DirectionWest:  domain.DirectionEast,
+}
+
+// ReverseLaneConfig flips every lane to travel the other way. Applied to a keep-right
+// layout it yields the keep-left one for the same road tiles, and vice versa.
+func ReverseLaneConfig(config domain.LaneConfigMap) domain.LaneConfigMap {
+	if config == nil {
+		return nil
+	}
+	reversed := make(domain.LaneConfigMap, len(config))
+	for direction, coords := range config {
+		opposite, ok := oppositeDirections[direction]
+		if !ok {
+			opposite = direction
+		}
+		reversed[opposite] = append(reversed[opposite], coords...)
+	}
+	return reversed
+}
+
+// roadRuns returns the [start, end] stretches of contiguous road along a line
+// that contain at least one seed tile
+func roadRuns(length int, isRoad, isSeed func(i int) bool) [][2]int {
+	var runs [][2]int
+	for i := 0; i < length; {
+		if !isRoad(i) {
+			i++
+			continue
+		}
+		start, seeded := i, false
+		for i < length && isRoad(i) {
+			seeded = seeded || isSeed(i)
+			i++
+		}
+		if seeded {
+			runs = append(runs, [2]int{start, i - 1})
+		}
+	}
+	return runs
+}
+
+// centreLineSide scans from (row, col) in the (dRow, dCol) direction and its opposite
+// through road tiles. It returns 1 if only the opposite scan hits a centre line (the
+// tile sits on the first half), -1 if only the forward scan does, and 0 otherwise.
+// A median ends the road but still counts as the centre line.
+func centreLineSide(row, col, dRow, dCol int, isRoad func(r, c int) bool, isCentre func(r, c int) bool) int {
+	hits := func(sign int) bool {
+		for step := 1; ; step++ {
+			r, c := row+sign*dRow*step, col+sign*dCol*step
+			if isCentre(r, c) {
+				return true
+			}
+			if !isRoad(r, c) {
+				return false
+			}
+		}
+	}
+
+	before, after := hits(1), hits(-1)
+	switch {
+	case after && !before:
+		return 1
+	case before && !after:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// CompareLaneConfigs reports every cell where the declared lanes differ from the inferred ones
+func CompareLaneConfigs(declared, inferred domain.LaneConfigMap) []ValidationIssue {
+	flatten := func(config domain.LaneConfigMap) map[[2]int]map[domain.Direction]bool {
+		cells := make(map[[2]int]map[domain.Direction]bool)
+		for direction, coords := range config {
+			for _, coord := range coords {
+				if cells[coord] == nil {
+					cells[coord] = make(map[domain.Direction]bool)
+				}
+				cells[coord][direction] = true
+			}
+		}
+		return cells
+	}
+	have, want := flatten(declared), flatten(inferred)
+
+	var issues []ValidationIssue
+	report := func(coord [2]int, format string, args ...any) {
+		issues = append(issues, ValidationIssue{
+			Coordinate: &domain.Coordinate{Row: coord[0], Col: coord[1]},
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	for coord, directions := range want {
+		for direction := range directions {
+			if !have[coord][direction] {
+				report(coord, "inferred lane %s is missing from lane_config", direction)
+			}
+		}
+	}
+	for coord, directions := range have {
+		for direction := range directions {
+			if !want[coord][direction] {
+				report(coord, "lane %s does not match the road layout", direction)
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i].Coordinate, issues[j].Coordinate
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		if issues[i].Message != issues[j].Message {
+			return issues[i].Message < issues[j].Message
+		}
+		return a.Col < b.Col
+	})
+	return mergeIssueRuns(issues)
+}
+
+// mergeIssueRuns collapses identical messages on consecutive cells of a row into one issue
+func mergeIssueRuns(issues []ValidationIssue) []ValidationIssue {
+	var merged []ValidationIssue
+	for i := 0; i < len(issues); {
+		j := i + 1
+		for j < len(issues) {
+			prev, next := issues[j-1].Coordinate, issues[j].Coordinate
+			if issues[j].Message != issues[i].Message || next.Row != prev.Row || next.Col != prev.Col+1 {
+				break
+			}
+			j++
+		}
+
+		issue := issues[i]
+		if last := issues[j-1].Coordinate; j-i > 1 {
+			issue.Message = fmt.Sprintf("%s (through col %d)", issue.Message, last.Col)
+		}
+		merged = append(merged, issue)
+		i = j
+	}
+	return merged
+}
@@ -0,0 +1,146 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// A horizontal two-lane road between sidewalks, split by a centre line
+var horizontalRoad = [][]int{
+	{3, 3, 3, 3, 3, 3},
+	{9, 9, 9, 9, 9, 9},
+	{13, 13, 13, 13, 13, 13},
+	{9, 9, 9, 9, 9, 9},
+	{4, 4, 4, 4, 4, 4},
+}
+
+// A vertical two-lane road between sidewalks, split by a centre line
+var verticalRoad = [][]int{
+	{19, 10, 17, 10, 20},
+	{19, 10, 17, 10, 20},
+	{19, 10, 17, 10, 20},
+	{19, 10, 17, 10, 20},
+}
+
+func rowCells(row, width int) [][2]int {
+	cells := make([][2]int, width)
+	for col := range cells {
+		cells[col] = [2]int{row, col}
+	}
+	return cells
+}
+
+func colCells(col, height int) [][2]int {
+	cells := make([][2]int, height)
+	for row := range cells {
+		cells[row] = [2]int{row, col}
+	}
+	return cells
+}
+
+func lanes(north, south, east, west [][2]int) domain.LaneConfigMap {
+	config := domain.LaneConfigMap{
+		domain.DirectionNorth: {},
+		domain.DirectionSouth: {},
+		domain.DirectionEast:  {},
+		domain.DirectionWest:  {},
+	}
+	for direction, cells := range map[domain.Direction][][2]int{
+		domain.DirectionNorth: north,
+		domain.DirectionSouth: south,
+		domain.DirectionEast:  east,
+		domain.DirectionWest:  west,
+	} {
+		config[direction] = append(config[direction], cells...)
+	}
+	return config
+}
+
+func TestInferLaneConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		grid    [][]int
+		side    domain.DrivingSide
+		want    domain.LaneConfigMap
+		wantErr string
+	}{
+		{
+			name: "horizontal road keeping right",
+			grid: horizontalRoad,
+			side: domain.DrivingSideRight,
+			want: lanes(nil, nil, rowCells(3, 6), rowCells(1, 6)),
+		},
+		{
+			name: "horizontal road keeping left",
+			grid: horizontalRoad,
+			side: domain.DrivingSideLeft,
+			want: lanes(nil, nil, rowCells(1, 6), rowCells(3, 6)),
+		},
+		{
+			name: "vertical road keeping right",
+			grid: verticalRoad,
+			side: domain.DrivingSideRight,
+			want: lanes(colCells(3, 4), colCells(1, 4), nil, nil),
+		},
+		{
+			name: "vertical road keeping left",
+			grid: verticalRoad,
+			side: domain.DrivingSideLeft,
+			want: lanes(colCells(1, 4), colCells(3, 4), nil, nil),
+		},
+		{
+			name: "road without a centre line",
+			grid: [][]int{
+				{3, 3, 3, 3},
+				{9, 9, 9, 9},
+				{9, 9, 9, 9},
+				{4, 4, 4, 4},
+			},
+			side:    domain.DrivingSideRight,
+			wantErr: "cannot tell which side of the centre line",
+		},
+		{
+			name:    "empty grid",
+			grid:    nil,
+			side:    domain.DrivingSideRight,
+			wantErr: "grid is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InferLaneConfig(tt.grid, tt.side)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lanes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReverseLaneConfigSwitchesDrivingSide(t *testing.T) {
+	for _, grid := range [][][]int{horizontalRoad, verticalRoad} {
+		right, err := InferLaneConfig(grid, domain.DrivingSideRight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		left, err := InferLaneConfig(grid, domain.DrivingSideLeft)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if issues := CompareLaneConfigs(ReverseLaneConfig(right), left); len(issues) > 0 {
+			t.Errorf("reversed keep-right lanes differ from keep-left ones: %v", issues)
+		}
+	}
+}
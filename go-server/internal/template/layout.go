@@ -0,0 +1,223 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"gorm.io/datatypes"
+)
+
+// layout is the parsed geometry for one grid + lane config pair. It is built once and never
+// mutated, so it can be read from any goroutine without locking.
+type layout struct {
+	metaID    string // stable template ID that experiments list in their pool
+	grid      *Grid
+	geometry  TridentGeometry
+	drawnFor  domain.DrivingSide // rule the stored lane config was drawn for
+	junctions []SignalJunction
+	regions   []domain.Region
+	lanes     map[domain.DrivingSide]*laneVariant
+}
+
+// laneVariant is the lane config and spawn candidates for one driving side
+type laneVariant struct {
+	directions laneGrid
+	laneConfig domain.LaneConfigMap
+	signals    []domain.SignalHead // heads of every signalised junction, without a state
+	spawns     []SpawnCandidate
+	bySpawn    map[domain.TridentSpawn]int // index into spawns
+}
+
+// candidate returns the cached candidate for a spawn, nil if the spawn is not a valid one
+func (v *laneVariant) candidate(spawn domain.TridentSpawn) *SpawnCandidate {
+	if i, ok := v.bySpawn[spawn]; ok {
+		return &v.spawns[i]
+	}
+	return nil
+}
+
+// hasSpawn reports whether any candidate satisfies the criteria
+func (v *laneVariant) hasSpawn(criteria SpawnCriteria) bool {
+	for _, candidate := range v.spawns {
+		if criteria.Matches(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// variant returns the lanes for a driving side, falling back to the side the template was drawn for
+func (l *layout) variant(side domain.DrivingSide) *laneVariant {
+	if lanes, ok := l.lanes[side]; ok {
+		return lanes
+	}
+	return l.lanes[l.drawnFor]
+}
+
+// zones returns the trident zones for a spawn on one driving side, from the cache when the
+// spawn is a valid one
+func (l *layout) zones(lanes *laneVariant, spawn domain.TridentSpawn) domain.TridentZones {
+	if candidate := lanes.candidate(spawn); candidate != nil {
+		return candidate.Zones
+	}
+	return buildTridentZones(spawn, l.geometry, l.grid, lanes.directions)
+}
+
+// trajectories returns the swerve paths for a spawn, from the cache when the spawn is a valid one
+func (l *layout) trajectories(lanes *laneVariant, spawn domain.TridentSpawn) domain.Trajectories {
+	if candidate := lanes.candidate(spawn); candidate != nil {
+		return candidate.Trajectories
+	}
+	return buildTrajectories(spawn, l.zones(lanes, spawn), l.geometry, l.grid, lanes.directions)
+}
+
+// rear returns the drivable tile behind a vehicle facing orientation
+func (l *layout) rear(lanes *laneVariant, row, col int, orientation domain.Direction) (*domain.EnrichedCoordinate, error) {
+	// Rear is opposite of orientation
+	behind, ok := oppositeDirections[orientation]
+	if !ok {
+		return nil, errors.New("invalid orientation")
+	}
+
+	rearRow, rearCol, inBounds := l.grid.Neighbour(row, col, behind)
+	if !inBounds {
+		return nil, errors.New("rear coordinate out of bounds")
+	}
+
+	surface := l.grid.Surface(rearRow, rearCol)
+
+	if domain.RulesFor(surface).Spawn {
+		return &domain.EnrichedCoordinate{
+			Coordinate:  domain.Coordinate{Row: rearRow, Col: rearCol},
+			Surface:     surface,
+			Orientation: lanes.directions.at(l.grid, rearRow, rearCol),
+		}, nil
+	}
+
+	return nil, errors.New("no valid rear coordinate found")
+}
+
+// scene uses the lanes for the driving side, the given spawn or a random valid one, and the
+// trident zones and swerve paths from that spawn
+func (l *layout) scene(title string, side domain.DrivingSide, spawn *domain.TridentSpawn) (*Scene, error) {
+	lanes := l.variant(side)
+	if spawn == nil {
+		if len(lanes.spawns) == 0 {
+			return nil, errors.New("no trident spawns found")
+		}
+		spawn = &lanes.spawns[rand.Intn(len(lanes.spawns))].Spawn
+	}
+	zones := l.zones(lanes, *spawn)
+	trajectories := l.trajectories(lanes, *spawn)
+
+	return &Scene{
+		Title:        title,
+		Grid:         l.grid.Rows(),
+		LaneConfig:   lanes.laneConfig,
+		Spawn:        spawn,
+		Zones:        &zones,
+		Trajectories: &trajectories,
+		Signals:      lanes.signals,
+		Regions:      l.regions,
+	}, nil
+}
+
+// buildLayout parses a grid and lane config into dense lookup tables and pre-computes valid
+// spawns for both driving sides
+func buildLayout(gridData, laneConfigData, metaData datatypes.JSON, defaults TridentGeometry) (*layout, error) {
+	var rows [][]int
+	if err := json.Unmarshal(gridData, &rows); err != nil {
+		return nil, err
+	}
+	grid, err := NewGrid(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta TemplateMeta
+	if metaData != nil {
+		if err := json.Unmarshal(metaData, &meta); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &layout{
+		metaID:    meta.ID,
+		grid:      grid,
+		geometry:  tridentGeometryFor(meta, defaults),
+		drawnFor:  meta.DrivingSideOrDefault(),
+		junctions: meta.Signals,
+		regions:   meta.Regions,
+		lanes:     make(map[domain.DrivingSide]*laneVariant, 2),
+	}
+
+	// Parse lane config as drawn, then derive the other side by reversing every lane
+	var laneConfig domain.LaneConfigMap
+	if laneConfigData != nil {
+		if err := json.Unmarshal(laneConfigData, &laneConfig); err != nil {
+			return nil, err
+		}
+	}
+	l.lanes[l.drawnFor] = l.newLaneVariant(laneConfig)
+	l.lanes[l.drawnFor.Opposite()] = l.newLaneVariant(ReverseLaneConfig(laneConfig))
+
+	return l, nil
+}
+
+// newLaneVariant indexes a lane config and pre-computes the zones, swerve paths and quality
+// of every valid trident spawn
+func (l *layout) newLaneVariant(laneConfig domain.LaneConfigMap) *laneVariant {
+	lanes := &laneVariant{
+		directions: newLaneGrid(l.grid, laneConfig),
+		laneConfig: laneConfig,
+		bySpawn:    make(map[domain.TridentSpawn]int),
+	}
+	for _, junction := range l.junctions {
+		lanes.signals = append(lanes.signals, buildSignalHeads(junction, l.grid, lanes.directions)...)
+	}
+	for _, spawn := range computeValidSpawns(l.grid, laneConfig, l.geometry) {
+		zones := buildTridentZones(spawn, l.geometry, l.grid, lanes.directions)
+		trajectories := buildTrajectories(spawn, zones, l.geometry, l.grid, lanes.directions)
+		rear, _ := l.rear(lanes, spawn.Row, spawn.Col, spawn.Orientation)
+		lanes.bySpawn[spawn] = len(lanes.spawns)
+		candidate := newSpawnCandidate(spawn, zones, trajectories, rear)
+		candidate.Signal = signalFor(spawn, l.geometry, lanes.signals)
+		candidate.Regions = regionContext(l.regions, spawn, zones)
+		lanes.spawns = append(lanes.spawns, candidate)
+	}
+	return lanes
+}
+
+// computeValidSpawns finds spawn points whose trident zones are complete
+func computeValidSpawns(grid *Grid, laneConfig domain.LaneConfigMap, geometry TridentGeometry) []domain.TridentSpawn {
+	var validSpawns []domain.TridentSpawn
+
+	// Fixed direction order keeps the spawn list stable between loads
+	for _, direction := range []domain.Direction{domain.DirectionNorth, domain.DirectionSouth, domain.DirectionEast, domain.DirectionWest} {
+		for _, coord := range laneConfig[direction] {
+			// AV must spawn on a surface that allows it (plain lanes, not paint)
+			if !grid.InBounds(coord[0], coord[1]) || !domain.RulesFor(grid.Surface(coord[0], coord[1])).Spawn {
+				continue
+			}
+
+			spawn := domain.TridentSpawn{
+				Coordinate:  domain.Coordinate{Row: coord[0], Col: coord[1]},
+				Orientation: direction,
+			}
+
+			if isValidSpawn(spawn, grid, geometry) {
+				validSpawns = append(validSpawns, spawn)
+			}
+		}
+	}
+
+	return validSpawns
+}
+
+// isValidSpawn checks if spawn point produces complete trident zones
+func isValidSpawn(spawn domain.TridentSpawn, grid *Grid, geometry TridentGeometry) bool {
+	zones := buildTridentZones(spawn, geometry, grid, nil)
+	return tridentComplete(zones, geometry)
+}
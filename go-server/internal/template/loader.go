@@ -0,0 +1,373 @@
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/pkg/database"
+	"gorm.io/datatypes"
+)
+
+// DefaultTemplateDir is where map files live relative to the server working directory
+const DefaultTemplateDir = "data/templates"
+
+// ParseMeta extracts the stable meta fields from a template
+func ParseMeta(tpl ContextTemplate) (TemplateMeta, error) {
+	var meta TemplateMeta
+	if tpl.Meta == nil {
+		return meta, nil
+	}
+	err := json.Unmarshal(tpl.Meta, &meta)
+	return meta, err
+}
+
+// DrivingSideOrDefault returns the declared driving side, keep right if unset
+func (m TemplateMeta) DrivingSideOrDefault() domain.DrivingSide {
+	if m.DrivingSide == "" {
+		return domain.DrivingSideRight
+	}
+	return m.DrivingSide
+}
+
+// ReadTemplateDir parses every *.json file in dir into a ContextTemplate
+func ReadTemplateDir(dir string) ([]ContextTemplate, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no template files found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	templates := make([]ContextTemplate, 0, len(paths))
+	seen := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		tpl, err := ReadTemplateFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		meta, _ := ParseMeta(tpl)
+		if other, dup := seen[meta.ID]; dup {
+			return nil, fmt.Errorf("%s: meta.id %q already used by %s", path, meta.ID, other)
+		}
+		seen[meta.ID] = path
+
+		templates = append(templates, tpl)
+	}
+
+	return templates, nil
+}
+
+// ReadTemplateFile parses a single map file
+func ReadTemplateFile(path string) (ContextTemplate, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ContextTemplate{}, err
+	}
+
+	var file TemplateFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return ContextTemplate{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tpl := ContextTemplate{
+		Name:       file.Name,
+		Width:      file.Width,
+		Height:     file.Height,
+		GridData:   datatypes.JSON(file.GridData),
+		LaneConfig: datatypes.JSON(file.LaneConfig),
+		Meta:       datatypes.JSON(file.Meta),
+	}
+
+	meta, err := ParseMeta(tpl)
+	if err != nil {
+		return ContextTemplate{}, fmt.Errorf("%s: invalid meta: %w", path, err)
+	}
+	if meta.ID == "" {
+		return ContextTemplate{}, fmt.Errorf("%s: meta.id is required", path)
+	}
+	if tpl.Name == "" || tpl.GridData == nil {
+		return ContextTemplate{}, fmt.Errorf("%s: name and grid_data are required", path)
+	}
+
+	// Authors may omit lane_config and let it be derived from the road tiles
+	if len(file.LaneConfig) == 0 || string(file.LaneConfig) == "null" {
+		var grid [][]int
+		if err := json.Unmarshal(file.GridData, &grid); err != nil {
+			return ContextTemplate{}, fmt.Errorf("%s: invalid grid_data: %w", path, err)
+		}
+		laneConfig, err := InferLaneConfig(grid, meta.DrivingSideOrDefault())
+		if err != nil {
+			return ContextTemplate{}, fmt.Errorf("%s: lane inference failed: %w", path, err)
+		}
+		laneJSON, err := json.Marshal(laneConfig)
+		if err != nil {
+			return ContextTemplate{}, err
+		}
+		tpl.LaneConfig = datatypes.JSON(laneJSON)
+	}
+
+	return tpl, nil
+}
+
+// FormatTemplateFile renders a template in the map file layout: one grid row and one
+// lane direction per line so diffs stay readable
+func FormatTemplateFile(tpl ContextTemplate) ([]byte, error) {
+	var grid [][]int
+	if err := json.Unmarshal(tpl.GridData, &grid); err != nil {
+		return nil, fmt.Errorf("invalid grid_data: %w", err)
+	}
+	var laneConfig domain.LaneConfigMap
+	if err := json.Unmarshal(tpl.LaneConfig, &laneConfig); err != nil {
+		return nil, fmt.Errorf("invalid lane_config: %w", err)
+	}
+
+	var b strings.Builder
+	name, _ := json.Marshal(tpl.Name)
+	meta := []byte("{}")
+	if tpl.Meta != nil {
+		meta = tpl.Meta
+	}
+
+	fmt.Fprintf(&b, "{\n  \"name\": %s,\n  \"width\": %d,\n  \"height\": %d,\n  \"meta\": %s,\n", name, tpl.Width, tpl.Height, meta)
+
+	b.WriteString("  \"grid_data\": [\n")
+	for i, row := range grid {
+		cells, _ := json.Marshal(row)
+		b.WriteString("    " + strings.ReplaceAll(string(cells), ",", ", "))
+		if i < len(grid)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("  ],\n")
+
+	b.WriteString("  \"lane_config\": {\n")
+	directions := []domain.Direction{domain.DirectionWest, domain.DirectionEast, domain.DirectionSouth, domain.DirectionNorth}
+	for i, direction := range directions {
+		coords := laneConfig[direction]
+		if coords == nil {
+			coords = [][2]int{}
+		}
+		cells, _ := json.Marshal(coords)
+		fmt.Fprintf(&b, "    %q: %s", direction, cells)
+		if i < len(directions)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("  }\n}\n")
+
+	return []byte(b.String()), nil
+}
+
+// SyncTemplatesFromDir upserts every map file in dir keyed on Meta.id and reports what changed
+func SyncTemplatesFromDir(ctx context.Context, repo Repository, dir string) (*SyncReport, error) {
+	files, err := ReadTemplateDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing is written unless every file on disk is valid
+	if err := ValidateTemplates(files); err != nil {
+		return nil, err
+	}
+
+	existing, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byMetaID := make(map[string]ContextTemplate, len(existing))
+	for _, tpl := range existing {
+		if meta, err := ParseMeta(tpl); err == nil && meta.ID != "" {
+			byMetaID[meta.ID] = tpl
+		}
+	}
+
+	// Templates retired by an earlier sync come back when their file does
+	deleted, err := repo.GetAll(ctx, database.WithUnscoped(), database.WithFilter("deleted_at IS NOT NULL"))
+	if err != nil {
+		return nil, err
+	}
+	retired := make(map[string]ContextTemplate, len(deleted))
+	for _, tpl := range deleted {
+		if meta, err := ParseMeta(tpl); err == nil && meta.ID != "" {
+			retired[meta.ID] = tpl
+		}
+	}
+
+	report := &SyncReport{}
+	onDisk := make(map[string]bool, len(files))
+
+	for _, tpl := range files {
+		meta, _ := ParseMeta(tpl)
+		onDisk[meta.ID] = true
+
+		current, found := byMetaID[meta.ID]
+		if previous, ok := retired[meta.ID]; !found && ok {
+			if err := repo.Restore(ctx, &previous); err != nil {
+				return nil, fmt.Errorf("restore %s: %w", meta.ID, err)
+			}
+			report.Restored = append(report.Restored, meta.ID)
+			current, found = previous, true
+		}
+		if !found {
+			if err := repo.Create(ctx, &tpl); err != nil {
+				return nil, fmt.Errorf("create %s: %w", meta.ID, err)
+			}
+			if err := createVersion(ctx, repo, &tpl); err != nil {
+				return nil, fmt.Errorf("version %s: %w", meta.ID, err)
+			}
+			report.Created = append(report.Created, meta.ID)
+			continue
+		}
+
+		changes := diffTemplates(current, tpl)
+		if len(changes) == 0 {
+			// Templates stored before versioning get their first revision here
+			if current.CurrentVersionID == nil {
+				if err := createVersion(ctx, repo, &current); err != nil {
+					return nil, fmt.Errorf("version %s: %w", meta.ID, err)
+				}
+			}
+			report.Unchanged = append(report.Unchanged, meta.ID)
+			continue
+		}
+
+		current.Name = tpl.Name
+		current.Width = tpl.Width
+		current.Height = tpl.Height
+		current.GridData = tpl.GridData
+		current.LaneConfig = tpl.LaneConfig
+		current.Meta = tpl.Meta
+		if err := createVersion(ctx, repo, &current); err != nil {
+			return nil, fmt.Errorf("update %s: %w", meta.ID, err)
+		}
+		report.Updated = append(report.Updated, TemplateDiff{MetaID: meta.ID, Version: current.Version, Changes: changes})
+	}
+
+	// Templates whose file is gone are retired: soft-deleted so they are no longer picked,
+	// but scenarios that point at them still resolve
+	for metaID, tpl := range byMetaID {
+		if onDisk[metaID] {
+			continue
+		}
+		if err := repo.Delete(ctx, &tpl); err != nil {
+			return nil, fmt.Errorf("retire %s: %w", metaID, err)
+		}
+		report.Orphaned = append(report.Orphaned, metaID)
+	}
+	sort.Strings(report.Restored)
+	sort.Strings(report.Orphaned)
+
+	return report, nil
+}
+
+// createVersion snapshots tpl as a new immutable revision and points the template at it
+func createVersion(ctx context.Context, repo Repository, tpl *ContextTemplate) error {
+	version := &ContextTemplateVersion{
+		ContextTemplateID: tpl.Id,
+		Version:           tpl.Version + 1,
+		Name:              tpl.Name,
+		Width:             tpl.Width,
+		Height:            tpl.Height,
+		GridData:          tpl.GridData,
+		LaneConfig:        tpl.LaneConfig,
+		Meta:              tpl.Meta,
+	}
+	if err := repo.CreateVersion(ctx, version); err != nil {
+		return err
+	}
+
+	tpl.Version = version.Version
+	tpl.CurrentVersionID = &version.Id
+	return repo.Update(ctx, tpl)
+}
+
+// diffTemplates describes field-level differences between the stored and the on-disk template
+func diffTemplates(current, next ContextTemplate) []string {
+	var changes []string
+
+	if current.Name != next.Name {
+		changes = append(changes, fmt.Sprintf("name: %q -> %q", current.Name, next.Name))
+	}
+	if current.Width != next.Width || current.Height != next.Height {
+		changes = append(changes, fmt.Sprintf("size: %dx%d -> %dx%d", current.Width, current.Height, next.Width, next.Height))
+	}
+	if !jsonEqual(current.GridData, next.GridData) {
+		changes = append(changes, describeGridChange(current.GridData, next.GridData))
+	}
+	if !jsonEqual(current.LaneConfig, next.LaneConfig) {
+		changes = append(changes, "lane_config changed")
+	}
+	if !jsonEqual(current.Meta, next.Meta) {
+		changes = append(changes, "meta changed")
+	}
+
+	return changes
+}
+
+// describeGridChange counts changed tiles when both grids parse with the same shape
+func describeGridChange(before, after datatypes.JSON) string {
+	var a, b [][]int
+	if json.Unmarshal(before, &a) != nil || json.Unmarshal(after, &b) != nil || len(a) != len(b) {
+		return "grid_data changed"
+	}
+
+	changed := 0
+	for row := range a {
+		if len(a[row]) != len(b[row]) {
+			return "grid_data changed"
+		}
+		for col := range a[row] {
+			if a[row][col] != b[row][col] {
+				changed++
+			}
+		}
+	}
+	return fmt.Sprintf("grid_data: %d tile(s) changed", changed)
+}
+
+// jsonEqual compares two JSON documents semantically (jsonb does not preserve formatting)
+func jsonEqual(a, b datatypes.JSON) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// String renders the report for startup logs
+func (r *SyncReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "templates: %d created, %d updated, %d unchanged, %d restored, %d orphaned",
+		len(r.Created), len(r.Updated), len(r.Unchanged), len(r.Restored), len(r.Orphaned))
+
+	for _, id := range r.Created {
+		fmt.Fprintf(&b, "\n  + %s", id)
+	}
+	for _, id := range r.Restored {
+		fmt.Fprintf(&b, "\n  + %s (restored)", id)
+	}
+	for _, diff := range r.Updated {
+		fmt.Fprintf(&b, "\n  ~ %s (v%d): %s", diff.MetaID, diff.Version, strings.Join(diff.Changes, "; "))
+	}
+	for _, id := range r.Orphaned {
+		fmt.Fprintf(&b, "\n  - %s (no file on disk, retired)", id)
+	}
+	return b.String()
+}
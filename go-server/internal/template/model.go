@@ -4,3 +4,4 @@ import "github.com/direwen/go-server/internal/shared/models"
 
 // Re-export from shared models for backward compatibility
 type ContextTemplate = models.ContextTemplate
+type ContextTemplateVersion = models.ContextTemplateVersion
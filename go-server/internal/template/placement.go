@@ -0,0 +1,57 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// Placement is where an entity ends up once its whole footprint is on the map
+type Placement struct {
+	Row         int                 `json:"row"` // front tile
+	Col         int                 `json:"col"`
+	Orientation domain.Direction    `json:"orientation"`
+	Cells       []domain.Coordinate `json:"cells"`
+}
+
+// place fits an entity's footprint around the tile it was given. Single-tile entities stay
+// where they are. Larger ones take the lane heading when they have none, and slide forward
+// along it (so the given tile is still covered) until every tile of the footprint is on the
+// carriageway.
+func (l *layout) place(lanes *laneVariant, entityType string, row, col int, orientation domain.Direction) (Placement, error) {
+	if !domain.IsMultiTile(entityType) {
+		return Placement{
+			Row:         row,
+			Col:         col,
+			Orientation: orientation,
+			Cells:       []domain.Coordinate{{Row: row, Col: col}},
+		}, nil
+	}
+
+	if _, ok := directionSteps[orientation]; !ok {
+		orientation = lanes.directions.at(l.grid, row, col)
+	}
+	step, ok := directionSteps[orientation]
+	if !ok {
+		return Placement{}, fmt.Errorf("%s at [%d,%d] has no heading to lie along", entityType, row, col)
+	}
+
+	for shift := 0; shift < domain.FootprintOf(entityType).Length; shift++ {
+		frontRow, frontCol := row+shift*step[0], col+shift*step[1]
+		cells := domain.FootprintCells(entityType, frontRow, frontCol, orientation)
+		if l.fits(cells) {
+			return Placement{Row: frontRow, Col: frontCol, Orientation: orientation, Cells: cells}, nil
+		}
+	}
+	return Placement{}, fmt.Errorf("%s does not fit on the road at [%d,%d] facing %s", entityType, row, col, orientation)
+}
+
+// fits reports whether every cell is on a road surface
+func (l *layout) fits(cells []domain.Coordinate) bool {
+	for _, cell := range cells {
+		if !l.grid.InBounds(cell.Row, cell.Col) || !domain.RulesFor(l.grid.Surface(cell.Row, cell.Col)).Road {
+			return false
+		}
+	}
+	return true
+}
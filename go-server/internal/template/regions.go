@@ -0,0 +1,68 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// validateRegion checks a region from meta.regions against the grid
+func validateRegion(region domain.Region, grid *Grid) error {
+	known := false
+	for _, kind := range domain.RegionKinds {
+		known = known || region.Kind == kind
+	}
+	if !known {
+		return fmt.Errorf("unknown region kind %q", region.Kind)
+	}
+	if region.Top > region.Bottom || region.Left > region.Right {
+		return fmt.Errorf("%s region: top/left must not be past bottom/right", region.Kind)
+	}
+	if !grid.InBounds(region.Top, region.Left) || !grid.InBounds(region.Bottom, region.Right) {
+		return fmt.Errorf("%s region is off the grid", region.Kind)
+	}
+	if (region.Kind == domain.RegionSpeedLimit) != (region.SpeedLimit > 0) {
+		return fmt.Errorf("%s region: speed_limit is required on speed_limit regions and only there", region.Kind)
+	}
+	return nil
+}
+
+// regionContext reads the regions at the spawn and its zones. The speed limit is the one
+// posted at the AV (the lowest if regions overlap); the other layers count when any of those
+// tiles lies inside them.
+func regionContext(regions []domain.Region, spawn domain.TridentSpawn, zones domain.TridentZones) domain.RegionContext {
+	tiles := []domain.Coordinate{spawn.Coordinate}
+	for _, zone := range []domain.TridentZone{zones.ZoneA, zones.ZoneB, zones.ZoneC} {
+		for _, coord := range zone.Coordinates {
+			tiles = append(tiles, coord.Coordinate)
+		}
+	}
+
+	var summary domain.RegionContext
+	for _, region := range regions {
+		if region.Kind == domain.RegionSpeedLimit {
+			if region.Contains(spawn.Row, spawn.Col) && (summary.SpeedLimit == 0 || region.SpeedLimit < summary.SpeedLimit) {
+				summary.SpeedLimit = region.SpeedLimit
+			}
+			continue
+		}
+
+		covered := false
+		for _, tile := range tiles {
+			if region.Contains(tile.Row, tile.Col) {
+				covered = true
+				break
+			}
+		}
+		switch {
+		case !covered:
+		case region.Kind == domain.RegionSchoolZone:
+			summary.SchoolZone = true
+		case region.Kind == domain.RegionPedestrianPriority:
+			summary.PedestrianPriority = true
+		case region.Kind == domain.RegionConstruction:
+			summary.Construction = true
+		}
+	}
+	return summary
+}
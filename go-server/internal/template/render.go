@@ -0,0 +1,545 @@
+package template
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// RenderFormat selects the renderer output
+type RenderFormat string
+
+const (
+	RenderASCII RenderFormat = "ascii" // terminals
+	RenderSVG   RenderFormat = "svg"   // papers and slides
+)
+
+// Scene is a template grid plus everything drawn on top of it
+type Scene struct {
+	Title        string
+	Grid         [][]int
+	LaneConfig   domain.LaneConfigMap
+	Spawn        *domain.TridentSpawn
+	Zones        *domain.TridentZones
+	Trajectories *domain.Trajectories
+	Signals      []domain.SignalHead
+	Regions      []domain.Region
+	Entities     []domain.RawEntity
+}
+
+// Render draws the scene in the requested format
+func (sc Scene) Render(format RenderFormat) (string, error) {
+	switch format {
+	case RenderASCII, "":
+		return sc.ASCII(), nil
+	case RenderSVG:
+		return sc.SVG(), nil
+	default:
+		return "", fmt.Errorf("unknown render format %q", format)
+	}
+}
+
+// ParseSpawn reads a spawn written as "row,col,direction", e.g. "4,19,W"
+func ParseSpawn(value string) (*domain.TridentSpawn, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("spawn must be row,col,direction, got %q", value)
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid spawn row: %w", err)
+	}
+	col, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid spawn col: %w", err)
+	}
+	direction := domain.Direction(strings.ToUpper(strings.TrimSpace(parts[2])))
+	if _, ok := laneArrows[direction]; !ok {
+		return nil, fmt.Errorf("invalid spawn direction %q", parts[2])
+	}
+	return &domain.TridentSpawn{Coordinate: domain.Coordinate{Row: row, Col: col}, Orientation: direction}, nil
+}
+
+var laneArrows = map[domain.Direction]string{
+	domain.DirectionNorth: "↑",
+	domain.DirectionSouth: "↓",
+	domain.DirectionEast:  "→",
+	domain.DirectionWest:  "←",
+}
+
+// Zone letters and colours match the frontend highlights (maintain, swerve left, swerve right)
+var zoneStyles = []struct {
+	label, option, colour string
+}{
+	{"A", "maintain", "#facc15"},
+	{"B", "swerve left", "#60a5fa"},
+	{"C", "swerve right", "#f87171"},
+}
+
+// paths returns the trajectories in zone order, nil if the scene has none
+func (sc Scene) paths() []domain.Trajectory {
+	if sc.Trajectories == nil {
+		return nil
+	}
+	return []domain.Trajectory{sc.Trajectories.Maintain, sc.Trajectories.SwerveLeft, sc.Trajectories.SwerveRight}
+}
+
+// overlay gathers per-cell lanes, zones and entities so both renderers draw the same thing
+type overlay struct {
+	height, width int
+	lanes         map[[2]int][]domain.Direction
+	zones         map[[2]int]int // index into zoneStyles
+	entities      map[[2]int][]domain.RawEntity
+	bodies        map[[2]int]string // entity type trailing behind a multi-tile entity's front
+}
+
+func (sc Scene) overlay() overlay {
+	o := overlay{
+		height:   len(sc.Grid),
+		lanes:    make(map[[2]int][]domain.Direction),
+		zones:    make(map[[2]int]int),
+		entities: make(map[[2]int][]domain.RawEntity),
+		bodies:   make(map[[2]int]string),
+	}
+	if o.height > 0 {
+		o.width = len(sc.Grid[0])
+	}
+
+	for _, direction := range []domain.Direction{domain.DirectionNorth, domain.DirectionSouth, domain.DirectionEast, domain.DirectionWest} {
+		for _, coord := range sc.LaneConfig[direction] {
+			o.lanes[coord] = append(o.lanes[coord], direction)
+		}
+	}
+
+	if sc.Zones != nil {
+		for i, zone := range []domain.TridentZone{sc.Zones.ZoneA, sc.Zones.ZoneB, sc.Zones.ZoneC} {
+			for _, coord := range zone.Coordinates {
+				o.zones[[2]int{coord.Row, coord.Col}] = i
+			}
+		}
+	}
+
+	hasEgo := false
+	for _, entity := range sc.Entities {
+		key := [2]int{entity.Row, entity.Col}
+		o.entities[key] = append(o.entities[key], entity)
+		hasEgo = hasEgo || entity.Metadata.IsEgo
+		for _, cell := range domain.FootprintCells(entity.Type, entity.Row, entity.Col, domain.Direction(entity.Metadata.Orientation))[1:] {
+			o.bodies[[2]int{cell.Row, cell.Col}] = entity.Type
+		}
+	}
+	// A bare spawn is drawn as the ego AV so templates can be checked before any scenario exists
+	if sc.Spawn != nil && !hasEgo {
+		key := [2]int{sc.Spawn.Row, sc.Spawn.Col}
+		o.entities[key] = append(o.entities[key], domain.RawEntity{
+			Type: "vehicle_av",
+			Row:  sc.Spawn.Row,
+			Col:  sc.Spawn.Col,
+			Metadata: domain.EntityMeta{
+				IsEgo:       true,
+				Orientation: string(sc.Spawn.Orientation),
+			},
+		})
+	}
+	return o
+}
+
+// entityCells returns the occupied cells in reading order
+func (o overlay) entityCells() [][2]int {
+	keys := make([][2]int, 0, len(o.entities))
+	for key := range o.entities {
+		keys = append(keys, key)
+	}
+	return readingOrder(keys)
+}
+
+// bodyCells returns the cells covered by the rest of a multi-tile entity, in reading order
+func (o overlay) bodyCells() [][2]int {
+	keys := make([][2]int, 0, len(o.bodies))
+	for key := range o.bodies {
+		keys = append(keys, key)
+	}
+	return readingOrder(keys)
+}
+
+func readingOrder(keys [][2]int) [][2]int {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// entityEmoji returns the registry emoji, or "?" for types the registry does not know
+func entityEmoji(entityType string) string {
+	if info, ok := domain.EntityRegistry[entityType]; ok && info.Emoji != "" {
+		return info.Emoji
+	}
+	return "?"
+}
+
+// tileGlyph is the two-column ASCII cell for a tile, picked from its registry surface
+func tileGlyph(tileID int) string {
+	tile, ok := domain.TileRegistry[tileID]
+	if !ok {
+		return "??"
+	}
+	switch tile.Definition.SurfaceType {
+	case domain.SurfaceBuilding:
+		return "██"
+	case domain.SurfaceWalkable:
+		return "░░"
+	case domain.SurfaceRestricted:
+		if strings.Contains(tile.Name, "Vertical") {
+			return "│ "
+		}
+		return "──"
+	case domain.SurfaceDrivable:
+		if allowsPedestrians(tile) {
+			return "≡≡"
+		}
+		return "  "
+	case domain.SurfaceBikeLane:
+		return "··"
+	case domain.SurfaceVerge:
+		return `""`
+	case domain.SurfaceParking:
+		return "::"
+	case domain.SurfaceTramTrack:
+		return "=="
+	case domain.SurfaceMedian:
+		return "▓▓"
+	}
+	return "??"
+}
+
+// allowsPedestrians reports shared tiles such as crosswalks
+func allowsPedestrians(tile domain.Tile) bool {
+	for _, usage := range tile.Definition.DefaultUsage {
+		if usage == domain.UsagePedestrian {
+			return true
+		}
+	}
+	return false
+}
+
+// ASCII draws the scene for a terminal. Every cell is two columns wide so entity emoji line up.
+func (sc Scene) ASCII() string {
+	o := sc.overlay()
+	var b strings.Builder
+
+	if sc.Title != "" {
+		fmt.Fprintf(&b, "%s (%dx%d)\n", sc.Title, o.width, o.height)
+	}
+
+	// Column ruler (units digit) and row numbers make coordinates easy to read off
+	b.WriteString("    ")
+	for col := 0; col < o.width; col++ {
+		fmt.Fprintf(&b, "%d ", col%10)
+	}
+	b.WriteString("\n")
+
+	for row, cols := range sc.Grid {
+		fmt.Fprintf(&b, "%3d ", row)
+		for col, tileID := range cols {
+			key := [2]int{row, col}
+			switch {
+			case len(o.entities[key]) > 0:
+				b.WriteString(entityEmoji(o.entities[key][0].Type))
+			case o.bodies[key] != "":
+				b.WriteString(entityEmoji(o.bodies[key]))
+			case hasZone(o.zones, key):
+				b.WriteString(zoneStyles[o.zones[key]].label)
+				if lanes := o.lanes[key]; len(lanes) > 0 {
+					b.WriteString(laneArrows[lanes[0]])
+				} else {
+					b.WriteString(" ")
+				}
+			case len(o.lanes[key]) > 0 && domain.RulesFor(domain.TileRegistry[tileID].Definition.SurfaceType).Spawn:
+				b.WriteString(laneArrows[o.lanes[key][0]] + " ")
+			default:
+				b.WriteString(tileGlyph(tileID))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(sc.legend())
+	return b.String()
+}
+
+func hasZone(zones map[[2]int]int, key [2]int) bool {
+	_, ok := zones[key]
+	return ok
+}
+
+// legend lists the spawn, zone sizes and every entity with its coordinate
+func (sc Scene) legend() string {
+	o := sc.overlay()
+	var b strings.Builder
+
+	b.WriteString("\n██ building  ░░ walkable  ≡≡ crosswalk  ── │ marking  ←→↑↓ lanes\n")
+	b.WriteString("·· bike lane  \"\" verge  :: parking  == tram track  ▓▓ median\n")
+	if sc.Spawn != nil {
+		fmt.Fprintf(&b, "Ego %s at [%d,%d] facing %s\n", entityEmoji("vehicle_av"), sc.Spawn.Row, sc.Spawn.Col, sc.Spawn.Orientation)
+	}
+	if sc.Zones != nil {
+		fmt.Fprintf(&b, "Zones: A (maintain) %d, B (swerve left) %d, C (swerve right) %d tile(s)\n",
+			len(sc.Zones.ZoneA.Coordinates), len(sc.Zones.ZoneB.Coordinates), len(sc.Zones.ZoneC.Coordinates))
+	}
+	for i, path := range sc.paths() {
+		fmt.Fprintf(&b, "Path %s (%s): ", zoneStyles[i].label, zoneStyles[i].option)
+		if !path.Feasible {
+			fmt.Fprintf(&b, "infeasible, %s\n", path.Reason)
+			continue
+		}
+		var notes []string
+		if path.LeavesRoad {
+			notes = append(notes, "leaves road")
+		}
+		if path.EntersOncoming {
+			notes = append(notes, "enters oncoming lane")
+		}
+		fmt.Fprintf(&b, "%d tile(s)", len(path.Tiles))
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, ", %s", strings.Join(notes, ", "))
+		}
+		b.WriteString("\n")
+	}
+	for _, region := range sc.Regions {
+		fmt.Fprintf(&b, "Region %s [%d,%d]-[%d,%d]", region.Kind, region.Top, region.Left, region.Bottom, region.Right)
+		if region.SpeedLimit > 0 {
+			fmt.Fprintf(&b, " %d km/h", region.SpeedLimit)
+		}
+		b.WriteString("\n")
+	}
+	for _, head := range sc.Signals {
+		if head.Kind != domain.SignalKindVehicle {
+			continue
+		}
+		state := head.State
+		if state == "" {
+			state = "-"
+		}
+		fmt.Fprintf(&b, "Signal %s [%d,%d] %s: %s\n", head.Junction, head.Row, head.Col, head.Direction, state)
+	}
+
+	for _, key := range o.entityCells() {
+		for _, entity := range o.entities[key] {
+			var flags []string
+			if entity.Metadata.IsEgo {
+				flags = append(flags, "ego")
+			}
+			if entity.Metadata.IsStar {
+				flags = append(flags, "star")
+			}
+			if entity.Metadata.IsViolation {
+				flags = append(flags, "violation")
+			}
+			if entity.Metadata.Count > 1 {
+				flags = append(flags, fmt.Sprintf("x%d", entity.Metadata.Count))
+			}
+			fmt.Fprintf(&b, "  %s %-14s [%d,%d] %s", entityEmoji(entity.Type), entity.Type, entity.Row, entity.Col, entity.Metadata.Orientation)
+			if len(flags) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(flags, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Signal head colours by state; heads without a state are grey
+var svgSignalFill = map[domain.SignalPhase]string{
+	domain.SignalPhaseGreen:    "#22c55e",
+	domain.SignalPhaseAmber:    "#f59e0b",
+	domain.SignalPhaseRed:      "#ef4444",
+	domain.SignalPhaseWalk:     "#22c55e",
+	domain.SignalPhaseDontWalk: "#ef4444",
+}
+
+// Region outline colours
+var svgRegionStroke = map[domain.RegionKind]string{
+	domain.RegionSpeedLimit:         "#60a5fa",
+	domain.RegionSchoolZone:         "#facc15",
+	domain.RegionPedestrianPriority: "#c084fc",
+	domain.RegionConstruction:       "#fb923c",
+}
+
+// SVG cell size in pixels
+const svgCell = 32
+
+// Surface colours follow the participant view (Tailwind gray/yellow palette)
+var svgSurfaceFill = map[domain.SurfaceType]string{
+	domain.SurfaceBuilding:   "#4b5563",
+	domain.SurfaceWalkable:   "#e5e7eb",
+	domain.SurfaceDrivable:   "#1f2937",
+	domain.SurfaceRestricted: "#1f2937",
+	domain.SurfaceBikeLane:   "#166534",
+	domain.SurfaceVerge:      "#4ade80",
+	domain.SurfaceParking:    "#374151",
+	domain.SurfaceTramTrack:  "#1f2937",
+	domain.SurfaceMedian:     "#9ca3af",
+}
+
+// SVG draws the scene as a standalone SVG document
+func (sc Scene) SVG() string {
+	o := sc.overlay()
+	titleHeight := 0
+	if sc.Title != "" {
+		titleHeight = svgCell
+	}
+	width, height := o.width*svgCell, o.height*svgCell+titleHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", width, height, width, height)
+	if sc.Title != "" {
+		fmt.Fprintf(&b, `<text x="4" y="%d" font-size="18">%s</text>`+"\n", svgCell-10, html.EscapeString(sc.Title))
+	}
+	fmt.Fprintf(&b, `<g transform="translate(0,%d)">`+"\n", titleHeight)
+
+	for row, cols := range sc.Grid {
+		for col, tileID := range cols {
+			x, y := col*svgCell, row*svgCell
+			tile := domain.TileRegistry[tileID]
+			fill, ok := svgSurfaceFill[tile.Definition.SurfaceType]
+			if !ok {
+				fill = "#ff00ff" // unknown tile
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>[%d,%d] %s</title></rect>`+"\n",
+				x, y, svgCell, svgCell, fill, row, col, html.EscapeString(tile.Name))
+
+			switch {
+			case tile.Definition.SurfaceType == domain.SurfaceRestricted:
+				// Centre-line paint
+				if strings.Contains(tile.Name, "Vertical") {
+					fmt.Fprintf(&b, `<rect x="%d" y="%d" width="4" height="%d" fill="#facc15"/>`+"\n", x+svgCell/2-2, y, svgCell)
+				} else {
+					fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="4" fill="#facc15"/>`+"\n", x, y+svgCell/2-2, svgCell)
+				}
+			case tile.Definition.SurfaceType == domain.SurfaceTramTrack:
+				// Two rails along the track
+				for _, offset := range []int{svgCell/2 - 6, svgCell/2 + 4} {
+					if strings.Contains(tile.Name, "Vertical") {
+						fmt.Fprintf(&b, `<rect x="%d" y="%d" width="2" height="%d" fill="#9ca3af"/>`+"\n", x+offset, y, svgCell)
+					} else {
+						fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="2" fill="#9ca3af"/>`+"\n", x, y+offset, svgCell)
+					}
+				}
+			case tile.Definition.SurfaceType == domain.SurfaceDrivable && allowsPedestrians(tile):
+				// Zebra stripes across the direction of travel
+				for i := 0; i < 4; i++ {
+					if strings.Contains(tile.Name, "Vertical") {
+						fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="4" fill="#f9fafb"/>`+"\n", x, y+i*8+2, svgCell)
+					} else {
+						fmt.Fprintf(&b, `<rect x="%d" y="%d" width="4" height="%d" fill="#f9fafb"/>`+"\n", x+i*8+2, y, svgCell)
+					}
+				}
+			}
+
+			key := [2]int{row, col}
+			if zone, ok := o.zones[key]; ok {
+				style := zoneStyles[zone]
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" fill-opacity="0.4" stroke="%s" stroke-width="2"/>`+"\n",
+					x+1, y+1, svgCell-2, svgCell-2, style.colour, style.colour)
+				fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="9" fill="#111827">%s</text>`+"\n", x+3, y+10, style.label)
+			}
+			if lanes := o.lanes[key]; len(lanes) > 0 && len(o.entities[key]) == 0 && o.bodies[key] == "" {
+				arrows := ""
+				for _, direction := range lanes {
+					arrows += laneArrows[direction]
+				}
+				fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="14" fill="#9ca3af" fill-opacity="0.6" text-anchor="middle">%s</text>`+"\n",
+					x+svgCell/2, y+svgCell/2+5, arrows)
+			}
+		}
+	}
+
+	// Paths through tile centres; dashed when infeasible
+	for i, path := range sc.paths() {
+		if len(path.Points) < 2 {
+			continue
+		}
+		points := make([]string, len(path.Points))
+		for j, p := range path.Points {
+			points[j] = fmt.Sprintf("%.1f,%.1f", (p.Col+0.5)*svgCell, (p.Row+0.5)*svgCell)
+		}
+		dash := ""
+		if !path.Feasible {
+			dash = ` stroke-dasharray="4 4"`
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="3" stroke-linejoin="round"%s><title>%s</title></polyline>`+"\n",
+			strings.Join(points, " "), zoneStyles[i].colour, dash, zoneStyles[i].option)
+	}
+
+	// Region outlines, dashed, labelled in the top-left corner
+	for _, region := range sc.Regions {
+		label := string(region.Kind)
+		if region.SpeedLimit > 0 {
+			label = fmt.Sprintf("%d km/h", region.SpeedLimit)
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="%s" stroke-width="2" stroke-dasharray="6 3"><title>%s</title></rect>`+"\n",
+			region.Left*svgCell+2, region.Top*svgCell+2, (region.Right-region.Left+1)*svgCell-4, (region.Bottom-region.Top+1)*svgCell-4,
+			svgRegionStroke[region.Kind], region.Kind)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="9" fill="%s">%s</text>`+"\n", region.Left*svgCell+5, region.Top*svgCell+12, svgRegionStroke[region.Kind], label)
+	}
+
+	// Signal heads in the tile corner: round for traffic, square for pedestrians
+	for _, head := range sc.Signals {
+		x, y := head.Col*svgCell, head.Row*svgCell
+		fill, ok := svgSignalFill[head.State]
+		if !ok {
+			fill = "#9ca3af"
+		}
+		title := fmt.Sprintf("%s %s signal [%d,%d] %s", head.Junction, head.Kind, head.Row, head.Col, head.State)
+		if head.Kind == domain.SignalKindVehicle {
+			fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="4" fill="%s" stroke="#111827"><title>%s</title></circle>`+"\n", x+svgCell-6, y+6, fill, title)
+		} else {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="7" height="7" fill="%s" stroke="#111827"><title>%s</title></rect>`+"\n", x+svgCell-10, y+2, fill, title)
+		}
+	}
+
+	// Entities last so nothing covers them; the body of a long vehicle is drawn faded
+	for _, key := range o.bodyCells() {
+		if len(o.entities[key]) > 0 {
+			continue
+		}
+		entityType := o.bodies[key]
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="20" text-anchor="middle" opacity="0.5"><title>%s</title>%s</text>`+"\n",
+			key[1]*svgCell+svgCell/2, key[0]*svgCell+svgCell-8, html.EscapeString(entityType), entityEmoji(entityType))
+	}
+	for _, key := range o.entityCells() {
+		entities := o.entities[key]
+		x, y := key[1]*svgCell, key[0]*svgCell
+		for i, entity := range entities {
+			label := entity.Type
+			if entity.Metadata.IsEgo {
+				label += " (ego)"
+			}
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="20" text-anchor="middle"><title>%s [%d,%d] %s</title>%s</text>`+"\n",
+				x+svgCell/2+i*4, y+svgCell-8, html.EscapeString(label), entity.Row, entity.Col, entity.Metadata.Orientation, entityEmoji(entity.Type))
+		}
+		if egoFacing := egoOrientation(entities); egoFacing != "" {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="10" fill="#f9fafb">%s</text>`+"\n", x+svgCell-10, y+10, laneArrows[egoFacing])
+		}
+	}
+
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
+
+// egoOrientation returns the facing of the ego AV among the entities in a cell, if any
+func egoOrientation(entities []domain.RawEntity) domain.Direction {
+	for _, entity := range entities {
+		if entity.Metadata.IsEgo {
+			return domain.Direction(entity.Metadata.Orientation)
+		}
+	}
+	return ""
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 
 	"github.com/direwen/go-server/pkg/database"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
 	Create(ctx context.Context, template *ContextTemplate) error
-	FirstOrCreate(ctx context.Context, template *ContextTemplate) error
+	Update(ctx context.Context, template *ContextTemplate) error
+	Delete(ctx context.Context, template *ContextTemplate) error
+	Restore(ctx context.Context, template *ContextTemplate) error
 	GetAll(ctx context.Context, opts ...database.QueryOption) ([]ContextTemplate, error)
+	CreateVersion(ctx context.Context, version *ContextTemplateVersion) error
+	GetVersionByID(ctx context.Context, id uuid.UUID, opts ...database.QueryOption) (*ContextTemplateVersion, error)
 }
 
 type repository struct {
@@ -25,8 +30,22 @@ func (r *repository) Create(ctx context.Context, template *ContextTemplate) erro
 	return database.GetDB(ctx, r.db).WithContext(ctx).Create(template).Error
 }
 
-func (r *repository) FirstOrCreate(ctx context.Context, template *ContextTemplate) error {
-	return database.GetDB(ctx, r.db).WithContext(ctx).FirstOrCreate(template, ContextTemplate{Name: template.Name}).Error
+func (r *repository) Update(ctx context.Context, template *ContextTemplate) error {
+	return database.GetDB(ctx, r.db).WithContext(ctx).Save(template).Error
+}
+
+// Delete soft-deletes a template; scenarios that point at it can still load it unscoped
+func (r *repository) Delete(ctx context.Context, template *ContextTemplate) error {
+	return database.GetDB(ctx, r.db).WithContext(ctx).Delete(template).Error
+}
+
+// Restore brings a soft-deleted template back
+func (r *repository) Restore(ctx context.Context, template *ContextTemplate) error {
+	err := database.GetDB(ctx, r.db).WithContext(ctx).Unscoped().Model(template).Update("deleted_at", nil).Error
+	if err == nil {
+		template.DeletedAt = gorm.DeletedAt{}
+	}
+	return err
 }
 
 func (r *repository) GetAll(ctx context.Context, opts ...database.QueryOption) ([]ContextTemplate, error) {
@@ -36,3 +55,15 @@ func (r *repository) GetAll(ctx context.Context, opts ...database.QueryOption) (
 	err := db.Find(&templates).Error
 	return templates, err
 }
+
+func (r *repository) CreateVersion(ctx context.Context, version *ContextTemplateVersion) error {
+	return database.GetDB(ctx, r.db).WithContext(ctx).Create(version).Error
+}
+
+func (r *repository) GetVersionByID(ctx context.Context, id uuid.UUID, opts ...database.QueryOption) (*ContextTemplateVersion, error) {
+	var version ContextTemplateVersion
+	db := database.GetDB(ctx, r.db).WithContext(ctx).Model(&ContextTemplateVersion{}).Where("id = ?", id)
+	db = database.ApplyOptions(db, opts...)
+	err := db.First(&version).Error
+	return &version, err
+}
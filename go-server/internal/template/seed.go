@@ -1,108 +0,0 @@
-package template
-
-import (
-	"context"
-	"log"
-
-	"gorm.io/datatypes"
-)
-
-var templates = []ContextTemplate{
-	// 1. URBAN SCHOOL ZONE (Straight Road)
-	// Layout: Rows 3-4 (Top) and Rows 6-7 (Bottom) are roads. Row 5 is center line.
-	// Logic: Keep Right -> Top goes West, Bottom goes East.
-	{
-		Name:   "Urban School Zone (Straight)",
-		Width:  20,
-		Height: 11,
-		GridData: datatypes.JSON([]byte(`[
-            [0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0],
-            [1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1],
-            [3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3],
-            [9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9],
-            [9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9],
-            [12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12, 12],
-            [9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9],
-            [9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9],
-            [4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4],
-            [2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2],
-            [0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0]
-        ]`)),
-		LaneConfig: datatypes.JSON([]byte(`{
-            "W": [[3,0],[3,1],[3,2],[3,3],[3,4],[3,5],[3,6],[3,7],[3,8],[3,9],[3,10],[3,11],[3,12],[3,13],[3,14],[3,15],[3,16],[3,17],[3,18],[3,19],[4,0],[4,1],[4,2],[4,3],[4,4],[4,5],[4,6],[4,7],[4,8],[4,9],[4,10],[4,11],[4,12],[4,13],[4,14],[4,15],[4,16],[4,17],[4,18],[4,19]],
-            "E": [[6,0],[6,1],[6,2],[6,3],[6,4],[6,5],[6,6],[6,7],[6,8],[6,9],[6,10],[6,11],[6,12],[6,13],[6,14],[6,15],[6,16],[6,17],[6,18],[6,19],[7,0],[7,1],[7,2],[7,3],[7,4],[7,5],[7,6],[7,7],[7,8],[7,9],[7,10],[7,11],[7,12],[7,13],[7,14],[7,15],[7,16],[7,17],[7,18],[7,19]],
-            "N": [],
-            "S": []
-        }`)),
-		Meta: datatypes.JSON([]byte(`{"id": "TPL_001_SCHOOL_ZONE"}`)),
-	},
-
-	// 2. 4-WAY INTERSECTION
-	// Layout: Horizontal Road at Row 4 (West) & 6 (East). Vertical Road at Col 9 (South) & 11 (North).
-	// Logic: Full span for all directions to allow through-traffic.
-	{
-		Name:   "4-Way Urban Intersection",
-		Width:  20,
-		Height: 11,
-		GridData: datatypes.JSON([]byte(`[
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [1, 1, 1, 1, 1, 1, 1, 1, 19, 15, 15, 15, 20, 1, 1, 1, 1, 1, 1, 1],
-            [3, 3, 3, 3, 3, 3, 3, 3, 5, 11, 17, 11, 7, 3, 3, 3, 3, 3, 3, 3],
-            [9, 9, 9, 9, 9, 9, 9, 16, 11, 11, 11, 11, 11, 16, 9, 9, 9, 9, 9, 9],
-            [18, 18, 18, 18, 18, 18, 18, 16, 18, 11, 11, 11, 18, 16, 18, 18, 18, 18, 18, 18],
-            [9, 9, 9, 9, 9, 9, 9, 16, 11, 11, 11, 11, 11, 16, 9, 9, 9, 9, 9, 9],
-            [4, 4, 4, 4, 4, 4, 4, 4, 6, 11, 17, 11, 8, 4, 4, 4, 4, 4, 4, 4],
-            [2, 2, 2, 2, 2, 2, 2, 2, 19, 15, 15, 15, 20, 2, 2, 2, 2, 2, 2, 2],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0]
-        ]`)),
-		LaneConfig: datatypes.JSON([]byte(`{
-            "W": [[4,0],[4,1],[4,2],[4,3],[4,4],[4,5],[4,6],[4,7],[4,8],[4,9],[4,10],[4,11],[4,12],[4,13],[4,14],[4,15],[4,16],[4,17],[4,18],[4,19]],
-            "E": [[6,0],[6,1],[6,2],[6,3],[6,4],[6,5],[6,6],[6,7],[6,8],[6,9],[6,10],[6,11],[6,12],[6,13],[6,14],[6,15],[6,16],[6,17],[6,18],[6,19]],
-            "S": [[0,9],[1,9],[2,9],[3,9],[4,9],[5,9],[6,9],[7,9],[8,9],[9,9],[10,9]],
-            "N": [[0,11],[1,11],[2,11],[3,11],[4,11],[5,11],[6,11],[7,11],[8,11],[9,11],[10,11]]
-        }`)),
-		Meta: datatypes.JSON([]byte(`{"id": "TPL_002_INTERSECTION"}`)),
-	},
-
-	// 3. URBAN T-JUNCTION
-	// Layout: Inverted T. Stem at Top (Cols 9,11). Bar at Bottom (Rows 7,9).
-	// Logic: Vertical roads stop at the bottom intersection. Horizontal roads span full width.
-	{
-		Name:   "Urban T-Junction",
-		Width:  20,
-		Height: 11,
-		GridData: datatypes.JSON([]byte(`[
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 10, 17, 10, 20, 0, 0, 0, 0, 0, 0, 0],
-            [0, 0, 0, 0, 0, 0, 0, 0, 19, 15, 15, 15, 20, 0, 0, 0, 0, 0, 0, 0],
-            [2, 2, 2, 2, 2, 2, 2, 8, 19, 10, 17, 10, 20, 2, 2, 2, 2, 2, 2, 2],
-            [4, 4, 4, 4, 4, 4, 4, 4, 5, 10, 17, 10, 7, 4, 4, 4, 4, 4, 4, 4],
-            [9, 9, 9, 9, 9, 9, 9, 9, 11, 11, 11, 11, 9, 9, 9, 9, 9, 9, 9, 9],
-            [18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18, 18],
-            [9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9],
-            [3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3]
-        ]`)),
-		LaneConfig: datatypes.JSON([]byte(`{
-            "W": [[7,0],[7,1],[7,2],[7,3],[7,4],[7,5],[7,6],[7,7],[7,8],[7,9],[7,10],[7,11],[7,12],[7,13],[7,14],[7,15],[7,16],[7,17],[7,18],[7,19]],
-            "E": [[9,0],[9,1],[9,2],[9,3],[9,4],[9,5],[9,6],[9,7],[9,8],[9,9],[9,10],[9,11],[9,12],[9,13],[9,14],[9,15],[9,16],[9,17],[9,18],[9,19]],
-            "S": [[0,9],[1,9],[2,9],[3,9],[4,9],[5,9],[6,9],[7,9],[8,9],[9,9]],
-            "N": [[0,11],[1,11],[2,11],[3,11],[4,11],[5,11],[6,11],[7,11],[8,11],[9,11]]
-        }`)),
-		Meta: datatypes.JSON([]byte(`{"id": "TPL_003_T_JUNCTION"}`)),
-	},
-}
-
-func SeedContextTemplates(repo Repository) error {
-	ctx := context.Background()
-	for _, tpl := range templates {
-		if err := repo.FirstOrCreate(ctx, &tpl); err != nil {
-			return err
-		}
-		log.Default().Println("Seeded template: ", tpl.Name)
-	}
-	return nil
-}
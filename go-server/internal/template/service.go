@@ -2,153 +2,282 @@ package template
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"math/rand"
-	"os"
-	"strconv"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/pkg/database"
 	"github.com/google/uuid"
 )
 
 type Service interface {
 	LoadAllTemplates(ctx context.Context) error
+	Reload(ctx context.Context) (*SyncReport, error)
 	GetAllTemplates(ctx context.Context) ([]ContextTemplate, error)
 	GetByID(id uuid.UUID) (*ContextTemplate, error)
-	PickTemplate(excludeIDs []uuid.UUID) (*ContextTemplate, error)
-	GetLaneConfig(templateID uuid.UUID) domain.LaneConfigMap
-	GetRandomTridentSpawn(templateID uuid.UUID) (*domain.TridentSpawn, error)
+	GetVersion(ctx context.Context, versionID uuid.UUID) (*ContextTemplateVersion, error)
+	PickTemplate(excludeIDs []uuid.UUID, pool []string, side domain.DrivingSide, criteria SpawnCriteria) (*ContextTemplate, error)
+	GetLaneConfig(templateID uuid.UUID, side domain.DrivingSide) domain.LaneConfigMap
+	PickSpawn(templateID uuid.UUID, side domain.DrivingSide, criteria SpawnCriteria) (*SpawnCandidate, error)
+	GetSpawns(templateID uuid.UUID, side domain.DrivingSide, criteria SpawnCriteria) ([]SpawnCandidate, error)
 	GetSurfaceAt(templateID uuid.UUID, row, col int) domain.SurfaceType
-	GetLaneDirectionAt(templateID uuid.UUID, row, col int) domain.Direction
-	CalculateTridentZones(templateID uuid.UUID, spawn domain.TridentSpawn) domain.TridentZones
-	GetRearCoordinate(templateID uuid.UUID, row, col int, orientation domain.Direction) (*domain.EnrichedCoordinate, error)
+	GetLaneDirectionAt(templateID uuid.UUID, side domain.DrivingSide, row, col int) domain.Direction
+	CalculateTridentZones(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn) domain.TridentZones
+	CalculateTrajectories(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn) domain.Trajectories
+	GetSignals(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn, phase domain.SignalPhase) []domain.SignalHead
+	GetRegions(templateID uuid.UUID) []domain.Region
+	GetRegionContext(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn) domain.RegionContext
+	GetRearCoordinate(templateID uuid.UUID, side domain.DrivingSide, row, col int, orientation domain.Direction) (*domain.EnrichedCoordinate, error)
+	PlaceEntity(templateID uuid.UUID, side domain.DrivingSide, entityType string, row, col int, orientation domain.Direction) (*Placement, error)
+	Scene(templateID uuid.UUID, side domain.DrivingSide, spawn *domain.TridentSpawn) (*Scene, error)
 }
 
 type service struct {
-	repo            Repository
-	cache           []ContextTemplate
-	laneConfigs     map[uuid.UUID]domain.LaneConfigMap
-	tridentSpawns   map[uuid.UUID][]domain.TridentSpawn
-	surfaceAt       map[uuid.UUID]map[[2]int]domain.SurfaceType // coord → surface
-	laneDirectionAt map[uuid.UUID]map[[2]int]domain.Direction   // coord → lane direction
-	gridDimensions  map[uuid.UUID][2]int                        // templateID → [height, width]
-	tridentDistance int                                         // how far ahead zones start
-	tridentDepth    int                                         // how many traversable tiles to collect
-	mu              sync.RWMutex
+	repo        Repository
+	txManager   database.TransactionManager
+	templateDir string
+	trident     TridentGeometry // defaults for templates without meta.trident
+	state       atomic.Pointer[snapshot]
+	writeMu     sync.Mutex // serialises snapshot publishers
+	reloadMu    sync.Mutex
 }
 
-func NewService(repo Repository) Service {
-	distance := 3
-	if val := os.Getenv("TRIDENT_ZONE_DISTANCE"); val != "" {
-		if parsed, err := strconv.Atoi(val); err == nil {
-			distance = parsed
-		}
-	}
+// snapshot is one immutable view of the cache. Readers load the current pointer without
+// locking; writers build a new snapshot and swap it in, never touching a published one.
+type snapshot struct {
+	cache    []ContextTemplate
+	retired  []ContextTemplate // no longer pickable, still resolvable by ID
+	versions map[uuid.UUID]ContextTemplateVersion
+	layouts  map[uuid.UUID]*layout // template or version ID → parsed geometry
+}
 
-	depth := 3
-	if val := os.Getenv("TRIDENT_ZONE_DEPTH"); val != "" {
-		if parsed, err := strconv.Atoi(val); err == nil {
-			depth = parsed
-		}
+func NewService(repo Repository, txManager database.TransactionManager, templateDir string) Service {
+	s := &service{
+		repo:        repo,
+		txManager:   txManager,
+		templateDir: templateDir,
+		trident:     DefaultTridentGeometry(),
 	}
+	s.state.Store(&snapshot{
+		versions: make(map[uuid.UUID]ContextTemplateVersion),
+		layouts:  make(map[uuid.UUID]*layout),
+	})
+	return s
+}
 
-	return &service{
-		repo:            repo,
-		tridentDistance: distance,
-		tridentDepth:    depth,
-	}
+// current returns the published snapshot
+func (s *service) current() *snapshot {
+	return s.state.Load()
+}
+
+// loadedTemplates is what a load read from the DB, not yet merged into the cache
+type loadedTemplates struct {
+	cache   []ContextTemplate
+	retired []ContextTemplate
+	layouts map[uuid.UUID]*layout
 }
 
 func (s *service) LoadAllTemplates(ctx context.Context) error {
+	loaded, err := s.loadTemplates(ctx)
+	if err != nil {
+		return err
+	}
+	s.publish(loaded)
+	return nil
+}
+
+// loadTemplates reads and validates every template and builds its geometry without
+// touching the cache
+func (s *service) loadTemplates(ctx context.Context) (*loadedTemplates, error) {
 	templates, err := s.repo.GetAll(ctx)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	laneConfigs := make(map[uuid.UUID]domain.LaneConfigMap)
-	tridentSpawns := make(map[uuid.UUID][]domain.TridentSpawn)
-	surfaceAt := make(map[uuid.UUID]map[[2]int]domain.SurfaceType)
-	laneDirectionAt := make(map[uuid.UUID]map[[2]int]domain.Direction)
-	gridDimensions := make(map[uuid.UUID][2]int)
+	// Refuse to cache anything if a single template is broken
+	if err := ValidateTemplates(templates); err != nil {
+		return nil, err
+	}
 
-	for _, template := range templates {
-		var grid [][]int
-		if err := json.Unmarshal(template.GridData, &grid); err != nil {
+	// Soft-deleted templates stay resolvable for scenarios that still point at them
+	deleted, err := s.repo.GetAll(ctx, database.WithUnscoped(), database.WithFilter("deleted_at IS NOT NULL"))
+	if err != nil {
+		return nil, err
+	}
+
+	layouts := make(map[uuid.UUID]*layout)
+
+	// Geometry is keyed by template ID and, when versioned, by the current version ID too
+	index := func(template ContextTemplate) error {
+		l, err := buildLayout(template.GridData, template.LaneConfig, template.Meta, s.trident)
+		if err != nil {
 			return err
 		}
+		layouts[template.Id] = l
+		if template.CurrentVersionID != nil {
+			layouts[*template.CurrentVersionID] = l
+		}
+		return nil
+	}
 
-		height := len(grid)
-		width := len(grid[0])
-		gridDimensions[template.Id] = [2]int{height, width}
-
-		surfaceAt[template.Id] = make(map[[2]int]domain.SurfaceType)
+	for _, template := range templates {
+		if err := index(template); err != nil {
+			return nil, err
+		}
+	}
 
-		// Build surfaceAt lookup
-		for row, cols := range grid {
-			for col, tileID := range cols {
-				if tile, exists := domain.TileRegistry[tileID]; exists {
-					surfaceAt[template.Id][[2]int{row, col}] = tile.Definition.SurfaceType
-				}
-			}
+	var retired []ContextTemplate
+	for _, template := range deleted {
+		if report := ValidateTemplate(template); report.HasIssues() {
+			log.Printf("Skipping deleted template %s: %d problem(s)", report.Template, len(report.Issues))
+			continue
 		}
+		if err := index(template); err != nil {
+			return nil, err
+		}
+		retired = append(retired, template)
+	}
 
-		// Parse lane config and build laneDirectionAt
-		var laneConfig domain.LaneConfigMap
-		if template.LaneConfig != nil {
-			if err := json.Unmarshal(template.LaneConfig, &laneConfig); err != nil {
-				return err
+	return &loadedTemplates{cache: templates, retired: retired, layouts: layouts}, nil
+}
+
+// publish swaps a load into the cache
+func (s *service) publish(loaded *loadedTemplates) {
+	layouts, retired := loaded.layouts, loaded.retired
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	previous := s.current()
+
+	// Keep what the last snapshot served for one generation, so a request that resolved it
+	// before this load can finish: the versions GetVersion indexed, and templates (or their
+	// current versions) that vanished since, e.g. hard-deleted rows or superseded versions.
+	// Older ones are dropped rather than piling up; GetVersion loads a version again on demand.
+	for id := range previous.versions {
+		if l, ok := previous.layouts[id]; ok && layouts[id] == nil {
+			layouts[id] = l
+		}
+	}
+	for _, template := range previous.cache {
+		keys := []uuid.UUID{template.Id}
+		if template.CurrentVersionID != nil {
+			keys = append(keys, *template.CurrentVersionID)
+		}
+		for _, key := range keys {
+			if _, exists := layouts[key]; exists {
+				continue
+			}
+			layouts[key] = previous.layouts[key]
+			if key == template.Id {
+				retired = append(retired, template)
 			}
 		}
-		laneConfigs[template.Id] = laneConfig
+	}
 
-		laneDirectionAt[template.Id] = make(map[[2]int]domain.Direction)
-		for direction, coords := range laneConfig {
-			for _, coord := range coords {
-				laneDirectionAt[template.Id][coord] = direction
-			}
+	s.state.Store(&snapshot{
+		cache:    loaded.cache,
+		retired:  retired,
+		versions: make(map[uuid.UUID]ContextTemplateVersion),
+		layouts:  layouts,
+	})
+}
+
+// GetVersion returns an immutable template revision and makes its geometry available
+// to the lookup methods under the version ID
+func (s *service) GetVersion(ctx context.Context, versionID uuid.UUID) (*ContextTemplateVersion, error) {
+	snap := s.current()
+	version, cached := snap.versions[versionID]
+	_, indexed := snap.layouts[versionID]
+
+	if cached && indexed {
+		return &version, nil
+	}
+
+	if !cached {
+		fetched, err := s.repo.GetVersionByID(ctx, versionID)
+		if err != nil {
+			return nil, errors.New("template version not found")
 		}
+		version = *fetched
+	}
+
+	l, err := buildLayout(version.GridData, version.LaneConfig, version.Meta, s.trident)
+	if err != nil {
+		return nil, err
+	}
 
-		// Pre-compute valid trident spawns
-		tridentSpawns[template.Id] = s.computeValidSpawns(
-			template.Id,
-			surfaceAt[template.Id],
-			laneConfig,
-			height,
-			width,
-		)
-	}
-
-	s.mu.Lock()
-	s.cache = templates
-	s.laneConfigs = laneConfigs
-	s.tridentSpawns = tridentSpawns
-	s.surfaceAt = surfaceAt
-	s.laneDirectionAt = laneDirectionAt
-	s.gridDimensions = gridDimensions
-	s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
-	return nil
+	// Copy-on-write: the published maps are shared with concurrent readers
+	previous := s.current()
+	next := *previous
+	next.versions = make(map[uuid.UUID]ContextTemplateVersion, len(previous.versions)+1)
+	for id, v := range previous.versions {
+		next.versions[id] = v
+	}
+	next.versions[versionID] = version
+	next.layouts = make(map[uuid.UUID]*layout, len(previous.layouts)+1)
+	for id, existing := range previous.layouts {
+		next.layouts[id] = existing
+	}
+	next.layouts[versionID] = l
+	s.state.Store(&next)
+
+	return &version, nil
+}
+
+// Reload syncs the map files into the DB and rebuilds the cache in one transaction. The
+// cache is only swapped once the transaction commits; on any failure the DB is rolled back
+// and the current cache keeps serving.
+func (s *service) Reload(ctx context.Context) (*SyncReport, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	var report *SyncReport
+	var loaded *loadedTemplates
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		report, err = SyncTemplatesFromDir(txCtx, s.repo, s.templateDir)
+		if err != nil {
+			return err
+		}
+		loaded, err = s.loadTemplates(txCtx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(loaded)
+	return report, nil
 }
 
 func (s *service) GetAllTemplates(ctx context.Context) ([]ContextTemplate, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	snap := s.current()
 
-	if len(s.cache) == 0 {
+	if len(snap.cache) == 0 {
 		return nil, errors.New("no templates found")
 	}
 
-	return s.cache, nil
+	return snap.cache, nil
 }
 
 func (s *service) GetByID(id uuid.UUID) (*ContextTemplate, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	snap := s.current()
 
-	for _, t := range s.cache {
+	for _, t := range snap.cache {
+		if t.Id == id {
+			return &t, nil
+		}
+	}
+	for _, t := range snap.retired {
 		if t.Id == id {
 			return &t, nil
 		}
@@ -156,305 +285,217 @@ func (s *service) GetByID(id uuid.UUID) (*ContextTemplate, error) {
 	return nil, errors.New("template not found")
 }
 
-func (s *service) PickTemplate(excludeIDs []uuid.UUID) (*ContextTemplate, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// PickTemplate picks a random template, preferring ones not in excludeIDs, among those in the
+// pool (meta IDs, every template when empty) with at least one spawn that satisfies the criteria
+func (s *service) PickTemplate(excludeIDs []uuid.UUID, pool []string, side domain.DrivingSide, criteria SpawnCriteria) (*ContextTemplate, error) {
+	snap := s.current()
 
-	if len(s.cache) == 0 {
+	if len(snap.cache) == 0 {
 		return nil, errors.New("template cache is empty")
 	}
 
+	// Only templates where the planned scenario is physically possible
+	var hosts []ContextTemplate
+	for _, t := range snap.cache {
+		l, ok := snap.layouts[t.Id]
+		if !ok || len(pool) > 0 && !slices.Contains(pool, l.metaID) {
+			continue
+		}
+		if l.variant(side).hasSpawn(criteria) {
+			hosts = append(hosts, t)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("no template has a spawn matching the scenario factors")
+	}
+
 	var candidates []ContextTemplate
 	excludeIDsMap := make(map[uuid.UUID]bool, len(excludeIDs))
 	for _, id := range excludeIDs {
 		excludeIDsMap[id] = true
 	}
 
-	for _, t := range s.cache {
+	for _, t := range hosts {
 		if _, found := excludeIDsMap[t.Id]; !found {
 			candidates = append(candidates, t)
 		}
 	}
 
 	if len(candidates) == 0 {
-		candidates = s.cache
+		candidates = hosts
 	}
 
 	randomIndex := rand.Intn(len(candidates))
 	return &candidates[randomIndex], nil
 }
 
-func (s *service) GetLaneConfig(templateID uuid.UUID) domain.LaneConfigMap {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// lanes returns the geometry and the lane variant for a driving side, nil if unknown
+func (s *service) lanes(templateID uuid.UUID, side domain.DrivingSide) (*layout, *laneVariant) {
+	l, ok := s.current().layouts[templateID]
+	if !ok {
+		return nil, nil
+	}
+	return l, l.variant(side)
+}
 
-	if config, ok := s.laneConfigs[templateID]; ok {
-		return config
+func (s *service) GetLaneConfig(templateID uuid.UUID, side domain.DrivingSide) domain.LaneConfigMap {
+	if _, lanes := s.lanes(templateID, side); lanes != nil {
+		return lanes.laneConfig
 	}
 	return nil
 }
 
-func (s *service) GetRandomTridentSpawn(templateID uuid.UUID) (*domain.TridentSpawn, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// PickSpawn returns a random pre-computed spawn whose zones satisfy the criteria
+func (s *service) PickSpawn(templateID uuid.UUID, side domain.DrivingSide, criteria SpawnCriteria) (*SpawnCandidate, error) {
+	candidates, err := s.GetSpawns(templateID, side, criteria)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no trident spawn matches the criteria")
+	}
+	randomIndex := rand.Intn(len(candidates))
+	return &candidates[randomIndex], nil
+}
+
+// GetSpawns lists the pre-computed spawns whose zones satisfy the criteria
+func (s *service) GetSpawns(templateID uuid.UUID, side domain.DrivingSide, criteria SpawnCriteria) ([]SpawnCandidate, error) {
+	_, lanes := s.lanes(templateID, side)
+	if lanes == nil || len(lanes.spawns) == 0 {
+		return nil, errors.New("no trident spawns found")
+	}
 
-	if spawns, ok := s.tridentSpawns[templateID]; ok {
-		if len(spawns) == 0 {
-			return nil, errors.New("no trident spawns found")
+	matches := make([]SpawnCandidate, 0, len(lanes.spawns))
+	for _, candidate := range lanes.spawns {
+		if criteria.Matches(candidate) {
+			matches = append(matches, candidate)
 		}
-		randomIndex := rand.Intn(len(spawns))
-		return &spawns[randomIndex], nil
 	}
-	return nil, errors.New("no trident spawns found")
+	return matches, nil
 }
 
 func (s *service) GetSurfaceAt(templateID uuid.UUID, row, col int) domain.SurfaceType {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if surfaces, ok := s.surfaceAt[templateID]; ok {
-		if surface, exists := surfaces[[2]int{row, col}]; exists {
-			return surface
-		}
+	if l, ok := s.current().layouts[templateID]; ok {
+		return l.grid.Surface(row, col)
 	}
 	return domain.SurfaceBuilding // default
 }
 
-func (s *service) GetLaneDirectionAt(templateID uuid.UUID, row, col int) domain.Direction {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if directions, ok := s.laneDirectionAt[templateID]; ok {
-		return directions[[2]int{row, col}] // returns "" if not found
+func (s *service) GetLaneDirectionAt(templateID uuid.UUID, side domain.DrivingSide, row, col int) domain.Direction {
+	if l, lanes := s.lanes(templateID, side); lanes != nil {
+		return lanes.directions.at(l.grid, row, col) // returns "" if not found
 	}
 	return ""
 }
 
-func (s *service) GetRearCoordinate(templateID uuid.UUID, row, col int, orientation domain.Direction) (*domain.EnrichedCoordinate, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	surfaces, ok := s.surfaceAt[templateID]
-	if !ok {
+func (s *service) GetRearCoordinate(templateID uuid.UUID, side domain.DrivingSide, row, col int, orientation domain.Direction) (*domain.EnrichedCoordinate, error) {
+	l, lanes := s.lanes(templateID, side)
+	if l == nil {
 		return nil, errors.New("template not found")
 	}
 
-	directions := s.laneDirectionAt[templateID]
-	dims := s.gridDimensions[templateID]
-	height, width := dims[0], dims[1]
+	return l.rear(lanes, row, col, orientation)
+}
 
-	// Rear is opposite of orientation
-	var dRow, dCol int
-	switch orientation {
-	case domain.DirectionNorth:
-		dRow, dCol = 1, 0
-	case domain.DirectionSouth:
-		dRow, dCol = -1, 0
-	case domain.DirectionEast:
-		dRow, dCol = 0, -1
-	case domain.DirectionWest:
-		dRow, dCol = 0, 1
-	default:
-		return nil, errors.New("invalid orientation")
+// PlaceEntity returns the tiles an entity covers; multi-tile entities are moved along their
+// lane so the whole footprint is on the road, or rejected when it cannot be
+func (s *service) PlaceEntity(templateID uuid.UUID, side domain.DrivingSide, entityType string, row, col int, orientation domain.Direction) (*Placement, error) {
+	l, lanes := s.lanes(templateID, side)
+	if l == nil {
+		return nil, errors.New("template not found")
 	}
 
-	rearRow := row + dRow
-	rearCol := col + dCol
-
-	if rearRow < 0 || rearRow >= height || rearCol < 0 || rearCol >= width {
-		return nil, errors.New("rear coordinate out of bounds")
+	placement, err := l.place(lanes, entityType, row, col, orientation)
+	if err != nil {
+		return nil, err
 	}
+	return &placement, nil
+}
 
-	key := [2]int{rearRow, rearCol}
-	surface := surfaces[key]
-
-	if surface == domain.SurfaceDrivable {
-		return &domain.EnrichedCoordinate{
-			Coordinate:  domain.Coordinate{Row: rearRow, Col: rearCol},
-			Surface:     surface,
-			Orientation: directions[key],
-		}, nil
+// CalculateTridentZones returns the zones with expandable B/C (skips restricted, stops at
+// building) for a spawn; valid spawns come from the load-time cache
+func (s *service) CalculateTridentZones(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn) domain.TridentZones {
+	l, lanes := s.lanes(templateID, side)
+	if l == nil {
+		return domain.TridentZones{}
 	}
 
-	return nil, errors.New("no valid rear coordinate found")
+	return l.zones(lanes, spawn)
 }
 
-// CalculateTridentZones builds zones with expandable B/C (skips restricted, stops at building)
-func (s *service) CalculateTridentZones(templateID uuid.UUID, spawn domain.TridentSpawn) domain.TridentZones {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	surfaces := s.surfaceAt[templateID]
-	directions := s.laneDirectionAt[templateID]
-	dims := s.gridDimensions[templateID]
-	height, width := dims[0], dims[1]
-
-	fRow, fCol, lRow, lCol, rRow, rCol := domain.CalculateTridentZones(spawn)
-
-	distance := s.tridentDistance
-	depth := s.tridentDepth
+// CalculateTrajectories returns the maintain/swerve-left/swerve-right paths from a spawn
+// into its trident zones; valid spawns come from the load-time cache
+func (s *service) CalculateTrajectories(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn) domain.Trajectories {
+	l, lanes := s.lanes(templateID, side)
+	if l == nil {
+		return domain.Trajectories{}
+	}
 
-	baseRow := spawn.Row + (fRow * distance)
-	baseCol := spawn.Col + (fCol * distance)
+	return l.trajectories(lanes, spawn)
+}
 
-	// Zone A: fixed strip forward (drivable + restricted allowed)
-	generateForwardZone := func(startRow, startCol int) domain.TridentZone {
-		coords := make([]domain.EnrichedCoordinate, 0, depth)
-		for i := 0; i < depth; i++ {
-			row := startRow + (fRow * i)
-			col := startCol + (fCol * i)
-			if row < 0 || row >= height || col < 0 || col >= width {
-				break
-			}
-			key := [2]int{row, col}
-			coords = append(coords, domain.EnrichedCoordinate{
-				Coordinate:  domain.Coordinate{Row: row, Col: col},
-				Surface:     surfaces[key],
-				Orientation: directions[key],
-			})
-		}
-		return domain.TridentZone{Coordinates: coords}
-	}
-
-	// Zone B/C: expand perpendicular, skip restricted, collect traversable, stop at building
-	generateSideZone := func(perpRow, perpCol int) domain.TridentZone {
-		coords := make([]domain.EnrichedCoordinate, 0, depth)
-
-		// For each forward step, scan perpendicular until we find traversable
-		for i := 0; i < depth; i++ {
-			fwdRow := baseRow + (fRow * i)
-			fwdCol := baseCol + (fCol * i)
-
-			// Scan perpendicular from forward position
-			for offset := 1; offset <= 5; offset++ { // max 5 tiles perpendicular
-				row := fwdRow + (perpRow * offset)
-				col := fwdCol + (perpCol * offset)
-
-				if row < 0 || row >= height || col < 0 || col >= width {
-					break // out of bounds
-				}
-
-				key := [2]int{row, col}
-				surface := surfaces[key]
-
-				if surface == domain.SurfaceBuilding {
-					break // hit wall, stop scanning this direction
-				}
-				if surface == domain.SurfaceRestricted {
-					continue // skip yellow lines
-				}
-				// Found traversable (drivable or walkable)
-				coords = append(coords, domain.EnrichedCoordinate{
-					Coordinate:  domain.Coordinate{Row: row, Col: col},
-					Surface:     surface,
-					Orientation: directions[key],
-				})
-				break // found one for this forward step, move to next
-			}
-		}
-		return domain.TridentZone{Coordinates: coords}
+// GetSignals returns every signal head with its state when the AV at the spawn sees phase.
+// Nil when the phase is None or the AV does not drive up to a signal.
+func (s *service) GetSignals(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn, phase domain.SignalPhase) []domain.SignalHead {
+	l, lanes := s.lanes(templateID, side)
+	if l == nil || phase == "" || phase == domain.SignalPhaseNone {
+		return nil
 	}
 
-	return domain.TridentZones{
-		ZoneA: generateForwardZone(baseRow, baseCol),
-		ZoneB: generateSideZone(lRow, lCol),
-		ZoneC: generateSideZone(rRow, rCol),
+	avSignal := signalFor(spawn, l.geometry, lanes.signals)
+	if avSignal == nil {
+		return nil
 	}
+	return signalStates(lanes.signals, avSignal.Axis, phase)
 }
 
-// computeValidSpawns finds valid trident spawn points
-func (s *service) computeValidSpawns(
-	templateID uuid.UUID,
-	surfaceAt map[[2]int]domain.SurfaceType,
-	laneConfig domain.LaneConfigMap,
-	height, width int,
-) []domain.TridentSpawn {
-	var validSpawns []domain.TridentSpawn
-
-	for direction, coords := range laneConfig {
-		for _, coord := range coords {
-			// AV must spawn on drivable (not restricted)
-			if surfaceAt[coord] != domain.SurfaceDrivable {
-				continue
-			}
-
-			spawn := domain.TridentSpawn{
-				Coordinate:  domain.Coordinate{Row: coord[0], Col: coord[1]},
-				Orientation: direction,
-			}
+// GetRegions returns the metadata layers of a template
+func (s *service) GetRegions(templateID uuid.UUID) []domain.Region {
+	if l, ok := s.current().layouts[templateID]; ok {
+		return l.regions
+	}
+	return nil
+}
 
-			if s.isValidSpawn(spawn, surfaceAt, height, width) {
-				validSpawns = append(validSpawns, spawn)
-			}
-		}
+// GetRegionContext summarises the regions at a spawn and its trident zones
+func (s *service) GetRegionContext(templateID uuid.UUID, side domain.DrivingSide, spawn domain.TridentSpawn) domain.RegionContext {
+	l, lanes := s.lanes(templateID, side)
+	if l == nil {
+		return domain.RegionContext{}
 	}
 
-	return validSpawns
+	return regionContext(l.regions, spawn, l.zones(lanes, spawn))
 }
 
-// isValidSpawn checks if spawn point produces valid trident zones
-func (s *service) isValidSpawn(
-	spawn domain.TridentSpawn,
-	surfaceAt map[[2]int]domain.SurfaceType,
-	height, width int,
-) bool {
-	fRow, fCol, lRow, lCol, rRow, rCol := domain.CalculateTridentZones(spawn)
-
-	const distance = 3
-	const depth = 3
-
-	baseRow := spawn.Row + (fRow * distance)
-	baseCol := spawn.Col + (fCol * distance)
-
-	// Check Zone A: all cells must be road (drivable or restricted) and in bounds
-	for i := 0; i < depth; i++ {
-		row := baseRow + (fRow * i)
-		col := baseCol + (fCol * i)
-		if row < 0 || row >= height || col < 0 || col >= width {
-			return false
-		}
-		surface := surfaceAt[[2]int{row, col}]
-		if surface != domain.SurfaceDrivable && surface != domain.SurfaceRestricted {
-			return false
-		}
+// Scene assembles a cached template (or version) for rendering
+func (s *service) Scene(templateID uuid.UUID, side domain.DrivingSide, spawn *domain.TridentSpawn) (*Scene, error) {
+	snap := s.current()
+
+	l, ok := snap.layouts[templateID]
+	if !ok {
+		return nil, errors.New("template not found")
 	}
 
-	// Check Zone B and C: must find at least 1 traversable tile per zone
-	checkSideZone := func(perpRow, perpCol int) bool {
-		foundTraversable := 0
-		for i := 0; i < depth; i++ {
-			fwdRow := baseRow + (fRow * i)
-			fwdCol := baseCol + (fCol * i)
-
-			for offset := 1; offset <= 5; offset++ {
-				row := fwdRow + (perpRow * offset)
-				col := fwdCol + (perpCol * offset)
-
-				if row < 0 || row >= height || col < 0 || col >= width {
-					break
-				}
-
-				surface := surfaceAt[[2]int{row, col}]
-				if surface == domain.SurfaceBuilding {
-					break
-				}
-				if surface == domain.SurfaceRestricted {
-					continue
-				}
-				// Found traversable
-				foundTraversable++
-				break
+	title := ""
+	for _, templates := range [][]ContextTemplate{snap.cache, snap.retired} {
+		for _, t := range templates {
+			if t.Id == templateID || t.CurrentVersionID != nil && *t.CurrentVersionID == templateID {
+				title = t.Name
 			}
 		}
-		return foundTraversable >= depth // need all 3 forward positions to have a side tile
-	}
-
-	if !checkSideZone(lRow, lCol) {
-		return false
 	}
-	if !checkSideZone(rRow, rCol) {
-		return false
+	if version, ok := snap.versions[templateID]; ok {
+		title = fmt.Sprintf("%s v%d", version.Name, version.Version)
 	}
 
-	return true
+	return l.scene(title, side, spawn)
+}
+
+// NewScene builds a scene straight from a template, without the service cache
+func NewScene(tpl ContextTemplate, side domain.DrivingSide, spawn *domain.TridentSpawn) (*Scene, error) {
+	l, err := buildLayout(tpl.GridData, tpl.LaneConfig, tpl.Meta, DefaultTridentGeometry())
+	if err != nil {
+		return nil, err
+	}
+	return l.scene(tpl.Name, side, spawn)
 }
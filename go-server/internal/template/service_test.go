@@ -0,0 +1,164 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"github.com/direwen/go-server/pkg/database"
+	"github.com/google/uuid"
+)
+
+// testLoad indexes templates the way loadTemplates does, under their ID and current version
+func testLoad(t *testing.T, templates ...ContextTemplate) *loadedTemplates {
+	t.Helper()
+	layouts := make(map[uuid.UUID]*layout)
+	for _, tpl := range templates {
+		l, err := buildLayout(tpl.GridData, tpl.LaneConfig, tpl.Meta, DefaultTridentGeometry())
+		if err != nil {
+			t.Fatal(err)
+		}
+		layouts[tpl.Id] = l
+		if tpl.CurrentVersionID != nil {
+			layouts[*tpl.CurrentVersionID] = l
+		}
+	}
+	return &loadedTemplates{cache: templates, layouts: layouts}
+}
+
+func TestPublishKeepsLayoutsForOneReload(t *testing.T) {
+	base := readTestTemplate(t)
+	templateA, templateB := uuid.New(), uuid.New()
+	v1, v2, vB := uuid.New(), uuid.New(), uuid.New()
+	version := func(id uuid.UUID, current uuid.UUID) ContextTemplate {
+		tpl := base
+		tpl.Id, tpl.CurrentVersionID = id, &current
+		return tpl
+	}
+
+	tests := []struct {
+		name      string
+		templates []ContextTemplate
+		resolves  []uuid.UUID // layouts the lookups still find
+		drops     []uuid.UUID // layouts that are gone
+		retired   []uuid.UUID // templates resolvable by ID but not pickable
+	}{
+		{
+			name:      "first load",
+			templates: []ContextTemplate{version(templateA, v1), version(templateB, vB)},
+			resolves:  []uuid.UUID{templateA, templateB, v1, vB},
+		},
+		{
+			name:      "A moves to v2 and B vanishes",
+			templates: []ContextTemplate{version(templateA, v2)},
+			resolves:  []uuid.UUID{templateA, templateB, v1, v2, vB},
+			retired:   []uuid.UUID{templateB},
+		},
+		{
+			name:      "a reload later",
+			templates: []ContextTemplate{version(templateA, v2)},
+			resolves:  []uuid.UUID{templateA, v2},
+			drops:     []uuid.UUID{templateB, v1, vB},
+		},
+	}
+
+	s := NewService(nil, nil, "").(*service)
+	for _, tt := range tests {
+		s.publish(testLoad(t, tt.templates...))
+		snap := s.current()
+		for _, id := range tt.resolves {
+			if snap.layouts[id] == nil {
+				t.Errorf("%s: layout %s is gone", tt.name, id)
+			}
+		}
+		for _, id := range tt.drops {
+			if _, ok := snap.layouts[id]; ok {
+				t.Errorf("%s: layout %s is still kept", tt.name, id)
+			}
+		}
+		if len(snap.retired) != len(tt.retired) {
+			t.Fatalf("%s: %d retired templates, want %d", tt.name, len(snap.retired), len(tt.retired))
+		}
+		for i, id := range tt.retired {
+			if snap.retired[i].Id != id {
+				t.Errorf("%s: retired %s, want %s", tt.name, snap.retired[i].Id, id)
+			}
+			if _, err := s.GetByID(id); err != nil {
+				t.Errorf("%s: retired template %s does not resolve: %v", tt.name, id, err)
+			}
+		}
+	}
+}
+
+// versionRepo serves template versions and counts the lookups; the rest of Repository is unused
+type versionRepo struct {
+	Repository
+	versions map[uuid.UUID]ContextTemplateVersion
+	fetches  int
+}
+
+func (r *versionRepo) GetVersionByID(ctx context.Context, id uuid.UUID, opts ...database.QueryOption) (*ContextTemplateVersion, error) {
+	r.fetches++
+	version, ok := r.versions[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &version, nil
+}
+
+func TestGetVersionLoadsOnDemand(t *testing.T) {
+	base := readTestTemplate(t)
+	stored := ContextTemplateVersion{
+		Version:    1,
+		Name:       base.Name,
+		Width:      base.Width,
+		Height:     base.Height,
+		GridData:   base.GridData,
+		LaneConfig: base.LaneConfig,
+		Meta:       base.Meta,
+	}
+	stored.Id = uuid.New()
+	repo := &versionRepo{versions: map[uuid.UUID]ContextTemplateVersion{stored.Id: stored}}
+	s := NewService(repo, nil, "").(*service)
+
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		reload      bool // publish an empty load first
+		wantErr     bool
+		wantFetches int // repository lookups so far
+	}{
+		{name: "first lookup reads the repository", id: stored.Id, wantFetches: 1},
+		{name: "second lookup is cached", id: stored.Id, wantFetches: 1},
+		{name: "a reload drops the cached version", id: stored.Id, reload: true, wantFetches: 2},
+		{name: "unknown version", id: uuid.New(), wantErr: true, wantFetches: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.reload {
+				s.publish(testLoad(t))
+			}
+			version, err := s.GetVersion(context.Background(), tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if version.Id != tt.id {
+					t.Errorf("got version %s, want %s", version.Id, tt.id)
+				}
+				if s.GetLaneConfig(tt.id, domain.DrivingSideRight) == nil {
+					t.Error("the version's geometry is not indexed")
+				}
+			}
+			if repo.fetches != tt.wantFetches {
+				t.Errorf("%d repository lookups, want %d", repo.fetches, tt.wantFetches)
+			}
+		})
+	}
+}
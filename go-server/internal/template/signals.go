@@ -0,0 +1,130 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// SignalJunction marks a signalised junction in meta.signals. The box from Top/Left to
+// Bottom/Right (inclusive) covers the junction and its crosswalks; signal heads are attached
+// to the tiles around and inside it for each driving side.
+type SignalJunction struct {
+	ID     string `json:"id"`
+	Top    int    `json:"top"`
+	Left   int    `json:"left"`
+	Bottom int    `json:"bottom"`
+	Right  int    `json:"right"`
+}
+
+func (j SignalJunction) contains(row, col int) bool {
+	return row >= j.Top && row <= j.Bottom && col >= j.Left && col <= j.Right
+}
+
+// Validate checks the box lies on the grid
+func (j SignalJunction) Validate(grid *Grid) error {
+	if j.ID == "" {
+		return errors.New("signal junction needs an id")
+	}
+	if j.Top > j.Bottom || j.Left > j.Right {
+		return fmt.Errorf("signal junction %s: top/left must not be past bottom/right", j.ID)
+	}
+	if !grid.InBounds(j.Top, j.Left) || !grid.InBounds(j.Bottom, j.Right) {
+		return fmt.Errorf("signal junction %s is off the grid", j.ID)
+	}
+	return nil
+}
+
+// buildSignalHeads attaches heads to a junction for one lane variant. A vehicle head goes on
+// every lane tile just outside the box whose lane runs into it. A pedestrian head goes on
+// every crosswalk tile on the edge of the box; one on the top or bottom edge crosses the
+// north-south arm, one on the left or right edge the east-west arm.
+func buildSignalHeads(junction SignalJunction, grid *Grid, directions laneGrid) []domain.SignalHead {
+	var heads []domain.SignalHead
+	for row := junction.Top - 1; row <= junction.Bottom+1; row++ {
+		for col := junction.Left - 1; col <= junction.Right+1; col++ {
+			if !grid.InBounds(row, col) {
+				continue
+			}
+			coord := domain.Coordinate{Row: row, Col: col}
+
+			if !junction.contains(row, col) {
+				lane := directions.at(grid, row, col)
+				if nextRow, nextCol, ok := grid.Neighbour(row, col, lane); ok && lane != "" && junction.contains(nextRow, nextCol) {
+					heads = append(heads, domain.SignalHead{
+						Junction:   junction.ID,
+						Kind:       domain.SignalKindVehicle,
+						Coordinate: coord,
+						Direction:  lane,
+						Axis:       lane.Axis(),
+					})
+				}
+				continue
+			}
+
+			onRowEdge := row == junction.Top || row == junction.Bottom
+			onColEdge := col == junction.Left || col == junction.Right
+			crosswalk := domain.RulesFor(grid.Surface(row, col)).Road && allowsPedestrians(domain.TileRegistry[grid.Tile(row, col)])
+			if onRowEdge == onColEdge || !crosswalk {
+				continue // interior, corner, or not a crosswalk
+			}
+			axis := domain.DirectionNorth.Axis()
+			if onColEdge {
+				axis = domain.DirectionEast.Axis()
+			}
+			heads = append(heads, domain.SignalHead{
+				Junction:   junction.ID,
+				Kind:       domain.SignalKindPedestrian,
+				Coordinate: coord,
+				Axis:       axis,
+			})
+		}
+	}
+	return heads
+}
+
+// signalFor returns the vehicle head the AV meets driving straight on from the spawn,
+// within the reach of zone A
+func signalFor(spawn domain.TridentSpawn, geometry TridentGeometry, heads []domain.SignalHead) *domain.SignalHead {
+	fRow, fCol, _, _, _, _ := domain.CalculateTridentZones(spawn)
+	for step := 0; step < geometry.Distance+geometry.Depth; step++ {
+		row, col := spawn.Row+step*fRow, spawn.Col+step*fCol
+		for i := range heads {
+			head := &heads[i]
+			if head.Kind == domain.SignalKindVehicle && head.Row == row && head.Col == col && head.Direction == spawn.Orientation {
+				return head
+			}
+		}
+	}
+	return nil
+}
+
+// signalStates sets every head for the phase the AV sees. Junctions run one plan: the AV's
+// axis shows the phase and the crossing axis is held at red (or released on green when the
+// AV has red). Pedestrians may walk across an axis that is at red.
+func signalStates(heads []domain.SignalHead, avAxis string, phase domain.SignalPhase) []domain.SignalHead {
+	axisState := func(axis string) domain.SignalPhase {
+		if axis == avAxis {
+			return phase
+		}
+		if phase == domain.SignalPhaseRed {
+			return domain.SignalPhaseGreen
+		}
+		return domain.SignalPhaseRed
+	}
+
+	states := make([]domain.SignalHead, len(heads))
+	for i, head := range heads {
+		head.State = axisState(head.Axis)
+		if head.Kind == domain.SignalKindPedestrian {
+			if head.State == domain.SignalPhaseRed {
+				head.State = domain.SignalPhaseWalk
+			} else {
+				head.State = domain.SignalPhaseDontWalk
+			}
+		}
+		states[i] = head
+	}
+	return states
+}
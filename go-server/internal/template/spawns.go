@@ -0,0 +1,170 @@
+package template
+
+import (
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// SpawnCandidate is a valid spawn with its zones, swerve paths and quality metrics, all
+// computed once when the template is loaded. Candidates are shared; treat them as read-only.
+type SpawnCandidate struct {
+	Spawn        domain.TridentSpawn        `json:"spawn"`
+	Zones        domain.TridentZones        `json:"zones"`
+	Trajectories domain.Trajectories        `json:"trajectories"`
+	Quality      SpawnQuality               `json:"quality"`
+	Rear         *domain.EnrichedCoordinate `json:"rear,omitempty"`   // drivable tile behind the AV, if any
+	Signal       *domain.SignalHead         `json:"signal,omitempty"` // signal the AV drives up to, if any
+	Regions      domain.RegionContext       `json:"regions"`          // regions at the spawn and its zones
+}
+
+// ZoneQuality is the size and surface mix of one zone
+type ZoneQuality struct {
+	Size     int                        `json:"size"`
+	Surfaces map[domain.SurfaceType]int `json:"surfaces"`
+}
+
+// SpawnQuality summarises the zones of a spawn
+type SpawnQuality struct {
+	ZoneA           ZoneQuality `json:"zone_a"`
+	ZoneB           ZoneQuality `json:"zone_b"`
+	ZoneC           ZoneQuality `json:"zone_c"`
+	FeasibleSwerves int         `json:"feasible_swerves"` // 0-2
+}
+
+// SpawnCriteria narrows PickSpawn. Zero fields match anything.
+type SpawnCriteria struct {
+	Orientation domain.Direction
+	// Every tile of the zone must have this surface; "drivable" matches the whole carriageway
+	ZoneA, ZoneB, ZoneC domain.SurfaceType
+	// Zone B or C must have at least one tile whose surface allows this usage
+	SideUsage string
+	// The star's zone, B or C when SideUsage is set and A otherwise, must have this many
+	// tiles the star can stand on, to spread its group
+	StarTiles int
+	// The tile behind the AV must be drivable
+	DrivableRear bool
+	// Both swerve paths must be feasible
+	FeasibleSwerves bool
+	// The AV must drive up to a traffic signal
+	Signalised bool
+	// The regions at the spawn and its zones must pass this check, e.g. the study's rules
+	Area func(domain.RegionContext) bool
+}
+
+// SpawnCriteriaFor turns the planned manipulation into spawn requirements. A compliant star
+// goes in zone B or C, so one of them needs a tile the star belongs on, while a violating
+// star stands in zone A, which is always road; a group needs enough tiles in its zone to
+// spread over. A tailgater needs a drivable tile behind the AV, and a signal phase needs a
+// signal ahead of it.
+func SpawnCriteriaFor(factors domain.ScenarioFactors) SpawnCriteria {
+	var criteria SpawnCriteria
+	if factors.PrimaryBehavior == string(domain.BehaviorCompliant) {
+		criteria.SideUsage = domain.EntityUsage(factors.PrimaryEntity)
+	}
+	criteria.StarTiles = domain.CrowdTiles(factors.Group())
+	criteria.DrivableRear = factors.HasTailgater
+	criteria.Signalised = factors.Signalised()
+	return criteria
+}
+
+func newSpawnCandidate(spawn domain.TridentSpawn, zones domain.TridentZones, trajectories domain.Trajectories, rear *domain.EnrichedCoordinate) SpawnCandidate {
+	quality := SpawnQuality{
+		ZoneA: zoneQuality(zones.ZoneA),
+		ZoneB: zoneQuality(zones.ZoneB),
+		ZoneC: zoneQuality(zones.ZoneC),
+	}
+	for _, path := range []domain.Trajectory{trajectories.SwerveLeft, trajectories.SwerveRight} {
+		if path.Feasible {
+			quality.FeasibleSwerves++
+		}
+	}
+
+	return SpawnCandidate{
+		Spawn:        spawn,
+		Zones:        zones,
+		Trajectories: trajectories,
+		Quality:      quality,
+		Rear:         rear,
+	}
+}
+
+func zoneQuality(zone domain.TridentZone) ZoneQuality {
+	quality := ZoneQuality{
+		Size:     len(zone.Coordinates),
+		Surfaces: make(map[domain.SurfaceType]int),
+	}
+	for _, coord := range zone.Coordinates {
+		quality.Surfaces[coord.Surface]++
+	}
+	return quality
+}
+
+// count returns the tiles of a surface in the zone; drivable counts every road surface
+func (q ZoneQuality) count(surface domain.SurfaceType) int {
+	if surface != domain.SurfaceDrivable {
+		return q.Surfaces[surface]
+	}
+	count := 0
+	for s, n := range q.Surfaces {
+		if domain.RulesFor(s).Road {
+			count += n
+		}
+	}
+	return count
+}
+
+// is reports whether every tile of the zone has the surface
+func (q ZoneQuality) is(surface domain.SurfaceType) bool {
+	return surface == "" || q.Size > 0 && q.count(surface) == q.Size
+}
+
+// allows reports whether at least one tile of the zone allows the usage
+func (q ZoneQuality) allows(usage string) bool {
+	return q.fits(usage) > 0
+}
+
+// fits returns the tiles of the zone that allow the usage, every tile when it is empty
+func (q ZoneQuality) fits(usage string) int {
+	if usage == "" {
+		return q.Size
+	}
+	count := 0
+	for surface, n := range q.Surfaces {
+		if domain.RulesFor(surface).Allows(usage) {
+			count += n
+		}
+	}
+	return count
+}
+
+// Matches reports whether a candidate satisfies the criteria
+func (c SpawnCriteria) Matches(candidate SpawnCandidate) bool {
+	if c.Orientation != "" && candidate.Spawn.Orientation != c.Orientation {
+		return false
+	}
+	if c.FeasibleSwerves && candidate.Quality.FeasibleSwerves < 2 {
+		return false
+	}
+	if c.DrivableRear && candidate.Rear == nil {
+		return false
+	}
+	if c.Signalised && candidate.Signal == nil {
+		return false
+	}
+	if c.Area != nil && !c.Area(candidate.Regions) {
+		return false
+	}
+	if !candidate.Quality.ZoneB.allows(c.SideUsage) && !candidate.Quality.ZoneC.allows(c.SideUsage) {
+		return false
+	}
+	if c.StarTiles > 1 {
+		if c.SideUsage == "" && candidate.Quality.ZoneA.Size < c.StarTiles {
+			return false
+		}
+		if c.SideUsage != "" && candidate.Quality.ZoneB.fits(c.SideUsage) < c.StarTiles && candidate.Quality.ZoneC.fits(c.SideUsage) < c.StarTiles {
+			return false
+		}
+	}
+	return candidate.Quality.ZoneA.is(c.ZoneA) &&
+		candidate.Quality.ZoneB.is(c.ZoneB) &&
+		candidate.Quality.ZoneC.is(c.ZoneC)
+}
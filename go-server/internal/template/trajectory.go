@@ -0,0 +1,189 @@
+package template
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// Sampling step along a path, in tiles. Small enough that no swept tile is skipped.
+const trajectoryStep = 0.25
+
+// Points per arc when sampling a lane change
+const arcSegments = 6
+
+// pathFrame maps local path coordinates (x forward, y towards one side, in tiles) onto the grid
+type pathFrame struct {
+	origin           domain.Coordinate
+	fRow, fCol       int // forward
+	sideRow, sideCol int // lateral
+	heading          domain.Direction
+}
+
+func (f pathFrame) point(x, y float64) domain.TrajectoryPoint {
+	return domain.TrajectoryPoint{
+		Row: float64(f.origin.Row) + float64(f.fRow)*x + float64(f.sideRow)*y,
+		Col: float64(f.origin.Col) + float64(f.fCol)*x + float64(f.sideCol)*y,
+	}
+}
+
+// local returns how far ahead and how far sideways a cell is from the origin
+func (f pathFrame) local(row, col int) (x, y int) {
+	dRow, dCol := row-f.origin.Row, col-f.origin.Col
+	return dRow*f.fRow + dCol*f.fCol, dRow*f.sideRow + dCol*f.sideCol
+}
+
+// buildTrajectories computes the AV path into each trident zone. Maintain drives straight
+// through zone A. A swerve holds its lane, changes line with two opposite arcs of the
+// geometry's turning radius, lands on the first zone tile it can reach that way and carries
+// on straight to the end of the zone. Paths that leave the map or clip a surface the zone
+// scan stops at (buildings) are infeasible.
+func buildTrajectories(
+	spawn domain.TridentSpawn,
+	zones domain.TridentZones,
+	geometry TridentGeometry,
+	grid *Grid,
+	directions laneGrid,
+) domain.Trajectories {
+	fRow, fCol, lRow, lCol, rRow, rCol := domain.CalculateTridentZones(spawn)
+	end := float64(geometry.Distance + geometry.Depth - 1)
+
+	frame := func(sideRow, sideCol int) pathFrame {
+		return pathFrame{origin: spawn.Coordinate, fRow: fRow, fCol: fCol, sideRow: sideRow, sideCol: sideCol, heading: spawn.Orientation}
+	}
+
+	return domain.Trajectories{
+		Maintain:    frame(lRow, lCol).trajectory([][2]float64{{0, 0}, {end, 0}}, grid, directions),
+		SwerveLeft:  frame(lRow, lCol).swerve(zones.ZoneB, end, geometry.TurnRadius, grid, directions),
+		SwerveRight: frame(rRow, rCol).swerve(zones.ZoneC, end, geometry.TurnRadius, grid, directions),
+	}
+}
+
+// swerve lands on the nearest zone tile the turning radius allows
+func (f pathFrame) swerve(zone domain.TridentZone, end float64, turnRadius int, grid *Grid, directions laneGrid) domain.Trajectory {
+	if len(zone.Coordinates) == 0 {
+		return domain.Trajectory{Reason: "zone is empty"}
+	}
+
+	radius := float64(turnRadius)
+	for _, target := range zone.Coordinates {
+		x, y := f.local(target.Row, target.Col)
+		if y <= 0 {
+			continue
+		}
+		start := float64(x) - laneChangeLength(float64(y), radius)
+		if start < 0 {
+			continue // too close to turn in
+		}
+
+		path := [][2]float64{{0, 0}}
+		path = append(path, laneChange(start, float64(y), radius)...)
+		path = append(path, [2]float64{end, float64(y)})
+		return f.trajectory(path, grid, directions)
+	}
+
+	return domain.Trajectory{Reason: fmt.Sprintf("zone is out of reach with a turning radius of %d tile(s)", turnRadius)}
+}
+
+// laneChangeLength is the forward distance needed to move sideways by offset with two
+// opposite arcs of the given radius. Beyond 2*radius the AV turns a full quarter each way and
+// the extra offset is covered square to the lane, so the length stops growing.
+func laneChangeLength(offset, radius float64) float64 {
+	if offset >= 2*radius {
+		return 2 * radius
+	}
+	return math.Sqrt(4*radius*offset - offset*offset)
+}
+
+// laneChange samples the S-curve starting at forward distance start and ending offset tiles
+// to the side, heading straight again
+func laneChange(start, offset, radius float64) [][2]float64 {
+	theta := math.Pi / 2
+	straight := offset - 2*radius
+	if offset < 2*radius {
+		theta = math.Acos(1 - offset/(2*radius))
+		straight = 0
+	}
+	length := 2*radius*math.Sin(theta) + straight*math.Cos(theta)
+
+	points := make([][2]float64, 0, 2*arcSegments+2)
+	for i := 0; i <= arcSegments; i++ {
+		phi := theta * float64(i) / arcSegments
+		points = append(points, [2]float64{start + radius*math.Sin(phi), radius * (1 - math.Cos(phi))})
+	}
+	for i := arcSegments; i >= 0; i-- {
+		phi := theta * float64(i) / arcSegments
+		points = append(points, [2]float64{start + length - radius*math.Sin(phi), offset - radius*(1-math.Cos(phi))})
+	}
+	return points
+}
+
+// trajectory turns a local polyline into grid points and walks it to find the swept tiles
+func (f pathFrame) trajectory(path [][2]float64, grid *Grid, directions laneGrid) domain.Trajectory {
+	trajectory := domain.Trajectory{Feasible: true}
+	for _, p := range path {
+		point := f.point(p[0], p[1])
+		point = domain.TrajectoryPoint{Row: roundTo(point.Row, 2), Col: roundTo(point.Col, 2)}
+		if n := len(trajectory.Points); n > 0 && trajectory.Points[n-1] == point {
+			continue // arcs meet, or the lane change starts at the spawn
+		}
+		trajectory.Points = append(trajectory.Points, point)
+	}
+
+	oncoming := oppositeDirections[f.heading]
+	seen := make(map[[2]int]bool)
+	sweep := func(point domain.TrajectoryPoint) bool {
+		row, col := int(math.Round(point.Row)), int(math.Round(point.Col))
+		if seen[[2]int{row, col}] {
+			return true
+		}
+		seen[[2]int{row, col}] = true
+
+		if !grid.InBounds(row, col) {
+			trajectory.Feasible = false
+			trajectory.Reason = fmt.Sprintf("path leaves the map at [%d,%d]", row, col)
+			return false
+		}
+		surface := grid.Surface(row, col)
+		lane := directions.at(grid, row, col)
+		trajectory.Tiles = append(trajectory.Tiles, domain.EnrichedCoordinate{
+			Coordinate:  domain.Coordinate{Row: row, Col: col},
+			Surface:     surface,
+			Orientation: lane,
+		})
+
+		rules := domain.RulesFor(surface)
+		switch {
+		case rules.Scan == domain.ScanStop:
+			trajectory.Feasible = false
+			trajectory.Reason = fmt.Sprintf("path hits %s at [%d,%d]", surface, row, col)
+			return false
+		case !rules.Road:
+			trajectory.LeavesRoad = true
+		case lane == oncoming:
+			trajectory.EntersOncoming = true
+		}
+		return true
+	}
+
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		steps := int(math.Ceil(math.Hypot(to[0]-from[0], to[1]-from[1]) / trajectoryStep))
+		for step := 0; step <= steps; step++ {
+			t := 1.0
+			if steps > 0 {
+				t = float64(step) / float64(steps)
+			}
+			if !sweep(f.point(from[0]+(to[0]-from[0])*t, from[1]+(to[1]-from[1])*t)) {
+				return trajectory
+			}
+		}
+	}
+	return trajectory
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
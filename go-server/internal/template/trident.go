@@ -0,0 +1,145 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// TridentGeometry controls where the trident zones sit relative to the ego spawn.
+// Templates may override any field in meta.trident; unset fields use the server defaults.
+type TridentGeometry struct {
+	Distance   int `json:"distance,omitempty"`    // tiles ahead of the ego where the zones start
+	Depth      int `json:"depth,omitempty"`       // forward steps covered by each zone
+	Scan       int `json:"scan,omitempty"`        // max tiles scanned sideways for zones B/C
+	TurnRadius int `json:"turn_radius,omitempty"` // minimum AV turning radius for swerve paths
+}
+
+// DefaultTridentGeometry reads the server-wide defaults from the environment
+func DefaultTridentGeometry() TridentGeometry {
+	geometry := TridentGeometry{Distance: 3, Depth: 3, Scan: 5, TurnRadius: 2}
+	for env, field := range map[string]*int{
+		"TRIDENT_ZONE_DISTANCE": &geometry.Distance,
+		"TRIDENT_ZONE_DEPTH":    &geometry.Depth,
+		"TRIDENT_ZONE_SCAN":     &geometry.Scan,
+		"TRIDENT_TURN_RADIUS":   &geometry.TurnRadius,
+	} {
+		if val := os.Getenv(env); val != "" {
+			if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+				*field = parsed
+			}
+		}
+	}
+	return geometry
+}
+
+// Or fills the unset fields from fallback
+func (g TridentGeometry) Or(fallback TridentGeometry) TridentGeometry {
+	if g.Distance == 0 {
+		g.Distance = fallback.Distance
+	}
+	if g.Depth == 0 {
+		g.Depth = fallback.Depth
+	}
+	if g.Scan == 0 {
+		g.Scan = fallback.Scan
+	}
+	if g.TurnRadius == 0 {
+		g.TurnRadius = fallback.TurnRadius
+	}
+	return g
+}
+
+// Validate rejects negative values (zero means "use the default")
+func (g TridentGeometry) Validate() error {
+	if g.Distance < 0 || g.Depth < 0 || g.Scan < 0 || g.TurnRadius < 0 {
+		return fmt.Errorf("meta.trident values must be positive, got distance=%d depth=%d scan=%d turn_radius=%d", g.Distance, g.Depth, g.Scan, g.TurnRadius)
+	}
+	return nil
+}
+
+// tridentGeometryFor resolves a template's geometry over the defaults
+func tridentGeometryFor(meta TemplateMeta, defaults TridentGeometry) TridentGeometry {
+	if meta.Trident == nil {
+		return defaults
+	}
+	return meta.Trident.Or(defaults)
+}
+
+// buildTridentZones lays out the three zones for a spawn. Zone A is the strip straight
+// ahead; zones B/C take, for every forward step, the first traversable tile sideways,
+// following each surface's scan rule (skip paint and medians, stop at buildings). Spawn
+// validation and zone calculation both go through here so they can never disagree.
+func buildTridentZones(
+	spawn domain.TridentSpawn,
+	geometry TridentGeometry,
+	grid *Grid,
+	directions laneGrid,
+) domain.TridentZones {
+	fRow, fCol, lRow, lCol, rRow, rCol := domain.CalculateTridentZones(spawn)
+
+	baseRow := spawn.Row + (fRow * geometry.Distance)
+	baseCol := spawn.Col + (fCol * geometry.Distance)
+
+	enrich := func(row, col int) domain.EnrichedCoordinate {
+		return domain.EnrichedCoordinate{
+			Coordinate:  domain.Coordinate{Row: row, Col: col},
+			Surface:     grid.Surface(row, col),
+			Orientation: directions.at(grid, row, col),
+		}
+	}
+
+	// Zone A: fixed strip forward, starting at the base tile itself
+	forward := make([]domain.EnrichedCoordinate, 0, geometry.Depth)
+	if grid.InBounds(baseRow, baseCol) {
+		forward = append(forward, enrich(baseRow, baseCol))
+		grid.Cast(baseRow, baseCol, fRow, fCol, geometry.Depth-1, func(row, col int, _ domain.SurfaceType) bool {
+			forward = append(forward, enrich(row, col))
+			return true
+		})
+	}
+
+	// Zone B/C: expand perpendicular from each forward step
+	side := func(perpRow, perpCol int) domain.TridentZone {
+		coords := make([]domain.EnrichedCoordinate, 0, geometry.Depth)
+		for i := 0; i < geometry.Depth; i++ {
+			fwdRow := baseRow + (fRow * i)
+			fwdCol := baseCol + (fCol * i)
+
+			grid.Cast(fwdRow, fwdCol, perpRow, perpCol, geometry.Scan, func(row, col int, surface domain.SurfaceType) bool {
+				switch domain.RulesFor(surface).Scan {
+				case domain.ScanStop:
+					return false // hit wall, stop scanning this direction
+				case domain.ScanSkip:
+					return true // skip yellow lines and medians
+				}
+				// Found traversable (road, sidewalk, verge...); one per forward step
+				coords = append(coords, enrich(row, col))
+				return false
+			})
+		}
+		return domain.TridentZone{Coordinates: coords}
+	}
+
+	return domain.TridentZones{
+		ZoneA: domain.TridentZone{Coordinates: forward},
+		ZoneB: side(lRow, lCol),
+		ZoneC: side(rRow, rCol),
+	}
+}
+
+// tridentComplete reports whether zones are usable: zone A is all road and every zone
+// has a tile for each forward step
+func tridentComplete(zones domain.TridentZones, geometry TridentGeometry) bool {
+	if len(zones.ZoneA.Coordinates) != geometry.Depth {
+		return false
+	}
+	for _, coord := range zones.ZoneA.Coordinates {
+		if !domain.RulesFor(coord.Surface).Road {
+			return false
+		}
+	}
+	return len(zones.ZoneB.Coordinates) == geometry.Depth && len(zones.ZoneC.Coordinates) == geometry.Depth
+}
@@ -0,0 +1,280 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+)
+
+// ValidationIssue is a single problem found in a template, optionally pinned to a tile
+type ValidationIssue struct {
+	Coordinate *domain.Coordinate `json:"coordinate,omitempty"`
+	Message    string             `json:"message"`
+}
+
+// ValidationReport collects every issue found in one template
+type ValidationReport struct {
+	Template string            `json:"template"` // meta id, falls back to name
+	Issues   []ValidationIssue `json:"issues"`
+}
+
+// ValidationError is returned when one or more templates fail validation
+type ValidationError struct {
+	Reports []*ValidationReport
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Reports))
+	for _, r := range e.Reports {
+		parts = append(parts, r.String())
+	}
+	return "invalid templates:\n" + strings.Join(parts, "\n")
+}
+
+func (r *ValidationReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+func (r *ValidationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "template %s: %d problem(s)", r.Template, len(r.Issues))
+	for _, issue := range r.Issues {
+		if issue.Coordinate != nil {
+			fmt.Fprintf(&b, "\n  [%d,%d] %s", issue.Coordinate.Row, issue.Coordinate.Col, issue.Message)
+		} else {
+			fmt.Fprintf(&b, "\n  %s", issue.Message)
+		}
+	}
+	return b.String()
+}
+
+func (r *ValidationReport) add(format string, args ...any) {
+	r.Issues = append(r.Issues, ValidationIssue{Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) addAt(row, col int, format string, args ...any) {
+	r.Issues = append(r.Issues, ValidationIssue{
+		Coordinate: &domain.Coordinate{Row: row, Col: col},
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// ValidateTemplates validates every template and returns a *ValidationError if any is broken
+func ValidateTemplates(templates []ContextTemplate) error {
+	var failed []*ValidationReport
+	for _, tpl := range templates {
+		if report := ValidateTemplate(tpl); report.HasIssues() {
+			failed = append(failed, report)
+		}
+	}
+	if len(failed) > 0 {
+		return &ValidationError{Reports: failed}
+	}
+	return nil
+}
+
+// ValidateTemplate checks grid shape, tile IDs, lane placement/continuity and spawn availability
+func ValidateTemplate(tpl ContextTemplate) *ValidationReport {
+	report := &ValidationReport{Template: tpl.Name}
+	meta, err := ParseMeta(tpl)
+	if err != nil {
+		report.add("meta is not valid JSON: %v", err)
+	} else if meta.ID != "" {
+		report.Template = meta.ID
+	}
+	switch meta.DrivingSide {
+	case "", domain.DrivingSideRight, domain.DrivingSideLeft:
+	default:
+		report.add("meta.driving_side must be %q or %q, got %q", domain.DrivingSideRight, domain.DrivingSideLeft, meta.DrivingSide)
+	}
+
+	// Grid shape
+	var grid [][]int
+	if err := json.Unmarshal(tpl.GridData, &grid); err != nil {
+		report.add("grid_data is not a 2D integer array: %v", err)
+		return report
+	}
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		report.add("grid_data is empty")
+		return report
+	}
+
+	height, width := len(grid), len(grid[0])
+	for row, cols := range grid {
+		if len(cols) != width {
+			report.addAt(row, 0, "ragged row: %d tiles, expected %d", len(cols), width)
+		}
+	}
+	if tpl.Height != height || tpl.Width != width {
+		report.add("declared size %dx%d does not match grid %dx%d", tpl.Width, tpl.Height, width, height)
+	}
+
+	// Tile IDs
+	for row, cols := range grid {
+		for col, tileID := range cols {
+			if _, exists := domain.TileRegistry[tileID]; !exists {
+				report.addAt(row, col, "unknown tile id %d", tileID)
+			}
+		}
+	}
+
+	// Stop here if the grid itself is unusable, lane checks would only add noise
+	if report.HasIssues() {
+		return report
+	}
+	dense, err := NewGrid(grid)
+	if err != nil {
+		report.add("%v", err)
+		return report
+	}
+
+	// Lane config
+	var laneConfig domain.LaneConfigMap
+	if tpl.LaneConfig != nil {
+		if err := json.Unmarshal(tpl.LaneConfig, &laneConfig); err != nil {
+			report.add("lane_config is not valid: %v", err)
+			return report
+		}
+	}
+
+	directions := make([]domain.Direction, 0, len(laneConfig))
+	for direction := range laneConfig {
+		directions = append(directions, direction)
+	}
+	sort.Slice(directions, func(i, j int) bool { return directions[i] < directions[j] })
+
+	laneAt := make(map[[2]int]bool)
+	for _, coords := range laneConfig {
+		for _, coord := range coords {
+			laneAt[coord] = true
+		}
+	}
+
+	for _, direction := range directions {
+		coords := laneConfig[direction]
+		switch direction {
+		case domain.DirectionNorth, domain.DirectionSouth, domain.DirectionEast, domain.DirectionWest:
+		default:
+			report.add("lane_config has unknown direction %q", direction)
+			continue
+		}
+
+		for _, coord := range coords {
+			row, col := coord[0], coord[1]
+			if !dense.InBounds(row, col) {
+				report.addAt(row, col, "lane %s is out of bounds", direction)
+				continue
+			}
+			// Lanes may cross anything on the carriageway, paint and tram tracks included
+			surface := dense.Surface(row, col)
+			if !domain.RulesFor(surface).Road {
+				report.addAt(row, col, "lane %s sits on %s tile %d (%s)",
+					direction, surface, grid[row][col], domain.TileRegistry[grid[row][col]].Name)
+			}
+		}
+
+		checkLaneContinuity(report, direction, coords, laneAt)
+	}
+
+	// Hand-written lanes must agree with the road layout unless the author opted out
+	if !meta.CustomLanes {
+		inferred, err := InferLaneConfig(grid, meta.DrivingSideOrDefault())
+		if err != nil {
+			report.add("lane inference failed: %v (set meta.custom_lanes to skip)", err)
+		} else {
+			report.Issues = append(report.Issues, CompareLaneConfigs(laneConfig, inferred)...)
+		}
+	}
+
+	// Spawn availability, for the side the lanes were drawn for and the reversed variant
+	if meta.Trident != nil {
+		if err := meta.Trident.Validate(); err != nil {
+			report.add("%v", err)
+			return report
+		}
+	}
+	geometry := tridentGeometryFor(meta, DefaultTridentGeometry())
+	drawnFor := meta.DrivingSideOrDefault()
+	if len(computeValidSpawns(dense, laneConfig, geometry)) == 0 {
+		report.add("no valid trident spawns (distance=%d depth=%d scan=%d)", geometry.Distance, geometry.Depth, geometry.Scan)
+	} else if len(computeValidSpawns(dense, ReverseLaneConfig(laneConfig), geometry)) == 0 {
+		report.add("no valid trident spawns when driving on the %s", drawnFor.Opposite())
+	}
+
+	for _, region := range meta.Regions {
+		if err := validateRegion(region, dense); err != nil {
+			report.add("meta.regions: %v", err)
+		}
+	}
+
+	// Signalised junctions need a lane running into them to carry a head
+	seenJunctions := make(map[string]bool, len(meta.Signals))
+	lanes := newLaneGrid(dense, laneConfig)
+	for _, junction := range meta.Signals {
+		if err := junction.Validate(dense); err != nil {
+			report.add("meta.signals: %v", err)
+			continue
+		}
+		if seenJunctions[junction.ID] {
+			report.add("meta.signals: junction %s is defined twice", junction.ID)
+		}
+		seenJunctions[junction.ID] = true
+
+		hasApproach := false
+		for _, head := range buildSignalHeads(junction, dense, lanes) {
+			hasApproach = hasApproach || head.Kind == domain.SignalKindVehicle
+		}
+		if !hasApproach {
+			report.add("meta.signals: no lane runs into junction %s", junction.ID)
+		}
+	}
+
+	return report
+}
+
+// checkLaneContinuity reports gaps along each line of a lane (rows for E/W, columns for N/S).
+// A gap is fine when other lanes take over on both sides of it, e.g. across a roundabout.
+func checkLaneContinuity(report *ValidationReport, direction domain.Direction, coords [][2]int, laneAt map[[2]int]bool) {
+	horizontal := direction == domain.DirectionEast || direction == domain.DirectionWest
+
+	// line index → positions along the direction of travel
+	lines := make(map[int][]int)
+	for _, coord := range coords {
+		if horizontal {
+			lines[coord[0]] = append(lines[coord[0]], coord[1])
+		} else {
+			lines[coord[1]] = append(lines[coord[1]], coord[0])
+		}
+	}
+
+	keys := make([]int, 0, len(lines))
+	for key := range lines {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	for _, line := range keys {
+		positions := lines[line]
+		sort.Ints(positions)
+		for i := 1; i < len(positions); i++ {
+			if positions[i]-positions[i-1] <= 1 {
+				continue
+			}
+			first, last := [2]int{line, positions[i-1] + 1}, [2]int{line, positions[i] - 1}
+			if !horizontal {
+				first, last = [2]int{positions[i-1] + 1, line}, [2]int{positions[i] - 1, line}
+			}
+			if laneAt[first] && laneAt[last] {
+				continue
+			}
+			if horizontal {
+				report.addAt(line, positions[i-1]+1, "lane %s is broken between cols %d and %d", direction, positions[i-1], positions[i])
+			} else {
+				report.addAt(positions[i-1]+1, line, "lane %s is broken between rows %d and %d", direction, positions[i-1], positions[i])
+			}
+		}
+	}
+}
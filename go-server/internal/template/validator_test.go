@@ -0,0 +1,176 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/direwen/go-server/internal/shared/domain"
+	"gorm.io/datatypes"
+)
+
+func readTestTemplate(t *testing.T) ContextTemplate {
+	t.Helper()
+	tpl, err := ReadTemplateFile(filepath.Join("..", "..", DefaultTemplateDir, "tpl_005_arterial.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tpl
+}
+
+// editGrid rewrites a template's grid_data
+func editGrid(t *testing.T, tpl *ContextTemplate, edit func(grid [][]int) [][]int) {
+	t.Helper()
+	var grid [][]int
+	if err := json.Unmarshal(tpl.GridData, &grid); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(edit(grid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.GridData = datatypes.JSON(data)
+}
+
+// editMeta rewrites one field of a template's meta
+func editMeta(t *testing.T, tpl *ContextTemplate, key string, value any) {
+	t.Helper()
+	var meta map[string]any
+	if err := json.Unmarshal(tpl.Meta, &meta); err != nil {
+		t.Fatal(err)
+	}
+	meta[key] = value
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.Meta = datatypes.JSON(data)
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(t *testing.T, tpl *ContextTemplate)
+		want []string // substrings of the expected issues, none when empty
+	}{
+		{
+			name: "shipped map",
+			edit: func(t *testing.T, tpl *ContextTemplate) {},
+		},
+		{
+			name: "grid is not an array",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				tpl.GridData = datatypes.JSON(`{"rows": 3}`)
+			},
+			want: []string{"grid_data is not a 2D integer array"},
+		},
+		{
+			name: "empty grid",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				tpl.GridData = datatypes.JSON(`[]`)
+			},
+			want: []string{"grid_data is empty"},
+		},
+		{
+			name: "ragged row",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				editGrid(t, tpl, func(grid [][]int) [][]int {
+					grid[2] = grid[2][:len(grid[2])-1]
+					return grid
+				})
+			},
+			want: []string{"ragged row"},
+		},
+		{
+			name: "declared size differs",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				tpl.Width++
+			},
+			want: []string{"does not match grid"},
+		},
+		{
+			name: "unknown tile",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				editGrid(t, tpl, func(grid [][]int) [][]int {
+					grid[0][0] = 999
+					return grid
+				})
+			},
+			want: []string{"unknown tile id 999"},
+		},
+		{
+			name: "unknown driving side",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				editMeta(t, tpl, "driving_side", "middle")
+			},
+			want: []string{"meta.driving_side must be"},
+		},
+		{
+			name: "lane config is not a map",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				tpl.LaneConfig = datatypes.JSON(`[1, 2]`)
+			},
+			want: []string{"lane_config is not valid"},
+		},
+		{
+			name: "lane off the road and against the inferred lanes",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				var lanes domain.LaneConfigMap
+				if err := json.Unmarshal(tpl.LaneConfig, &lanes); err != nil {
+					t.Fatal(err)
+				}
+				lanes[domain.DirectionEast] = append(lanes[domain.DirectionEast], [2]int{0, 0})
+				data, err := json.Marshal(lanes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tpl.LaneConfig = datatypes.JSON(data)
+			},
+			want: []string{"lane E sits on", "0,0"},
+		},
+		{
+			name: "custom lanes skip inference",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				editMeta(t, tpl, "custom_lanes", true)
+			},
+		},
+		{
+			name: "unknown lane direction",
+			edit: func(t *testing.T, tpl *ContextTemplate) {
+				tpl.LaneConfig = datatypes.JSON(`{"up": [[5, 0]]}`)
+			},
+			want: []string{`unknown direction "up"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl := readTestTemplate(t)
+			tt.edit(t, &tpl)
+			report := ValidateTemplate(tpl)
+			if len(tt.want) == 0 {
+				if report.HasIssues() {
+					t.Fatalf("unexpected issues:\n%s", report)
+				}
+				return
+			}
+			text := report.String()
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("issues do not mention %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateShippedTemplates(t *testing.T) {
+	templates, err := ReadTemplateDir(filepath.Join("..", "..", DefaultTemplateDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateTemplates(templates); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Watch polls the template directory and reloads whenever a map file is added, removed or modified.
+// It blocks until ctx is cancelled, so run it in its own goroutine.
+func Watch(ctx context.Context, svc Service, dir string, interval time.Duration) {
+	last, err := dirFingerprint(dir)
+	if err != nil {
+		log.Printf("Template watcher: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := dirFingerprint(dir)
+		if err != nil {
+			log.Printf("Template watcher: %v", err)
+			continue
+		}
+		if current == last {
+			continue
+		}
+		last = current
+
+		report, err := svc.Reload(ctx)
+		if err != nil {
+			log.Printf("Template reload rejected, keeping current cache: %v", err)
+			continue
+		}
+		log.Println("Templates reloaded:", report)
+	}
+}
+
+// dirFingerprint summarises names, sizes and mtimes of the map files in dir
+func dirFingerprint(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
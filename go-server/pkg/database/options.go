@@ -26,6 +26,13 @@ func WithFilter(condition string, args ...interface{}) QueryOption {
 	}
 }
 
+// To include soft-deleted rows
+func WithUnscoped() QueryOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+}
+
 // To apply all query options to a GORM DB instance
 func ApplyOptions(db *gorm.DB, opts ...QueryOption) *gorm.DB {
 	for _, opt := range opts {